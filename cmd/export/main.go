@@ -0,0 +1,157 @@
+// Command export streams active salons with their services, amenities and
+// hours to a CSV, NDJSON or GeoJSON file, with the filters of the search
+// API. CSV and NDJSON exports can be imported back with cmd/import.
+//
+//	go run ./cmd/export [-format csv] [-o salons.csv] [-city Rosario] [-near "Palermo, Buenos Aires"]
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"beauty-salons/internal/config"
+	"beauty-salons/internal/domain"
+	"beauty-salons/internal/export"
+	"beauty-salons/internal/logging"
+	"beauty-salons/internal/repository"
+)
+
+func main() {
+	formatFlag := flag.String("format", "", "csv, ndjson or geojson (default: from the -o extension, else csv)")
+	output := flag.String("o", "", "output file (default: stdout)")
+	query := flag.String("q", "", "full-text query")
+	city := flag.String("city", "", "city")
+	category := flag.Int64("category", 0, "category ID")
+	priceRange := flag.Int("price-range", 0, "price range (1-4)")
+	minRating := flag.Float64("min-rating", 0, "minimum rating")
+	verified := flag.Bool("verified", false, "verified salons only")
+	bbox := flag.String("bbox", "", "bounding box as minLon,minLat,maxLon,maxLat")
+	near := flag.String("near", "", "place name, e.g. \"Palermo, Buenos Aires\"")
+	radius := flag.Float64("radius", 0, "radius in km around -near (default: the place's radius)")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Configuration error: %v\n", err)
+		os.Exit(1)
+	}
+
+	logger, err := logging.New(os.Stderr, cfg.Log.Format, cfg.Log.Level)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Logging error: %v\n", err)
+		os.Exit(1)
+	}
+	slog.SetDefault(logger)
+
+	format := export.FormatCSV
+	switch {
+	case *formatFlag != "":
+		format, err = export.ParseFormat(*formatFlag)
+	case *output != "":
+		format, err = export.ParseFormat(filepath.Ext(*output))
+	}
+	if err != nil {
+		fatal("unknown export format", err)
+	}
+
+	params := domain.SalonSearchParams{Query: *query, City: *city, PriceRange: domain.PriceRange(*priceRange)}
+	if *category != 0 {
+		params.CategoryID = category
+	}
+	if *minRating > 0 {
+		params.MinRating = minRating
+	}
+	if *verified {
+		params.IsVerified = verified
+	}
+	if *bbox != "" {
+		if params.BoundingBox, err = domain.ParseBoundingBox(*bbox); err != nil {
+			fatal("invalid bounding box", err)
+		}
+	}
+
+	repo, err := repository.NewPostgresRepository(cfg.Database.URL, repository.Config{
+		MaxOpenConns:    cfg.Database.MaxOpenConns,
+		MaxIdleConns:    cfg.Database.MaxIdleConns,
+		ConnMaxLifetime: cfg.Database.ConnMaxLifetime,
+		ConnMaxIdleTime: cfg.Database.ConnMaxIdleTime,
+		StatementTimeouts: repository.StatementTimeouts{
+			Search: cfg.Database.StatementTimeouts.Search,
+			Read:   cfg.Database.StatementTimeouts.Read,
+			Write:  cfg.Database.StatementTimeouts.Write,
+			Export: cfg.Database.StatementTimeouts.Export,
+		},
+		ReplicaURL:           cfg.Database.ReplicaURL,
+		ReplicaCheckInterval: cfg.Database.ReplicaCheckInterval,
+		MaxReplicaLag:        cfg.Database.MaxReplicaLag,
+		Ranking:              cfg.Ranking,
+	})
+	if err != nil {
+		fatal("failed to connect to PostgreSQL", err)
+	}
+	defer repo.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if *near != "" {
+		place, err := repo.FindPlace(ctx, *near)
+		if err != nil {
+			fatal("place lookup failed", err)
+		}
+		if place == nil {
+			fatal("unknown place", fmt.Errorf("no place matches %q", *near))
+		}
+		params.Location = &place.Centroid
+		params.RadiusKm = &place.RadiusKm
+		if *radius > 0 {
+			params.RadiusKm = radius
+		}
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		if out, err = os.Create(*output); err != nil {
+			fatal("failed to create output file", err)
+		}
+	}
+
+	w, err := export.NewWriter(out, format)
+	if err != nil {
+		fatal("unknown export format", err)
+	}
+	count := 0
+	err = repo.ExportSalons(ctx, params, func(salons []domain.Salon) error {
+		for i := range salons {
+			if err := w.Write(&salons[i]); err != nil {
+				return err
+			}
+		}
+		count += len(salons)
+		return w.Flush()
+	})
+	if err == nil {
+		err = w.Close()
+	}
+	if out != os.Stdout {
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		fatal("export failed", err)
+	}
+	slog.Info("salons exported", "format", format, "salons", count)
+}
+
+// fatal logs an unrecoverable error and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
@@ -0,0 +1,86 @@
+// Command geocode fills in the coordinates of salons that have none, or
+// whose address changed, using the geocoders configured by
+// GEOCODER_PROVIDERS. Results are cached in PostgreSQL, so re-running it is
+// cheap. Salons that failed are retried; those not found are not.
+//
+//	go run ./cmd/geocode [-batch 100]
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"beauty-salons/internal/config"
+	"beauty-salons/internal/geocode"
+	"beauty-salons/internal/logging"
+	"beauty-salons/internal/repository"
+)
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Configuration error: %v\n", err)
+		os.Exit(1)
+	}
+
+	batchSize := flag.Int("batch", cfg.Geocoding.BatchSize, "salons read per query")
+	flag.Parse()
+
+	logger, err := logging.New(os.Stderr, cfg.Log.Format, cfg.Log.Level)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Logging error: %v\n", err)
+		os.Exit(1)
+	}
+	slog.SetDefault(logger)
+
+	repo, err := repository.NewPostgresRepository(cfg.Database.URL, repository.Config{
+		MaxOpenConns:    cfg.Database.MaxOpenConns,
+		MaxIdleConns:    cfg.Database.MaxIdleConns,
+		ConnMaxLifetime: cfg.Database.ConnMaxLifetime,
+		ConnMaxIdleTime: cfg.Database.ConnMaxIdleTime,
+		StatementTimeouts: repository.StatementTimeouts{
+			Search: cfg.Database.StatementTimeouts.Search,
+			Read:   cfg.Database.StatementTimeouts.Read,
+			Write:  cfg.Database.StatementTimeouts.Write,
+			Export: cfg.Database.StatementTimeouts.Export,
+		},
+		Ranking: cfg.Ranking,
+	})
+	if err != nil {
+		fatal("failed to connect to PostgreSQL", err)
+	}
+	defer repo.Close()
+
+	geocoder, err := geocode.New(cfg.Geocoding.GeocoderConfig(), repo)
+	if err != nil {
+		fatal("failed to configure geocoding", err)
+	}
+	if geocoder == nil {
+		fatal("no geocoder configured", fmt.Errorf("set GEOCODER_PROVIDERS (gazetteer and/or nominatim)"))
+	}
+
+	// Ctrl+C stops after the current salon; the rest stay pending
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	slog.Info("geocoding salons", "geocoder", geocoder.Name())
+	stats, err := geocode.Backfill(ctx, repo, geocoder, *batchSize)
+	fmt.Println(stats)
+	if err != nil {
+		fatal("geocoding stopped", err)
+	}
+	if stats.Located > 0 {
+		fmt.Println("Run POST /api/v1/admin/sync to update the search index.")
+	}
+}
+
+// fatal logs an unrecoverable error and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
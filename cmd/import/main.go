@@ -0,0 +1,142 @@
+// Command import upserts salons with their services, amenities and hours
+// from CSV or NDJSON files (see salons.example.csv), then indexes them in
+// Elasticsearch. Rows that fail validation are listed and skipped.
+//
+//	go run ./cmd/import [-dry-run] [-no-index] [-batch 100] [-format csv] salons.csv
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"beauty-salons/internal/config"
+	"beauty-salons/internal/importer"
+	"beauty-salons/internal/logging"
+	"beauty-salons/internal/repository"
+	"beauty-salons/internal/search"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "validate and write in a transaction that is rolled back")
+	noIndex := flag.Bool("no-index", false, "don't index the imported salons (run a sync later)")
+	batchSize := flag.Int("batch", importer.DefaultBatchSize, "salons written per transaction")
+	formatFlag := flag.String("format", "", "csv or ndjson (default: from the file extension)")
+	report := flag.Bool("json", false, "print the report as JSON")
+	flag.Parse()
+	if flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: import [flags] salons.csv|salons.ndjson")
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+	path := flag.Arg(0)
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Configuration error: %v\n", err)
+		os.Exit(1)
+	}
+
+	logger, err := logging.New(os.Stderr, cfg.Log.Format, cfg.Log.Level)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Logging error: %v\n", err)
+		os.Exit(1)
+	}
+	slog.SetDefault(logger)
+
+	var format importer.Format
+	if *formatFlag != "" {
+		if format, err = importer.ParseFormat(*formatFlag); err != nil {
+			fatal("unknown file format", err)
+		}
+	}
+	rows, err := importer.Load(path, format)
+	if err != nil {
+		fatal("failed to read import file", err)
+	}
+
+	repo, err := repository.NewPostgresRepository(cfg.Database.URL, repository.Config{
+		MaxOpenConns:    cfg.Database.MaxOpenConns,
+		MaxIdleConns:    cfg.Database.MaxIdleConns,
+		ConnMaxLifetime: cfg.Database.ConnMaxLifetime,
+		ConnMaxIdleTime: cfg.Database.ConnMaxIdleTime,
+		StatementTimeouts: repository.StatementTimeouts{
+			Search: cfg.Database.StatementTimeouts.Search,
+			Read:   cfg.Database.StatementTimeouts.Read,
+			Write:  cfg.Database.StatementTimeouts.Write,
+			Export: cfg.Database.StatementTimeouts.Export,
+		},
+		Ranking: cfg.Ranking,
+	})
+	if err != nil {
+		fatal("failed to connect to PostgreSQL", err)
+	}
+	defer repo.Close()
+
+	var index importer.Indexer
+	if !*noIndex && !*dryRun {
+		esClient, err := search.NewElasticsearchClient(search.Config{
+			Addresses:          cfg.Elasticsearch.Addresses,
+			CloudID:            cfg.Elasticsearch.CloudID,
+			APIKey:             cfg.Elasticsearch.APIKey,
+			Username:           cfg.Elasticsearch.Username,
+			Password:           cfg.Elasticsearch.Password,
+			CACertPath:         cfg.Elasticsearch.CACertPath,
+			InsecureSkipVerify: cfg.Elasticsearch.InsecureSkipVerify,
+
+			MaxRetries:      cfg.Elasticsearch.MaxRetries,
+			RetryBackoff:    cfg.Elasticsearch.RetryBackoff,
+			MaxRetryBackoff: cfg.Elasticsearch.MaxRetryBackoff,
+			RequestTimeout:  cfg.Elasticsearch.RequestTimeout,
+			Sniff:           cfg.Elasticsearch.Sniff,
+			SniffInterval:   cfg.Elasticsearch.SniffInterval,
+			Compress:        cfg.Elasticsearch.Compress,
+
+			Index:   cfg.Elasticsearch.Index,
+			Ranking: cfg.Ranking,
+		})
+		if err != nil {
+			fatal("failed to create Elasticsearch client", err)
+		}
+		defer esClient.Close()
+		index = esClient
+	}
+
+	// Ctrl+C rolls back the batch in progress; earlier batches stay committed
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Imported salons are read back for indexing right after their batch
+	// commits, before a read replica may have them
+	result, err := importer.Import(repository.WithPrimary(ctx), repo, index, rows, importer.Options{BatchSize: *batchSize, DryRun: *dryRun})
+	if *report {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(result)
+	} else {
+		for _, rowErr := range result.Errors {
+			fmt.Printf("line %d %s: %s\n", rowErr.Line, rowErr.Slug, rowErr.Error)
+		}
+		if result.IndexError != "" {
+			fmt.Printf("indexing failed, run POST /api/v1/admin/sync: %s\n", result.IndexError)
+		}
+		fmt.Println(result)
+	}
+	if err != nil {
+		fatal("import stopped", err)
+	}
+	if result.Failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// fatal logs an unrecoverable error and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
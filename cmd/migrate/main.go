@@ -0,0 +1,148 @@
+// Command migrate applies and reverts the embedded schema migrations
+// (migrations/*.sql) and shows which ones the database has.
+//
+//	go run ./cmd/migrate status
+//	go run ./cmd/migrate up
+//	go run ./cmd/migrate down [steps]      # default 1
+//	go run ./cmd/migrate baseline VERSION  # databases created by docker-entrypoint-initdb.d
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"text/tabwriter"
+
+	"beauty-salons/internal/config"
+	"beauty-salons/internal/logging"
+	"beauty-salons/internal/migrate"
+	"beauty-salons/internal/repository"
+	"beauty-salons/migrations"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: migrate status | up | down [steps] | baseline VERSION")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 || len(os.Args) > 3 {
+		usage()
+	}
+	command, arg := os.Args[1], ""
+	if len(os.Args) == 3 {
+		arg = os.Args[2]
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Configuration error: %v\n", err)
+		os.Exit(1)
+	}
+
+	logger, err := logging.New(os.Stderr, cfg.Log.Format, cfg.Log.Level)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Logging error: %v\n", err)
+		os.Exit(1)
+	}
+	slog.SetDefault(logger)
+
+	repo, err := repository.NewPostgresRepository(cfg.Database.URL, repository.Config{
+		MaxOpenConns:    cfg.Database.MaxOpenConns,
+		MaxIdleConns:    cfg.Database.MaxIdleConns,
+		ConnMaxLifetime: cfg.Database.ConnMaxLifetime,
+		ConnMaxIdleTime: cfg.Database.ConnMaxIdleTime,
+		StatementTimeouts: repository.StatementTimeouts{
+			Search: cfg.Database.StatementTimeouts.Search,
+			Read:   cfg.Database.StatementTimeouts.Read,
+			Write:  cfg.Database.StatementTimeouts.Write,
+			Export: cfg.Database.StatementTimeouts.Export,
+		},
+		Ranking: cfg.Ranking,
+	})
+	if err != nil {
+		fatal("failed to connect to PostgreSQL", err)
+	}
+	defer repo.Close()
+
+	migrator, err := migrate.New(repo.DB(), migrations.FS)
+	if err != nil {
+		fatal("failed to load migrations", err)
+	}
+
+	// Ctrl+C rolls back the migration in progress
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	switch {
+	case command == "status" && arg == "":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			fatal("failed to read migration status", err)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATE\tAPPLIED AT")
+		for _, s := range statuses {
+			appliedAt := "-"
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.Local().Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%03d\t%s\t%s\t%s\n", s.Version, s.Name, s.State(), appliedAt)
+		}
+		_ = w.Flush()
+
+	case command == "up" && arg == "":
+		applied, err := migrator.Up(ctx)
+		report("applied", applied)
+		if err != nil {
+			fatal("migration failed", err)
+		}
+
+	case command == "down":
+		steps := 1
+		if arg != "" {
+			if steps, err = strconv.Atoi(arg); err != nil || steps < 1 {
+				usage()
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		report("reverted", reverted)
+		if err != nil {
+			fatal("migration failed", err)
+		}
+
+	case command == "baseline" && arg != "":
+		version, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			usage()
+		}
+		recorded, err := migrator.Baseline(ctx, version)
+		report("recorded", recorded)
+		if err != nil {
+			fatal("baseline failed", err)
+		}
+
+	default:
+		usage()
+	}
+}
+
+// report prints the migrations a command went through
+func report(verb string, done []migrate.Migration) {
+	for _, m := range done {
+		fmt.Printf("%s %03d_%s\n", verb, m.Version, m.Name)
+	}
+	if len(done) == 0 {
+		fmt.Println("nothing to do")
+	}
+}
+
+// fatal logs an unrecoverable error and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
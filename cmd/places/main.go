@@ -0,0 +1,87 @@
+// Command places loads the named places that the near= search parameter
+// resolves, from CSV or GeoJSON files (see places.example.csv). Places are
+// upserted by kind, name and area; -replace deletes those not in the files.
+//
+//	go run ./cmd/places [-replace] places.csv [more.geojson ...]
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"beauty-salons/internal/config"
+	"beauty-salons/internal/domain"
+	"beauty-salons/internal/logging"
+	"beauty-salons/internal/places"
+	"beauty-salons/internal/repository"
+)
+
+func main() {
+	replace := flag.Bool("replace", false, "delete places that are not in the files")
+	flag.Parse()
+	if flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: places [-replace] file.csv|file.geojson ...")
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Configuration error: %v\n", err)
+		os.Exit(1)
+	}
+
+	logger, err := logging.New(os.Stderr, cfg.Log.Format, cfg.Log.Level)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Logging error: %v\n", err)
+		os.Exit(1)
+	}
+	slog.SetDefault(logger)
+
+	// Read every file before touching the table
+	var all []domain.Place
+	for _, path := range flag.Args() {
+		loaded, err := places.Load(path)
+		if err != nil {
+			fatal("failed to read places", err)
+		}
+		all = append(all, loaded...)
+	}
+
+	repo, err := repository.NewPostgresRepository(cfg.Database.URL, repository.Config{
+		MaxOpenConns:    cfg.Database.MaxOpenConns,
+		MaxIdleConns:    cfg.Database.MaxIdleConns,
+		ConnMaxLifetime: cfg.Database.ConnMaxLifetime,
+		ConnMaxIdleTime: cfg.Database.ConnMaxIdleTime,
+		StatementTimeouts: repository.StatementTimeouts{
+			Search: cfg.Database.StatementTimeouts.Search,
+			Read:   cfg.Database.StatementTimeouts.Read,
+			Write:  cfg.Database.StatementTimeouts.Write,
+			Export: cfg.Database.StatementTimeouts.Export,
+		},
+		Ranking: cfg.Ranking,
+	})
+	if err != nil {
+		fatal("failed to connect to PostgreSQL", err)
+	}
+	defer repo.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	n, err := repo.ImportPlaces(ctx, all, *replace)
+	if err != nil {
+		fatal("failed to import places", err)
+	}
+	fmt.Printf("%d places imported\n", n)
+}
+
+// fatal logs an unrecoverable error and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
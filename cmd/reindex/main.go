@@ -0,0 +1,144 @@
+// Command reindex compares the live Elasticsearch index mapping with the
+// versioned mapping in internal/search/mappings and, when they differ,
+// indexes every active salon from PostgreSQL into a new index, then points
+// the index alias at it.
+//
+//	go run ./cmd/reindex            # reindex only when the mapping changed
+//	go run ./cmd/reindex -check     # report; exit 1 when out of date
+//	go run ./cmd/reindex -force     # reindex anyway
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"beauty-salons/internal/config"
+	"beauty-salons/internal/domain"
+	"beauty-salons/internal/logging"
+	"beauty-salons/internal/repository"
+	"beauty-salons/internal/search"
+	"beauty-salons/internal/synonyms"
+)
+
+func main() {
+	check := flag.Bool("check", false, "only report whether the mapping is up to date (exit 1 when not)")
+	force := flag.Bool("force", false, "reindex even when the mapping is up to date")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Configuration error: %v\n", err)
+		os.Exit(1)
+	}
+
+	logger, err := logging.New(os.Stderr, cfg.Log.Format, cfg.Log.Level)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Logging error: %v\n", err)
+		os.Exit(1)
+	}
+	slog.SetDefault(logger)
+
+	// Inline synonyms are part of the mapping, so they must match the API's
+	rules := synonyms.Default()
+	if cfg.Search.SynonymsFile != "" {
+		if rules, err = synonyms.Load(cfg.Search.SynonymsFile); err != nil {
+			fatal("failed to load synonyms", err)
+		}
+	}
+
+	esClient, err := search.NewElasticsearchClient(search.Config{
+		Addresses:          cfg.Elasticsearch.Addresses,
+		CloudID:            cfg.Elasticsearch.CloudID,
+		APIKey:             cfg.Elasticsearch.APIKey,
+		Username:           cfg.Elasticsearch.Username,
+		Password:           cfg.Elasticsearch.Password,
+		CACertPath:         cfg.Elasticsearch.CACertPath,
+		InsecureSkipVerify: cfg.Elasticsearch.InsecureSkipVerify,
+
+		MaxRetries:      cfg.Elasticsearch.MaxRetries,
+		RetryBackoff:    cfg.Elasticsearch.RetryBackoff,
+		MaxRetryBackoff: cfg.Elasticsearch.MaxRetryBackoff,
+		RequestTimeout:  cfg.Elasticsearch.RequestTimeout,
+		Sniff:           cfg.Elasticsearch.Sniff,
+		SniffInterval:   cfg.Elasticsearch.SniffInterval,
+		Compress:        cfg.Elasticsearch.Compress,
+
+		Index:   cfg.Elasticsearch.Index,
+		Ranking: cfg.Ranking,
+
+		SynonymsPath: cfg.Elasticsearch.SynonymsPath,
+		Synonyms:     rules,
+	})
+	if err != nil {
+		fatal("failed to create Elasticsearch client", err)
+	}
+	defer esClient.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	status, err := esClient.CheckMapping(ctx)
+	if err != nil {
+		fatal("failed to check index mapping", err)
+	}
+	fmt.Println(status)
+	if *check {
+		if !status.InSync() {
+			os.Exit(1)
+		}
+		return
+	}
+	if status.InSync() && !*force {
+		return
+	}
+
+	repo, err := repository.NewPostgresRepository(cfg.Database.URL, repository.Config{
+		MaxOpenConns:    cfg.Database.MaxOpenConns,
+		MaxIdleConns:    cfg.Database.MaxIdleConns,
+		ConnMaxLifetime: cfg.Database.ConnMaxLifetime,
+		ConnMaxIdleTime: cfg.Database.ConnMaxIdleTime,
+		StatementTimeouts: repository.StatementTimeouts{
+			Search: cfg.Database.StatementTimeouts.Search,
+			Read:   cfg.Database.StatementTimeouts.Read,
+			Write:  cfg.Database.StatementTimeouts.Write,
+			Export: cfg.Database.StatementTimeouts.Export,
+		},
+		ReplicaURL:           cfg.Database.ReplicaURL,
+		ReplicaCheckInterval: cfg.Database.ReplicaCheckInterval,
+		MaxReplicaLag:        cfg.Database.MaxReplicaLag,
+		Ranking:              cfg.Ranking,
+	})
+	if err != nil {
+		fatal("failed to connect to PostgreSQL", err)
+	}
+	defer repo.Close()
+
+	// Searches keep using the old index until the new one is complete
+	count := 0
+	index, err := esClient.Rebuild(ctx, func(bulk func([]domain.Salon) error) error {
+		return repo.ExportSalons(ctx, domain.SalonSearchParams{}, func(salons []domain.Salon) error {
+			if err := bulk(salons); err != nil {
+				return err
+			}
+			count += len(salons)
+			slog.Info("indexed salons", "count", count)
+			return nil
+		})
+	})
+	if err != nil {
+		fatal("reindex failed, run it again", err)
+	}
+
+	fmt.Printf("reindexed %d salons into %s (alias %s) with mapping v%d\n", count, index, esClient.Index(), status.Expected.Version)
+}
+
+// fatal logs an unrecoverable error and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
@@ -0,0 +1,168 @@
+package analytics
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"beauty-salons/internal/domain"
+	"beauty-salons/internal/metrics"
+)
+
+// ===========================================
+// SEARCH ANALYTICS RECORDER
+// ===========================================
+// Handlers hand events to Record, which never blocks: events go into a
+// bounded channel and are dropped (and counted) when it is full.
+// Run drains the channel and writes events to the store in batches.
+
+// Store persists batches of events
+type Store interface {
+	InsertSearchEvents(ctx context.Context, events []domain.SearchEvent) error
+}
+
+// Config sizes the buffer and batching
+type Config struct {
+	BufferSize    int           // Events held in memory before new ones are dropped
+	BatchSize     int           // Events written per insert
+	FlushInterval time.Duration // Max time an event waits before being written
+}
+
+// Recorder buffers search events and writes them asynchronously
+type Recorder struct {
+	store         Store
+	events        chan domain.SearchEvent
+	batchSize     int
+	flushInterval time.Duration
+}
+
+// NewRecorder creates a recorder; call Run in a background worker
+func NewRecorder(store Store, cfg Config) *Recorder {
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = 1000
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = 2 * time.Second
+	}
+
+	return &Recorder{
+		store:         store,
+		events:        make(chan domain.SearchEvent, cfg.BufferSize),
+		batchSize:     cfg.BatchSize,
+		flushInterval: cfg.FlushInterval,
+	}
+}
+
+// Record queues an event without blocking. It returns false if the event
+// was dropped because the buffer is full. A nil recorder drops everything.
+func (r *Recorder) Record(e domain.SearchEvent) bool {
+	if r == nil {
+		return false
+	}
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now()
+	}
+
+	select {
+	case r.events <- e:
+		return true
+	default:
+		metrics.AnalyticsEvents.WithLabelValues("dropped").Inc()
+		return false
+	}
+}
+
+// Run writes events until stopping is closed, then flushes what is still
+// buffered. ctx bounds the final writes.
+func (r *Recorder) Run(ctx context.Context, stopping <-chan struct{}) {
+	ticker := time.NewTicker(r.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]domain.SearchEvent, 0, r.batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		r.write(ctx, batch)
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case e := <-r.events:
+			batch = append(batch, e)
+			if len(batch) >= r.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-stopping:
+			for {
+				select {
+				case e := <-r.events:
+					batch = append(batch, e)
+					if len(batch) >= r.batchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// write inserts one batch; failed batches are logged and discarded
+func (r *Recorder) write(ctx context.Context, batch []domain.SearchEvent) {
+	if err := r.store.InsertSearchEvents(ctx, batch); err != nil {
+		metrics.AnalyticsEvents.WithLabelValues("failed").Add(float64(len(batch)))
+		slog.Error("failed to write search events", "events", len(batch), "error", err)
+		return
+	}
+	metrics.AnalyticsEvents.WithLabelValues("written").Add(float64(len(batch)))
+}
+
+// NewSearchID returns a random identifier that ties clicks to a search
+func NewSearchID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// MaxWindow is the longest report window accepted
+const MaxWindow = 365 * 24 * time.Hour
+
+// ParseWindow parses a report window such as "24h", "7d" or "30d".
+// Go duration syntax is accepted, plus a whole-day "d" suffix.
+func ParseWindow(s string) (time.Duration, error) {
+	var d time.Duration
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid window %q", s)
+		}
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		parsed, err := time.ParseDuration(s)
+		if err != nil {
+			return 0, fmt.Errorf("invalid window %q", s)
+		}
+		d = parsed
+	}
+
+	if d <= 0 || d > MaxWindow {
+		return 0, fmt.Errorf("window %q must be between 1s and 365d", s)
+	}
+	return d, nil
+}
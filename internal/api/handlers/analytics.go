@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"beauty-salons/internal/analytics"
+	"beauty-salons/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Analytics report defaults
+const (
+	DefaultReportWindow = "7d"
+	DefaultReportLimit  = 20
+	MaxReportLimit      = 100
+	DefaultCTRPositions = 10
+)
+
+// recordSearch queues a search event and returns the ID clients echo when
+// reporting clicks. It returns "" when analytics is disabled.
+func (h *Handler) recordSearch(params domain.SalonSearchParams, backend string, total int, start time.Time) string {
+	if h.recorder == nil {
+		return ""
+	}
+
+	id := analytics.NewSearchID()
+	h.recorder.Record(domain.SearchEvent{
+		Type:      domain.EventSearch,
+		SearchID:  id,
+		Query:     params.Query,
+		Filters:   params.Filters(),
+		Backend:   backend,
+		TotalHits: total,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+		Page:      params.Page,
+		PageSize:  params.PageSize,
+	})
+	return id
+}
+
+// RecordClick records a click on a search result
+// POST /api/v1/search/click
+func (h *Handler) RecordClick(c *gin.Context) {
+	if h.recorder == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Search analytics is disabled"})
+		return
+	}
+
+	var req domain.ClickRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid click: " + err.Error()})
+		return
+	}
+
+	h.recorder.Record(domain.SearchEvent{
+		Type:     domain.EventClick,
+		SearchID: req.SearchID,
+		SalonID:  &req.SalonID,
+		Position: &req.Position,
+	})
+	c.Status(http.StatusAccepted)
+}
+
+// TopQueries reports the most frequent queries
+// GET /api/v1/admin/analytics/top-queries?window=7d&limit=20
+func (h *Handler) TopQueries(c *gin.Context) {
+	window, since, ok := reportWindow(c)
+	if !ok {
+		return
+	}
+	limit, ok := reportLimit(c, DefaultReportLimit)
+	if !ok {
+		return
+	}
+
+	stats, err := h.repo.TopQueries(c.Request.Context(), since, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, newReport(window, since, stats))
+}
+
+// ZeroResultQueries reports the most frequent queries that found nothing
+// GET /api/v1/admin/analytics/zero-results?window=7d&limit=20
+func (h *Handler) ZeroResultQueries(c *gin.Context) {
+	window, since, ok := reportWindow(c)
+	if !ok {
+		return
+	}
+	limit, ok := reportLimit(c, DefaultReportLimit)
+	if !ok {
+		return
+	}
+
+	stats, err := h.repo.ZeroResultQueries(c.Request.Context(), since, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, newReport(window, since, stats))
+}
+
+// ClickThroughRate reports click-through rate by result position
+// GET /api/v1/admin/analytics/ctr?window=7d&limit=10
+func (h *Handler) ClickThroughRate(c *gin.Context) {
+	window, since, ok := reportWindow(c)
+	if !ok {
+		return
+	}
+	positions, ok := reportLimit(c, DefaultCTRPositions)
+	if !ok {
+		return
+	}
+
+	rates, err := h.repo.ClickThroughByPosition(c.Request.Context(), since, positions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, newReport(window, since, rates))
+}
+
+// FilterUsage reports the most used search filters
+// GET /api/v1/admin/analytics/filters?window=7d&limit=20
+func (h *Handler) FilterUsage(c *gin.Context) {
+	window, since, ok := reportWindow(c)
+	if !ok {
+		return
+	}
+	limit, ok := reportLimit(c, DefaultReportLimit)
+	if !ok {
+		return
+	}
+
+	usage, err := h.repo.FilterUsage(c.Request.Context(), since, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, newReport(window, since, usage))
+}
+
+// reportWindow reads the window query parameter, writing a 400 if invalid
+func reportWindow(c *gin.Context) (string, time.Time, bool) {
+	window := c.DefaultQuery("window", DefaultReportWindow)
+	d, err := analytics.ParseWindow(window)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return "", time.Time{}, false
+	}
+	return window, time.Now().Add(-d), true
+}
+
+// reportLimit reads the limit query parameter, writing a 400 if invalid
+func reportLimit(c *gin.Context, def int) (int, bool) {
+	limitStr := c.Query("limit")
+	if limitStr == "" {
+		return def, true
+	}
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit < 1 || limit > MaxReportLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and " + strconv.Itoa(MaxReportLimit)})
+		return 0, false
+	}
+	return limit, true
+}
+
+func newReport(window string, since time.Time, items interface{}) domain.AnalyticsReport {
+	return domain.AnalyticsReport{
+		Since:  since,
+		Until:  time.Now(),
+		Window: window,
+		Items:  items,
+	}
+}
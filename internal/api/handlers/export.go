@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"beauty-salons/internal/domain"
+	"beauty-salons/internal/export"
+	"beauty-salons/internal/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportBatchTimeout bounds how long each batch of an export may take to
+// reach the client. It replaces the server's write timeout, which would
+// otherwise cut long exports off partway.
+const ExportBatchTimeout = 30 * time.Second
+
+// SalonExporter streams the salons matching a search in batches
+type SalonExporter interface {
+	ExportSalons(ctx context.Context, params domain.SalonSearchParams, fn func([]domain.Salon) error) error
+}
+
+// ExportSalons streams every active salon matching the search filters, with
+// its services, amenities and hours, from a PostgreSQL cursor. CSV and
+// NDJSON exports can be imported back with POST /api/v1/admin/import.
+// GET /api/v1/admin/export?format=csv|ndjson|geojson&city=...&near=...
+func (h *Handler) ExportSalons(c *gin.Context) {
+	formatStr := c.DefaultQuery("format", string(export.FormatCSV))
+	format, err := export.ParseFormat(formatStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// A malformed area would otherwise be ignored and export everything
+	if bbox := c.Query("bbox"); bbox != "" {
+		if _, err := domain.ParseBoundingBox(bbox); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if polygon := c.Query("polygon"); polygon != "" {
+		if _, err := domain.ParsePolygon(polygon); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	params := h.ParseSearchParams(c)
+	if _, ok := h.resolveNear(c, &params); !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	w, err := export.NewWriter(c.Writer, format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Each batch gets its own write deadline, so the export may take as
+	// long as it needs while a stalled client is still dropped
+	rc := http.NewResponseController(c.Writer)
+	extendDeadline := func() {
+		err := rc.SetWriteDeadline(time.Now().Add(ExportBatchTimeout))
+		if err != nil && !errors.Is(err, http.ErrNotSupported) {
+			logging.FromContext(ctx).Warn("could not extend the export write deadline", "error", err)
+		}
+	}
+	extendDeadline()
+
+	// Headers are only sent with the first batch, so a failure before any
+	// salon is written can still be reported as JSON
+	started := false
+	count := 0
+	err = h.exporter.ExportSalons(ctx, params, func(salons []domain.Salon) error {
+		extendDeadline()
+		if !started {
+			started = true
+			startExport(c, format)
+		}
+		for i := range salons {
+			if err := w.Write(&salons[i]); err != nil {
+				return err
+			}
+		}
+		count += len(salons)
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	})
+	if err != nil {
+		if !started {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Export failed: " + err.Error()})
+			return
+		}
+		// The status is already sent; the client sees a truncated file
+		logging.FromContext(ctx).Error("export failed", "format", format, "salons", count, "error", err)
+		return
+	}
+
+	if !started {
+		startExport(c, format)
+	}
+	if err := w.Close(); err != nil {
+		logging.FromContext(ctx).Error("export failed", "format", format, "salons", count, "error", err)
+		return
+	}
+	logging.FromContext(ctx).Info("salons exported", "format", format, "salons", count)
+}
+
+// startExport sends the headers of an export download
+func startExport(c *gin.Context, format export.Format) {
+	filename := fmt.Sprintf("salons-%s.%s", time.Now().UTC().Format("20060102"), format)
+	c.Header("Content-Type", format.ContentType())
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Status(http.StatusOK)
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"beauty-salons/internal/domain"
+	"beauty-salons/internal/logging"
+	"beauty-salons/internal/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Map marker and cluster limits
+const (
+	DefaultPinLimit     = 1000
+	MaxPinLimit         = 5000
+	DefaultClusterLimit = 1000
+	MaxClusterLimit     = 10000
+)
+
+// SearchPins returns lightweight map markers for the salons in a viewport
+// or polygon, using Elasticsearch with the same fallback as SearchSalons.
+// All other search filters apply.
+// GET /api/v1/search/pins?bbox=minLon,minLat,maxLon,maxLat&limit=...
+// GET /api/v1/search/pins?polygon={"type":"Polygon","coordinates":[...]}
+func (h *Handler) SearchPins(c *gin.Context) {
+	params := h.ParseSearchParams(c)
+	if err := viewportError(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	limit := mapLimit(c, DefaultPinLimit, MaxPinLimit)
+	place, ok := h.resolveNear(c, &params)
+	if !ok {
+		return
+	}
+
+	var pins []domain.SalonPin
+	var warnings []string
+	source, total, ok := h.mapSearch(c, params,
+		func(ctx context.Context) (total int, err error) {
+			pins, total, warnings, err = h.es.SearchPins(ctx, params, limit)
+			return total, err
+		},
+		func(ctx context.Context) (total int, err error) {
+			pins, total, err = h.repo.SearchPins(ctx, params, limit)
+			return total, err
+		},
+	)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.PinsResponse{
+		Pins:      pins,
+		Total:     int64(total),
+		Truncated: total > len(pins),
+		Source:    source,
+		Degraded:  source == "postgresql",
+		Near:      place,
+		Warnings:  warnings,
+	})
+}
+
+// SearchClusters groups the salons in a viewport or polygon into map tiles
+// for the given zoom level, with counts, centroids and the best rated salon
+// of each tile. All other search filters apply.
+// GET /api/v1/search/clusters?zoom=12&bbox=minLon,minLat,maxLon,maxLat
+func (h *Handler) SearchClusters(c *gin.Context) {
+	params := h.ParseSearchParams(c)
+
+	zoom, err := strconv.Atoi(c.Query("zoom"))
+	if err != nil || zoom < 0 || zoom > domain.MaxClusterZoom {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "zoom must be between 0 and " + strconv.Itoa(domain.MaxClusterZoom),
+		})
+		return
+	}
+	if err := viewportError(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	limit := mapLimit(c, DefaultClusterLimit, MaxClusterLimit)
+	place, ok := h.resolveNear(c, &params)
+	if !ok {
+		return
+	}
+
+	var clusters []domain.SalonCluster
+	var warnings []string
+	source, total, ok := h.mapSearch(c, params,
+		func(ctx context.Context) (total int, err error) {
+			clusters, total, warnings, err = h.es.SearchClusters(ctx, params, zoom, limit)
+			return total, err
+		},
+		func(ctx context.Context) (total int, err error) {
+			clusters, total, err = h.repo.SearchClusters(ctx, params, zoom, limit)
+			return total, err
+		},
+	)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.ClustersResponse{
+		Zoom:     zoom,
+		Clusters: clusters,
+		Total:    int64(total),
+		Source:   source,
+		Degraded: source == "postgresql",
+		Near:     place,
+		Warnings: warnings,
+	})
+}
+
+// mapSearch runs a map search on Elasticsearch, falling back to PostgreSQL
+// like SearchSalons. It returns the backend that answered, or writes an
+// error response and returns false.
+func (h *Handler) mapSearch(c *gin.Context, params domain.SalonSearchParams, esSearch, pgSearch func(ctx context.Context) (int, error)) (string, int, bool) {
+	ctx := c.Request.Context()
+	start := time.Now()
+
+	var total int
+	esErr := h.guardElasticsearch(ctx, func(esCtx context.Context) error {
+		var err error
+		total, err = esSearch(esCtx)
+		return err
+	})
+	if esErr == nil {
+		logSearch(ctx, params, "elasticsearch", total, start, false)
+		metrics.ObserveSearch("elasticsearch", total)
+		return "elasticsearch", total, true
+	}
+
+	// Client went away, nothing to fall back for
+	if ctx.Err() != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Search cancelled: " + ctx.Err().Error()})
+		return "", 0, false
+	}
+
+	reason := fallbackReason(esErr)
+	logging.FromContext(ctx).Warn("elasticsearch map search failed, falling back to PostgreSQL",
+		"reason", reason, "error", esErr)
+	metrics.SearchFallbacks.WithLabelValues(reason).Inc()
+
+	total, err := pgSearch(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Search failed: " + err.Error(),
+		})
+		return "", 0, false
+	}
+
+	logSearch(ctx, params, "postgresql", total, start, true)
+	metrics.ObserveSearch("postgresql", total)
+	return "postgresql", total, true
+}
+
+// mapLimit reads the limit query parameter, capped at max
+func mapLimit(c *gin.Context, def, max int) int {
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			return min(l, max)
+		}
+	}
+	return def
+}
+
+// viewportError reports why the request has no usable bbox or polygon
+func viewportError(c *gin.Context) error {
+	bboxStr, polygonStr := c.Query("bbox"), c.Query("polygon")
+	if bboxStr == "" && polygonStr == "" {
+		return errors.New("bbox or polygon is required")
+	}
+	if bboxStr != "" {
+		if _, err := domain.ParseBoundingBox(bboxStr); err != nil {
+			return err
+		}
+	}
+	if polygonStr != "" {
+		if _, err := domain.ParsePolygon(polygonStr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
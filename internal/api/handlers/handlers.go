@@ -1,36 +1,154 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	"beauty-salons/internal/analytics"
 	"beauty-salons/internal/domain"
+	"beauty-salons/internal/logging"
+	"beauty-salons/internal/metrics"
 	"beauty-salons/internal/repository"
 	"beauty-salons/internal/search"
 
 	"github.com/gin-gonic/gin"
 )
 
+// Default fallback policy for Elasticsearch searches
+const (
+	DefaultSearchTimeout    = 2 * time.Second
+	DefaultBreakerThreshold = 5
+	DefaultBreakerCooldown  = 30 * time.Second
+)
+
+// Default pagination limits
+const (
+	DefaultPageSize = 10
+	MaxPageSize     = 100
+)
+
 // Handler contains all HTTP handlers
 type Handler struct {
-	repo *repository.PostgresRepository
-	es   *search.ElasticsearchClient
+	repo     *repository.PostgresRepository
+	es       *search.ElasticsearchClient
+	exporter SalonExporter
+	database DatabaseChecker
+
+	breaker         *search.CircuitBreaker
+	searchTimeout   time.Duration
+	defaultPageSize int
+	maxPageSize     int
+	health          *healthTracker
+	recorder        *analytics.Recorder
+}
+
+// Option configures optional Handler behaviour
+type Option func(*Handler)
+
+// WithCircuitBreaker sets the breaker guarding Elasticsearch searches
+func WithCircuitBreaker(breaker *search.CircuitBreaker) Option {
+	return func(h *Handler) {
+		h.breaker = breaker
+	}
+}
+
+// WithSearchTimeout sets how long to wait for Elasticsearch before falling back to PostgreSQL
+func WithSearchTimeout(timeout time.Duration) Option {
+	return func(h *Handler) {
+		h.searchTimeout = timeout
+	}
+}
+
+// WithPageLimits sets the default and maximum number of results per page
+func WithPageLimits(defaultSize, maxSize int) Option {
+	return func(h *Handler) {
+		h.defaultPageSize = defaultSize
+		h.maxPageSize = maxSize
+	}
+}
+
+// WithAnalytics records every answered search and reported click
+func WithAnalytics(recorder *analytics.Recorder) Option {
+	return func(h *Handler) {
+		h.recorder = recorder
+	}
+}
+
+// WithExporter sets where exports read salons from (the repository by default)
+func WithExporter(exporter SalonExporter) Option {
+	return func(h *Handler) {
+		h.exporter = exporter
+	}
+}
+
+// WithDatabaseChecker sets the database /readyz checks (the repository by default)
+func WithDatabaseChecker(database DatabaseChecker) Option {
+	return func(h *Handler) {
+		h.database = database
+	}
 }
 
 // NewHandler creates a new handler instance
-func NewHandler(repo *repository.PostgresRepository, es *search.ElasticsearchClient) *Handler {
-	return &Handler{
-		repo: repo,
-		es:   es,
+func NewHandler(repo *repository.PostgresRepository, es *search.ElasticsearchClient, opts ...Option) *Handler {
+	h := &Handler{
+		repo:            repo,
+		es:              es,
+		exporter:        repo,
+		database:        repo,
+		breaker:         search.NewCircuitBreaker(DefaultBreakerThreshold, DefaultBreakerCooldown),
+		searchTimeout:   DefaultSearchTimeout,
+		defaultPageSize: DefaultPageSize,
+		maxPageSize:     MaxPageSize,
+		health:          newHealthTracker(),
+	}
+	for _, opt := range opts {
+		opt(h)
 	}
+	return h
 }
 
-// SearchSalons handles search requests using Elasticsearch
+// SearchSalons handles search requests using Elasticsearch, falling back to
+// PostgreSQL when the cluster is unavailable
 // GET /api/v1/search?q=...&city=...&category=...&min_rating=...&verified=...
 func (h *Handler) SearchSalons(c *gin.Context) {
 	params := h.ParseSearchParams(c)
+	place, ok := h.resolveNear(c, &params)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+	start := time.Now()
+
+	results, total, warnings, esErr := h.searchElasticsearch(ctx, params)
+	if esErr == nil {
+		logSearch(ctx, params, "elasticsearch", total, start, false)
+		metrics.ObserveSearch("elasticsearch", total)
+		response := domain.NewSearchResponse(results, int64(total), params)
+		response.Source = "elasticsearch"
+		response.Near = place
+		response.Warnings = warnings
+		response.SearchID = h.recordSearch(params, "elasticsearch", total, start)
+		c.JSON(http.StatusOK, response)
+		return
+	}
 
-	results, total, err := h.es.Search(c.Request.Context(), params)
+	// Client went away, nothing to fall back for
+	if ctx.Err() != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Search cancelled: " + ctx.Err().Error()})
+		return
+	}
+
+	reason := fallbackReason(esErr)
+	logging.FromContext(ctx).Warn("elasticsearch search failed, falling back to PostgreSQL",
+		"reason", reason, "error", esErr)
+	metrics.SearchFallbacks.WithLabelValues(reason).Inc()
+
+	pgResults, pgTotal, err := h.repo.SearchSalons(ctx, params)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Search failed: " + err.Error(),
@@ -38,17 +156,83 @@ func (h *Handler) SearchSalons(c *gin.Context) {
 		return
 	}
 
-	response := domain.NewSearchResponse(results, int64(total), params)
-	response.Source = "elasticsearch"
+	logSearch(ctx, params, "postgresql", pgTotal, start, true)
+	metrics.ObserveSearch("postgresql", pgTotal)
+	response := domain.NewSearchResponse(pgResults, int64(pgTotal), params)
+	response.Source = "postgresql"
+	response.Degraded = true
+	response.Near = place
+	response.SearchID = h.recordSearch(params, "postgresql", pgTotal, start)
 	c.JSON(http.StatusOK, response)
 }
 
+// searchElasticsearch runs a search through the circuit breaker with a timeout
+func (h *Handler) searchElasticsearch(ctx context.Context, params domain.SalonSearchParams) (results []domain.SalonSearchResult, total int, warnings []string, err error) {
+	err = h.guardElasticsearch(ctx, func(esCtx context.Context) error {
+		var err error
+		results, total, warnings, err = h.es.Search(esCtx, params)
+		return err
+	})
+	return results, total, warnings, err
+}
+
+// guardElasticsearch runs an Elasticsearch call through the circuit breaker
+// with the search timeout
+func (h *Handler) guardElasticsearch(ctx context.Context, call func(ctx context.Context) error) error {
+	if !h.breaker.Allow() {
+		return search.ErrCircuitOpen
+	}
+
+	esCtx, cancel := context.WithTimeout(ctx, h.searchTimeout)
+	defer cancel()
+
+	err := call(esCtx)
+	switch {
+	case err == nil:
+		h.breaker.RecordSuccess()
+	case ctx.Err() != nil:
+		// The caller gave up, which says nothing about cluster health
+		h.breaker.Release()
+	default:
+		h.breaker.RecordFailure()
+	}
+	return err
+}
+
+// logSearch writes one structured log line per answered search
+func logSearch(ctx context.Context, params domain.SalonSearchParams, backend string, total int, start time.Time, degraded bool) {
+	logging.FromContext(ctx).Info("search",
+		"params", params,
+		"backend", backend,
+		"hits", total,
+		"degraded", degraded,
+		"latency_ms", time.Since(start).Milliseconds(),
+	)
+}
+
+// fallbackReason classifies an Elasticsearch failure for metrics
+func fallbackReason(err error) string {
+	switch {
+	case errors.Is(err, search.ErrCircuitOpen):
+		return "circuit_open"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	default:
+		return "error"
+	}
+}
+
 // SearchSalonsPostgres handles search using PostgreSQL (for comparison)
 // GET /api/v1/search/postgres?q=...
 func (h *Handler) SearchSalonsPostgres(c *gin.Context) {
 	params := h.ParseSearchParams(c)
+	place, ok := h.resolveNear(c, &params)
+	if !ok {
+		return
+	}
+	start := time.Now()
 
-	salons, total, err := h.repo.SearchSalons(c.Request.Context(), params)
+	results, total, err := h.repo.SearchSalons(c.Request.Context(), params)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Search failed: " + err.Error(),
@@ -56,12 +240,81 @@ func (h *Handler) SearchSalonsPostgres(c *gin.Context) {
 		return
 	}
 
-	results := SalonsToSearchResults(salons)
+	logSearch(c.Request.Context(), params, "postgresql", total, start, false)
+	metrics.ObserveSearch("postgresql", total)
 	response := domain.NewSearchResponse(results, int64(total), params)
 	response.Source = "postgresql"
+	response.Near = place
+	response.SearchID = h.recordSearch(params, "postgresql", total, start)
 	c.JSON(http.StatusOK, response)
 }
 
+// CompareSearch runs the same search against both backends concurrently
+// and reports how their rankings differ
+// GET /api/v1/search/compare?q=...&k=...
+func (h *Handler) CompareSearch(c *gin.Context) {
+	params := h.ParseSearchParams(c)
+	if _, ok := h.resolveNear(c, &params); !ok {
+		return
+	}
+	ctx := c.Request.Context()
+
+	// Rankings are compared over the page, or its first k results
+	// (NewSearchComparison caps k at the page size)
+	k := params.PageSize
+	if kStr := c.Query("k"); kStr != "" {
+		if v, err := strconv.Atoi(kStr); err == nil && v > 0 {
+			k = v
+		}
+	}
+
+	var esResult, pgResult domain.BackendResult
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+		esResult = domain.BackendResult{Backend: "elasticsearch", Results: []domain.SalonSearchResult{}}
+		start := time.Now()
+		results, total, warnings, err := h.es.Search(ctx, params)
+		esResult.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+		if err != nil {
+			esResult.Error = err.Error()
+			return
+		}
+		esResult.Results = results
+		esResult.Total = int64(total)
+		esResult.Warnings = warnings
+	}()
+
+	go func() {
+		defer wg.Done()
+		pgResult = domain.BackendResult{Backend: "postgresql", Results: []domain.SalonSearchResult{}}
+		start := time.Now()
+		results, total, err := h.repo.SearchSalons(ctx, params)
+		pgResult.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+		if err != nil {
+			pgResult.Error = err.Error()
+			return
+		}
+		pgResult.Results = results
+		pgResult.Total = int64(total)
+	}()
+
+	wg.Wait()
+
+	if esResult.Error != "" && pgResult.Error != "" {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":         "Search failed on both backends",
+			"elasticsearch": esResult.Error,
+			"postgresql":    pgResult.Error,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.NewSearchComparison(esResult, pgResult, params, k))
+}
+
 // GetSalon retrieves a single salon by ID
 // GET /api/v1/salons/:id
 func (h *Handler) GetSalon(c *gin.Context) {
@@ -98,42 +351,33 @@ func (h *Handler) GetCategories(c *gin.Context) {
 func (h *Handler) SyncToElasticsearch(c *gin.Context) {
 	ctx := c.Request.Context()
 
-	// Get all salons from PostgreSQL
-	salons, err := h.repo.GetAllSalons(ctx)
+	start := time.Now()
+	status := "failed"
+	defer func() {
+		metrics.SyncDuration.WithLabelValues(status).Observe(time.Since(start).Seconds())
+	}()
+
+	// Load a new index from the export cursor (every salon field, details
+	// batch-loaded); searches keep the old index until it is complete
+	count := 0
+	_, err := h.es.Rebuild(ctx, func(bulk func([]domain.Salon) error) error {
+		return h.exporter.ExportSalons(ctx, domain.SalonSearchParams{}, func(salons []domain.Salon) error {
+			if err := bulk(salons); err != nil {
+				return err
+			}
+			count += len(salons)
+			return nil
+		})
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch salons: " + err.Error()})
-		return
-	}
-
-	// Enrich with services (in a real app, you'd batch this)
-	for i := range salons {
-		fullSalon, err := h.repo.GetSalonByID(ctx, salons[i].ID)
-		if err == nil {
-			salons[i].Services = fullSalon.Services
-			salons[i].Amenities = fullSalon.Amenities
-		}
-	}
-
-	// Delete and recreate index for clean sync
-	if err := h.es.DeleteIndex(ctx); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete index: " + err.Error()})
-		return
-	}
-
-	if err := h.es.CreateIndex(ctx); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create index: " + err.Error()})
-		return
-	}
-
-	// Bulk index all salons
-	if err := h.es.BulkIndexSalons(ctx, salons); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to index salons: " + err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sync salons: " + err.Error()})
 		return
 	}
 
+	status = "success"
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Sync completed successfully",
-		"count":   len(salons),
+		"count":   count,
 	})
 }
 
@@ -161,6 +405,18 @@ func (h *Handler) GetIndexStats(c *gin.Context) {
 	c.JSON(http.StatusOK, stats)
 }
 
+// ReloadSynonyms makes Elasticsearch re-read its synonyms file
+// POST /api/v1/admin/synonyms/reload
+func (h *Handler) ReloadSynonyms(c *gin.Context) {
+	result, err := h.es.ReloadSynonyms(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
 // Helper methods
 
 // ParseSearchParams extracts search parameters from the request query string
@@ -206,12 +462,25 @@ func (h *Handler) ParseSearchParams(c *gin.Context) domain.SalonSearchParams {
 			}
 		}
 	}
+	params.Near = strings.TrimSpace(c.Query("near"))
 	if radiusStr := c.Query("radius"); radiusStr != "" {
 		if r, err := strconv.ParseFloat(radiusStr, 64); err == nil {
 			params.RadiusKm = &r
 		}
 	}
 
+	// Map viewport; invalid values are ignored like other filters
+	if bboxStr := c.Query("bbox"); bboxStr != "" {
+		if box, err := domain.ParseBoundingBox(bboxStr); err == nil {
+			params.BoundingBox = box
+		}
+	}
+	if polygonStr := c.Query("polygon"); polygonStr != "" {
+		if polygon, err := domain.ParsePolygon(polygonStr); err == nil {
+			params.Polygon = polygon
+		}
+	}
+
 	// Sort option
 	if sortStr := c.Query("sort"); sortStr != "" {
 		params.SortBy = domain.SortOption(sortStr)
@@ -234,12 +503,43 @@ func (h *Handler) ParseSearchParams(c *gin.Context) domain.SalonSearchParams {
 		params.Page = 1
 	}
 	if params.PageSize <= 0 {
-		params.PageSize = 10
+		params.PageSize = h.defaultPageSize
+	}
+	if params.PageSize > h.maxPageSize {
+		params.PageSize = h.maxPageSize
 	}
 
 	return params
 }
 
+// resolveNear sets the search origin to the place named by the near
+// parameter, unless lat/lon were given. Without a radius parameter the
+// place's own radius applies. When the place is unknown or the lookup
+// fails, it writes the error response and returns false.
+func (h *Handler) resolveNear(c *gin.Context, params *domain.SalonSearchParams) (*domain.Place, bool) {
+	if params.Near == "" || params.Location != nil {
+		return nil, true
+	}
+
+	place, err := h.repo.FindPlace(c.Request.Context(), params.Near)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Place lookup failed: " + err.Error()})
+		return nil, false
+	}
+	if place == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown place: " + params.Near})
+		return nil, false
+	}
+
+	centroid := place.Centroid
+	params.Location = &centroid
+	if params.RadiusKm == nil && place.RadiusKm > 0 {
+		radius := place.RadiusKm
+		params.RadiusKm = &radius
+	}
+	return place, true
+}
+
 // SalonsToSearchResults wraps plain salons into SalonSearchResult (for PostgreSQL responses)
 func SalonsToSearchResults(salons []domain.Salon) []domain.SalonSearchResult {
 	results := make([]domain.SalonSearchResult, len(salons))
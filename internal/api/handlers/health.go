@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"beauty-salons/internal/search"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReadinessTimeout bounds how long /readyz waits for all dependency checks
+const ReadinessTimeout = 2 * time.Second
+
+// Dependency status values
+const (
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusReady    = "ready"
+	StatusDegraded = "degraded"
+	StatusNotReady = "not_ready"
+)
+
+// DatabaseChecker is the PostgreSQL state /readyz reports
+type DatabaseChecker interface {
+	Ping(ctx context.Context) error
+	ReplicaDB() *sql.DB
+	ReplicaHealthy() bool
+}
+
+// DependencyStatus reports the result of checking one dependency
+type DependencyStatus struct {
+	Status      string                 `json:"status"`
+	Critical    bool                   `json:"critical"`
+	LatencyMs   float64                `json:"latency_ms"`
+	Error       string                 `json:"error,omitempty"`
+	LastError   string                 `json:"last_error,omitempty"`
+	LastErrorAt *time.Time             `json:"last_error_at,omitempty"`
+	Details     map[string]interface{} `json:"details,omitempty"`
+}
+
+// ReadinessResponse is the body returned by /readyz
+type ReadinessResponse struct {
+	Status       string                      `json:"status"`
+	Dependencies map[string]DependencyStatus `json:"dependencies"`
+}
+
+// lastError remembers the most recent failure of a dependency
+type lastError struct {
+	message string
+	at      time.Time
+}
+
+// healthTracker keeps the last error per dependency across readiness probes
+type healthTracker struct {
+	mu     sync.Mutex
+	errors map[string]lastError
+}
+
+func newHealthTracker() *healthTracker {
+	return &healthTracker{errors: make(map[string]lastError)}
+}
+
+// record stores err as the latest failure of name and fills in the last error fields
+func (t *healthTracker) record(name string, status *DependencyStatus, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err != nil {
+		t.errors[name] = lastError{message: err.Error(), at: time.Now().UTC()}
+	}
+	if last, ok := t.errors[name]; ok {
+		at := last.at
+		status.LastError = last.message
+		status.LastErrorAt = &at
+	}
+}
+
+// Livez reports that the process is alive. It never checks dependencies,
+// so orchestrators only restart the container when the process is stuck.
+// GET /livez
+func (h *Handler) Livez(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "alive"})
+}
+
+// Readyz checks PostgreSQL and Elasticsearch and returns 503 when the API
+// cannot serve traffic. PostgreSQL is critical; Elasticsearch problems only
+// degrade the service because searches fall back to PostgreSQL, as they do
+// while the circuit breaker is open.
+// GET /readyz
+func (h *Handler) Readyz(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), ReadinessTimeout)
+	defer cancel()
+
+	var pg, es DependencyStatus
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+		pg = h.checkPostgres(ctx)
+	}()
+
+	go func() {
+		defer wg.Done()
+		es = h.checkElasticsearch(ctx)
+	}()
+
+	wg.Wait()
+
+	response := ReadinessResponse{
+		Status: StatusReady,
+		Dependencies: map[string]DependencyStatus{
+			"postgres":      pg,
+			"elasticsearch": es,
+		},
+	}
+
+	for _, dep := range response.Dependencies {
+		if dep.Status == StatusUp {
+			continue
+		}
+		if dep.Critical {
+			response.Status = StatusNotReady
+			break
+		}
+		response.Status = StatusDegraded
+	}
+
+	code := http.StatusOK
+	if response.Status == StatusNotReady {
+		code = http.StatusServiceUnavailable
+	}
+	c.JSON(code, response)
+}
+
+// checkPostgres pings the primary database and reports the read replica's state
+func (h *Handler) checkPostgres(ctx context.Context) DependencyStatus {
+	status := DependencyStatus{Status: StatusUp, Critical: true}
+	start := time.Now()
+
+	err := h.database.Ping(ctx)
+
+	status.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+	if err != nil {
+		status.Status = StatusDown
+		status.Error = err.Error()
+	}
+	// An unhealthy replica doesn't affect readiness: reads fall back to the primary
+	if h.database.ReplicaDB() != nil {
+		replica := StatusDown
+		if h.database.ReplicaHealthy() {
+			replica = StatusUp
+		}
+		status.Details = map[string]interface{}{"replica": replica}
+	}
+	h.health.record("postgres", &status, err)
+	return status
+}
+
+// checkElasticsearch verifies cluster health and that the salons index exists and has documents
+func (h *Handler) checkElasticsearch(ctx context.Context) DependencyStatus {
+	status := DependencyStatus{
+		Status:  StatusUp,
+		Details: map[string]interface{}{"breaker": h.breaker.State()},
+	}
+	start := time.Now()
+
+	err := func() error {
+		clusterStatus, err := h.es.ClusterStatus(ctx)
+		if err != nil {
+			return err
+		}
+		status.Details["cluster_status"] = clusterStatus
+		if clusterStatus == "red" {
+			return fmt.Errorf("cluster status is red")
+		}
+
+		count, err := h.es.CountDocuments(ctx)
+		if err != nil {
+			return err
+		}
+		status.Details["documents"] = count
+		if count == 0 {
+			return fmt.Errorf("index is empty, run a sync")
+		}
+		return nil
+	}()
+
+	status.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+	if err != nil {
+		status.Status = StatusDown
+		status.Error = err.Error()
+	} else if h.breaker.State() == search.BreakerOpen {
+		status.Status = StatusDegraded
+		status.Error = search.ErrCircuitOpen.Error()
+	}
+	h.health.record("elasticsearch", &status, err)
+	return status
+}
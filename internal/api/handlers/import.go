@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"beauty-salons/internal/importer"
+	"beauty-salons/internal/logging"
+	"beauty-salons/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaxImportBytes bounds the size of an uploaded import file
+const MaxImportBytes = 32 << 20
+
+// ImportSalons upserts salons from a CSV or NDJSON request body and indexes
+// them. The response is a per-row report; rows with errors are skipped.
+// POST /api/v1/admin/import?format=csv|ndjson&dry_run=true&batch_size=100
+func (h *Handler) ImportSalons(c *gin.Context) {
+	formatStr := c.Query("format")
+	if formatStr == "" {
+		formatStr = c.ContentType()
+	}
+	format, err := importer.ParseFormat(formatStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	opts := importer.Options{DryRun: c.Query("dry_run") == "true"}
+	if sizeStr := c.Query("batch_size"); sizeStr != "" {
+		if size, err := strconv.Atoi(sizeStr); err == nil {
+			opts.BatchSize = size
+		}
+	}
+
+	rows, err := importer.Parse(http.MaxBytesReader(c.Writer, c.Request.Body, MaxImportBytes), format)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Import file larger than " + strconv.Itoa(MaxImportBytes>>20) + " MiB"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid import file: " + err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	// Imported salons are read back for indexing right after their batch
+	// commits, before a read replica may have them
+	report, err := importer.Import(repository.WithPrimary(ctx), h.repo, h.es, rows, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Import failed: " + err.Error(), "report": report})
+		return
+	}
+
+	logging.FromContext(ctx).Info("salons imported", "report", report.String())
+	c.JSON(http.StatusOK, report)
+}
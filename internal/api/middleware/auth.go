@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"beauty-salons/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// principalKey is the gin context key holding the authenticated principal
+const principalKey = "auth.principal"
+
+// Authenticate resolves the caller's principal and stores it in the context.
+// Requests without credentials continue as anonymous (public role); requests
+// with invalid credentials are rejected with 401.
+func Authenticate(authn auth.Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, err := authn.Authenticate(c.Request)
+		switch {
+		case err == nil:
+		case errors.Is(err, auth.ErrNoCredentials):
+			principal = auth.Anonymous()
+		default:
+			c.Header("WWW-Authenticate", `Bearer realm="beauty-salons"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+			return
+		}
+
+		c.Set(principalKey, principal)
+		c.Next()
+	}
+}
+
+// CurrentPrincipal returns the principal set by Authenticate (anonymous if none)
+func CurrentPrincipal(c *gin.Context) *auth.Principal {
+	if v, ok := c.Get(principalKey); ok {
+		if p, ok := v.(*auth.Principal); ok {
+			return p
+		}
+	}
+	return auth.Anonymous()
+}
+
+// RequireRole allows the request only if the principal has one of the roles.
+// Anonymous callers get 401, authenticated callers without the role get 403.
+func RequireRole(roles ...auth.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal := CurrentPrincipal(c)
+		if principal.HasRole(roles...) {
+			c.Next()
+			return
+		}
+
+		if principal.Method == "anonymous" {
+			c.Header("WWW-Authenticate", `Bearer realm="beauty-salons"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+	}
+}
+
+// RequireSalonOwnership allows the request only if the principal may manage
+// the salon identified by the given route parameter (admins always may)
+func RequireSalonOwnership(param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		salonID, err := strconv.ParseInt(c.Param(param), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid salon ID"})
+			return
+		}
+
+		if !CurrentPrincipal(c).CanManageSalon(salonID) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "You do not manage this salon"})
+			return
+		}
+		c.Next()
+	}
+}
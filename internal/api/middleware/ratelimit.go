@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"beauty-salons/internal/logging"
+	"beauty-salons/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit limits requests per client with a token bucket. Authenticated
+// callers are keyed by subject, anonymous callers by client IP. Buckets are
+// namespaced by name, so routes sharing a name share a budget.
+// If the store fails the request is let through rather than rejected.
+func RateLimit(store ratelimit.Store, name string, limit ratelimit.Limit) gin.HandlerFunc {
+	policy := strconv.Itoa(limit.Burst) + ";w=" + strconv.Itoa(int(math.Ceil(float64(limit.Burst)*60/float64(max(limit.PerMinute, 1)))))
+
+	return func(c *gin.Context) {
+		res, err := store.Take(c.Request.Context(), name+":"+clientKey(c), limit)
+		if err != nil {
+			logging.FromContext(c.Request.Context()).Error("rate limit store failed, allowing request", "error", err)
+			c.Next()
+			return
+		}
+
+		c.Header("RateLimit-Policy", policy)
+		c.Header("RateLimit-Limit", strconv.Itoa(res.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+
+		if !res.Allowed {
+			c.Header("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Rate limit exceeded"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// clientKey identifies the caller for rate limiting
+func clientKey(c *gin.Context) string {
+	if p := CurrentPrincipal(c); p.Method != "anonymous" {
+		return p.Method + ":" + p.Subject
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// ceilSeconds rounds a duration up to whole seconds, as the headers require
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
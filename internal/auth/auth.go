@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+)
+
+// ===========================================
+// AUTHENTICATION & AUTHORIZATION
+// ===========================================
+// An Authenticator turns request credentials into a Principal.
+// Authorization is role based, plus an ownership check for salon owners.
+
+var (
+	// ErrNoCredentials means the request carried no credentials this authenticator understands
+	ErrNoCredentials = errors.New("no credentials")
+	// ErrInvalidCredentials means credentials were present but rejected
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Role grants access to a set of routes
+type Role string
+
+const (
+	RoleAdmin      Role = "admin"
+	RoleSalonOwner Role = "salon_owner"
+	RolePublic     Role = "public"
+)
+
+// IsValid checks that the role is one the API knows about
+func (r Role) IsValid() bool {
+	return r == RoleAdmin || r == RoleSalonOwner || r == RolePublic
+}
+
+// Principal is the authenticated caller
+type Principal struct {
+	Subject  string  `json:"subject"`
+	Roles    []Role  `json:"roles"`
+	SalonIDs []int64 `json:"salon_ids,omitempty"` // Salons a salon_owner may manage
+	Method   string  `json:"method"`              // api_key, jwt or anonymous
+}
+
+// Anonymous returns the principal used for requests without credentials
+func Anonymous() *Principal {
+	return &Principal{Subject: "anonymous", Roles: []Role{RolePublic}, Method: "anonymous"}
+}
+
+// HasRole reports whether the principal has any of the given roles.
+// Admins implicitly have every role.
+func (p *Principal) HasRole(roles ...Role) bool {
+	for _, have := range p.Roles {
+		if have == RoleAdmin {
+			return true
+		}
+		for _, want := range roles {
+			if have == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CanManageSalon reports whether the principal may modify the given salon
+func (p *Principal) CanManageSalon(salonID int64) bool {
+	if p.HasRole(RoleAdmin) {
+		return true
+	}
+	if !p.HasRole(RoleSalonOwner) {
+		return false
+	}
+	for _, id := range p.SalonIDs {
+		if id == salonID {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticator extracts and verifies credentials from a request
+type Authenticator interface {
+	// Authenticate returns ErrNoCredentials when the request has no credentials
+	// for this scheme, so other authenticators can be tried.
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+// Chain tries each authenticator in order until one recognizes the credentials
+type Chain []Authenticator
+
+// Authenticate implements Authenticator
+func (c Chain) Authenticate(r *http.Request) (*Principal, error) {
+	for _, a := range c {
+		p, err := a.Authenticate(r)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+		return p, err
+	}
+	return nil, ErrNoCredentials
+}
@@ -0,0 +1,245 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// JWTConfig configures bearer token verification.
+// HS256 tokens are verified with Secret, RS256 tokens with the keys in JWKSFile.
+type JWTConfig struct {
+	Secret   string        // Shared secret for HS256 (empty disables HS256)
+	JWKSFile string        // Path to a JSON Web Key Set with RSA keys (empty disables RS256)
+	Issuer   string        // Required "iss" claim, if set
+	Audience string        // Required "aud" claim, if set
+	Leeway   time.Duration // Allowed clock skew for exp/nbf
+}
+
+// JWTAuthenticator authenticates "Authorization: Bearer <jwt>" requests
+type JWTAuthenticator struct {
+	secret   []byte
+	rsaKeys  map[string]*rsa.PublicKey // by kid
+	issuer   string
+	audience string
+	leeway   time.Duration
+}
+
+// jwtHeader is the JOSE header of a token
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// jwtClaims are the claims the API understands
+type jwtClaims struct {
+	Subject   string          `json:"sub"`
+	Issuer    string          `json:"iss"`
+	Audience  json.RawMessage `json:"aud"` // string or array of strings
+	ExpiresAt *int64          `json:"exp"`
+	NotBefore *int64          `json:"nbf"`
+	Roles     []Role          `json:"roles"`
+	SalonIDs  []int64         `json:"salon_ids"`
+}
+
+// NewJWTAuthenticator creates a JWT authenticator, loading the JWKS file if configured
+func NewJWTAuthenticator(cfg JWTConfig) (*JWTAuthenticator, error) {
+	a := &JWTAuthenticator{
+		secret:   []byte(cfg.Secret),
+		rsaKeys:  map[string]*rsa.PublicKey{},
+		issuer:   cfg.Issuer,
+		audience: cfg.Audience,
+		leeway:   cfg.Leeway,
+	}
+
+	if cfg.JWKSFile != "" {
+		keys, err := loadJWKS(cfg.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		a.rsaKeys = keys
+	}
+
+	if len(a.secret) == 0 && len(a.rsaKeys) == 0 {
+		return nil, errors.New("jwt: either a HS256 secret or a JWKS file is required")
+	}
+
+	return a, nil
+}
+
+// Authenticate implements Authenticator
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return nil, ErrNoCredentials
+	}
+
+	claims, err := a.verify(strings.TrimSpace(token))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+
+	roles := claims.Roles
+	if len(roles) == 0 {
+		roles = []Role{RolePublic}
+	}
+
+	return &Principal{
+		Subject:  claims.Subject,
+		Roles:    roles,
+		SalonIDs: claims.SalonIDs,
+		Method:   "jwt",
+	}, nil
+}
+
+// verify checks the signature and standard claims of a compact JWT
+func (a *JWTAuthenticator) verify(token string) (*jwtClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("invalid header: %w", err)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.New("invalid signature encoding")
+	}
+	signed := []byte(parts[0] + "." + parts[1])
+
+	// The algorithm decides which key type is acceptable, never the other way
+	// around, so an RSA public key can't be abused as an HMAC secret
+	switch header.Alg {
+	case "HS256":
+		if len(a.secret) == 0 {
+			return nil, errors.New("HS256 tokens are not accepted")
+		}
+		mac := hmac.New(sha256.New, a.secret)
+		mac.Write(signed)
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return nil, errors.New("signature mismatch")
+		}
+	case "RS256":
+		key, ok := a.rsaKeys[header.Kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key id %q", header.Kid)
+		}
+		digest := sha256.Sum256(signed)
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+			return nil, errors.New("signature mismatch")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", header.Alg)
+	}
+
+	var claims jwtClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("invalid claims: %w", err)
+	}
+
+	now := time.Now()
+	if claims.ExpiresAt == nil {
+		return nil, errors.New("token has no expiry")
+	}
+	if now.After(time.Unix(*claims.ExpiresAt, 0).Add(a.leeway)) {
+		return nil, errors.New("token expired")
+	}
+	if claims.NotBefore != nil && now.Add(a.leeway).Before(time.Unix(*claims.NotBefore, 0)) {
+		return nil, errors.New("token not yet valid")
+	}
+	if a.issuer != "" && claims.Issuer != a.issuer {
+		return nil, errors.New("unexpected issuer")
+	}
+	if a.audience != "" && !audienceContains(claims.Audience, a.audience) {
+		return nil, errors.New("unexpected audience")
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("token has no subject")
+	}
+
+	return &claims, nil
+}
+
+// decodeSegment base64url-decodes and unmarshals a JWT segment
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// audienceContains handles "aud" as either a string or an array
+func audienceContains(raw json.RawMessage, want string) bool {
+	var single string
+	if err := json.Unmarshal(raw, &single); err == nil {
+		return single == want
+	}
+	var list []string
+	if err := json.Unmarshal(raw, &list); err == nil {
+		for _, aud := range list {
+			if aud == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// loadJWKS reads RSA public keys from a JSON Web Key Set file
+func loadJWKS(path string) (map[string]*rsa.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("jwt: failed to read JWKS file: %w", err)
+	}
+
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("jwt: failed to parse JWKS file: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: key %q has an invalid modulus", k.Kid)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: key %q has an invalid exponent", k.Kid)
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("jwt: no RSA signing keys found in %s", path)
+	}
+	return keys, nil
+}
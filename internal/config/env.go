@@ -0,0 +1,101 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// errInvalidEnv is wrapped by environment parse errors
+var errInvalidEnv = errors.New("invalid environment variables")
+
+// envReader overlays typed environment variables onto config fields,
+// collecting parse errors instead of stopping at the first one
+type envReader struct {
+	errs []string
+}
+
+func (e *envReader) lookup(key string) (string, bool) {
+	value, ok := os.LookupEnv(key)
+	if !ok || strings.TrimSpace(value) == "" {
+		return "", false
+	}
+	return strings.TrimSpace(value), true
+}
+
+func (e *envReader) fail(key, value, kind string) {
+	e.errs = append(e.errs, fmt.Sprintf("%s=%q is not a valid %s", key, value, kind))
+}
+
+func (e *envReader) string(key string, dst *string) {
+	if value, ok := e.lookup(key); ok {
+		*dst = value
+	}
+}
+
+func (e *envReader) int(key string, dst *int) {
+	if value, ok := e.lookup(key); ok {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			e.fail(key, value, "integer")
+			return
+		}
+		*dst = n
+	}
+}
+
+func (e *envReader) float(key string, dst *float64) {
+	if value, ok := e.lookup(key); ok {
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			e.fail(key, value, "number")
+			return
+		}
+		*dst = f
+	}
+}
+
+func (e *envReader) bool(key string, dst *bool) {
+	if value, ok := e.lookup(key); ok {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			e.fail(key, value, "boolean")
+			return
+		}
+		*dst = b
+	}
+}
+
+func (e *envReader) duration(key string, dst *time.Duration) {
+	if value, ok := e.lookup(key); ok {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			e.fail(key, value, "duration (e.g. 10s, 5m)")
+			return
+		}
+		*dst = d
+	}
+}
+
+// list parses a comma-separated value
+func (e *envReader) list(key string, dst *[]string) {
+	if value, ok := e.lookup(key); ok {
+		items := []string{}
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		*dst = items
+	}
+}
+
+func (e *envReader) err() error {
+	if len(e.errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w:\n  - %s", errInvalidEnv, strings.Join(e.errs, "\n  - "))
+}
@@ -0,0 +1,115 @@
+package domain
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ===========================================
+// Search Analytics
+// ===========================================
+
+// Search event types
+const (
+	EventSearch = "search"
+	EventClick  = "click"
+)
+
+// SearchEvent is one row of the search_events table: either a search that
+// was answered or a click on one of its results
+type SearchEvent struct {
+	Type      string            `json:"type"`
+	SearchID  string            `json:"search_id"`
+	Query     string            `json:"query,omitempty"`
+	Filters   map[string]string `json:"filters,omitempty"`
+	Backend   string            `json:"backend,omitempty"`
+	TotalHits int               `json:"total_hits"`
+	LatencyMs float64           `json:"latency_ms"`
+	Page      int               `json:"page,omitempty"`
+	PageSize  int               `json:"page_size,omitempty"`
+	SalonID   *int64            `json:"salon_id,omitempty"` // Clicked salon
+	Position  *int              `json:"position,omitempty"` // 1-based rank of the clicked result
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// ClickRequest is the body of POST /search/click
+type ClickRequest struct {
+	SearchID string `json:"search_id" binding:"required"`
+	SalonID  int64  `json:"salon_id" binding:"required,min=1"`
+	Position int    `json:"position" binding:"required,min=1"`
+}
+
+// NormalizeQuery lowercases and collapses whitespace so equivalent queries group together
+func NormalizeQuery(q string) string {
+	return strings.Join(strings.Fields(strings.ToLower(q)), " ")
+}
+
+// Filters returns the filters applied to a search, keyed by query parameter name
+func (p SalonSearchParams) Filters() map[string]string {
+	filters := make(map[string]string)
+	if p.City != "" {
+		filters["city"] = p.City
+	}
+	if p.CategoryID != nil {
+		filters["category"] = strconv.FormatInt(*p.CategoryID, 10)
+	}
+	if p.PriceRange != 0 {
+		filters["price_range"] = strconv.Itoa(int(p.PriceRange))
+	}
+	if p.MinRating != nil {
+		filters["min_rating"] = strconv.FormatFloat(*p.MinRating, 'f', -1, 64)
+	}
+	if p.IsVerified != nil {
+		filters["verified"] = strconv.FormatBool(*p.IsVerified)
+	}
+	if p.Location != nil {
+		filters["location"] = "true"
+	}
+	if p.RadiusKm != nil {
+		filters["radius_km"] = strconv.FormatFloat(*p.RadiusKm, 'f', -1, 64)
+	}
+	if p.BoundingBox != nil {
+		filters["bbox"] = "true"
+	}
+	if p.Polygon != nil {
+		filters["polygon"] = "true"
+	}
+	if p.SortBy != "" && p.SortBy != SortByRelevance {
+		filters["sort"] = string(p.SortBy)
+	}
+	return filters
+}
+
+// QueryStat aggregates searches for one normalized query
+type QueryStat struct {
+	Query      string    `json:"query" db:"query"`
+	Searches   int64     `json:"searches" db:"searches"`
+	AvgHits    float64   `json:"avg_hits" db:"avg_hits"`
+	AvgLatency float64   `json:"avg_latency_ms" db:"avg_latency_ms"`
+	Clicks     int64     `json:"clicks" db:"clicks"`
+	LastSeenAt time.Time `json:"last_seen_at" db:"last_seen_at"`
+}
+
+// PositionCTR is the click-through rate for one result position
+type PositionCTR struct {
+	Position    int     `json:"position" db:"position"`
+	Impressions int64   `json:"impressions" db:"impressions"`
+	Clicks      int64   `json:"clicks" db:"clicks"`
+	CTR         float64 `json:"ctr"`
+}
+
+// FilterUsage counts how often a filter value was used
+type FilterUsage struct {
+	Filter   string `json:"filter" db:"filter"`
+	Value    string `json:"value" db:"value"`
+	Searches int64  `json:"searches" db:"searches"`
+}
+
+// AnalyticsReport wraps a report with the window it covers
+type AnalyticsReport struct {
+	Since  time.Time   `json:"since"`
+	Until  time.Time   `json:"until"`
+	Window string      `json:"window"`
+	Items  interface{} `json:"items"`
+}
@@ -0,0 +1,153 @@
+package domain
+
+// ===========================================
+// Backend Comparison Types
+// ===========================================
+
+// BackendResult holds the outcome of running a search against one backend
+type BackendResult struct {
+	Backend   string              `json:"backend"`
+	Results   []SalonSearchResult `json:"results"`
+	Total     int64               `json:"total"`
+	LatencyMs float64             `json:"latency_ms"`
+	Error     string              `json:"error,omitempty"`
+	Warnings  []string            `json:"warnings,omitempty"`
+}
+
+// IDs returns the salon IDs in ranked order
+func (b BackendResult) IDs() []int64 {
+	ids := make([]int64, len(b.Results))
+	for i, r := range b.Results {
+		ids[i] = r.Salon.ID
+	}
+	return ids
+}
+
+// RankingMetrics describes how similar two ranked result lists are
+type RankingMetrics struct {
+	K          int      `json:"k"`
+	OverlapAtK float64  `json:"overlap_at_k"` // Fraction of the top-k shared by both lists
+	KendallTau *float64 `json:"kendall_tau"`  // Rank correlation of shared IDs (nil if fewer than 2)
+	OnlyInA    []int64  `json:"only_in_a"`
+	OnlyInB    []int64  `json:"only_in_b"`
+}
+
+// SearchComparison is the response of the side-by-side comparison endpoint
+type SearchComparison struct {
+	Query               string        `json:"query,omitempty"`
+	Elasticsearch       BackendResult `json:"elasticsearch"`
+	Postgres            BackendResult `json:"postgres"`
+	K                   int           `json:"k"`
+	OverlapAtK          float64       `json:"overlap_at_k"`
+	KendallTau          *float64      `json:"kendall_tau"`
+	OnlyInElasticsearch []int64       `json:"only_in_elasticsearch"`
+	OnlyInPostgres      []int64       `json:"only_in_postgres"`
+}
+
+// NewSearchComparison builds a comparison from the results of both backends.
+// Each backend returned one page, so k is at most the page size.
+func NewSearchComparison(es, pg BackendResult, params SalonSearchParams, k int) SearchComparison {
+	if params.PageSize > 0 && (k <= 0 || k > params.PageSize) {
+		k = params.PageSize
+	}
+	metrics := CompareRankings(es.IDs(), pg.IDs(), k)
+
+	return SearchComparison{
+		Query:               params.Query,
+		Elasticsearch:       es,
+		Postgres:            pg,
+		K:                   metrics.K,
+		OverlapAtK:          metrics.OverlapAtK,
+		KendallTau:          metrics.KendallTau,
+		OnlyInElasticsearch: metrics.OnlyInA,
+		OnlyInPostgres:      metrics.OnlyInB,
+	}
+}
+
+// CompareRankings computes overlap@k, Kendall tau and the set differences
+// between the top-k of two ranked ID lists.
+func CompareRankings(a, b []int64, k int) RankingMetrics {
+	if k <= 0 {
+		k = max(len(a), len(b))
+	}
+	a = topK(a, k)
+	b = topK(b, k)
+
+	metrics := RankingMetrics{
+		K:       k,
+		OnlyInA: difference(a, b),
+		OnlyInB: difference(b, a),
+	}
+
+	// Overlap is measured against the longer list, so two empty lists agree
+	denominator := min(k, max(len(a), len(b)))
+	if denominator == 0 {
+		metrics.OverlapAtK = 1
+	} else {
+		shared := len(a) - len(metrics.OnlyInA)
+		metrics.OverlapAtK = float64(shared) / float64(denominator)
+	}
+
+	metrics.KendallTau = kendallTau(a, b)
+	return metrics
+}
+
+// kendallTau computes Kendall's tau-a over the IDs present in both lists.
+// Returns nil when fewer than two IDs are shared.
+func kendallTau(a, b []int64) *float64 {
+	rankB := make(map[int64]int, len(b))
+	for i, id := range b {
+		rankB[id] = i
+	}
+
+	// Ranks in b of shared IDs, ordered by their rank in a
+	ranks := []int{}
+	for _, id := range a {
+		if r, ok := rankB[id]; ok {
+			ranks = append(ranks, r)
+		}
+	}
+
+	n := len(ranks)
+	if n < 2 {
+		return nil
+	}
+
+	concordant, discordant := 0, 0
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			if ranks[i] < ranks[j] {
+				concordant++
+			} else {
+				discordant++
+			}
+		}
+	}
+
+	tau := float64(concordant-discordant) / float64(n*(n-1)/2)
+	return &tau
+}
+
+// topK returns at most the first k IDs
+func topK(ids []int64, k int) []int64 {
+	if len(ids) > k {
+		return ids[:k]
+	}
+	return ids
+}
+
+// difference returns the IDs in a that are not in b, preserving order
+func difference(a, b []int64) []int64 {
+	inB := make(map[int64]bool, len(b))
+	for _, id := range b {
+		inB[id] = true
+	}
+
+	diff := []int64{}
+	for _, id := range a {
+		if !inB[id] {
+			diff = append(diff, id)
+		}
+	}
+	return diff
+}
@@ -0,0 +1,297 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ===========================================
+// Map Viewport Search
+// ===========================================
+
+// MaxPolygonVertices bounds the size of a polygon filter
+const MaxPolygonVertices = 1000
+
+// BoundingBox is a map viewport in WGS84 degrees
+type BoundingBox struct {
+	MinLon float64 `json:"min_lon"`
+	MinLat float64 `json:"min_lat"`
+	MaxLon float64 `json:"max_lon"`
+	MaxLat float64 `json:"max_lat"`
+}
+
+// ParseBoundingBox parses "minLon,minLat,maxLon,maxLat"
+func ParseBoundingBox(s string) (*BoundingBox, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return nil, errors.New("bbox must be minLon,minLat,maxLon,maxLat")
+	}
+
+	var v [4]float64
+	for i, part := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, fmt.Errorf("bbox: invalid number %q", part)
+		}
+		v[i] = f
+	}
+
+	box := &BoundingBox{MinLon: v[0], MinLat: v[1], MaxLon: v[2], MaxLat: v[3]}
+	if err := box.Validate(); err != nil {
+		return nil, err
+	}
+	return box, nil
+}
+
+// Validate checks the corners are valid coordinates in the right order
+func (b BoundingBox) Validate() error {
+	min := GeoPoint{Latitude: b.MinLat, Longitude: b.MinLon}
+	max := GeoPoint{Latitude: b.MaxLat, Longitude: b.MaxLon}
+	if !min.IsValid() || !max.IsValid() {
+		return errors.New("bbox coordinates out of range")
+	}
+	if b.MinLat > b.MaxLat {
+		return errors.New("bbox minLat must not exceed maxLat")
+	}
+	if b.MinLon > b.MaxLon {
+		return errors.New("bbox minLon must not exceed maxLon (boxes crossing the antimeridian are not supported)")
+	}
+	return nil
+}
+
+// String formats the box as accepted by ParseBoundingBox
+func (b BoundingBox) String() string {
+	return strconv.FormatFloat(b.MinLon, 'f', -1, 64) + "," +
+		strconv.FormatFloat(b.MinLat, 'f', -1, 64) + "," +
+		strconv.FormatFloat(b.MaxLon, 'f', -1, 64) + "," +
+		strconv.FormatFloat(b.MaxLat, 'f', -1, 64)
+}
+
+// Polygon is a GeoJSON Polygon geometry: linear rings of [lon, lat]
+// positions, the first being the outer boundary and the rest holes
+type Polygon struct {
+	Type        string        `json:"type"`
+	Coordinates [][][]float64 `json:"coordinates"`
+}
+
+// ParsePolygon parses and validates a GeoJSON Polygon geometry
+func ParsePolygon(s string) (*Polygon, error) {
+	var p Polygon
+	if err := json.Unmarshal([]byte(s), &p); err != nil {
+		return nil, fmt.Errorf("polygon is not valid GeoJSON: %w", err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Validate checks the geometry is a closed polygon with valid coordinates
+func (p Polygon) Validate() error {
+	if p.Type != "Polygon" {
+		return fmt.Errorf("polygon type must be \"Polygon\", got %q", p.Type)
+	}
+	if len(p.Coordinates) == 0 {
+		return errors.New("polygon has no rings")
+	}
+
+	vertices := 0
+	for i, ring := range p.Coordinates {
+		if len(ring) < 4 {
+			return fmt.Errorf("polygon ring %d needs at least 4 positions", i)
+		}
+		for _, pos := range ring {
+			if len(pos) != 2 {
+				return fmt.Errorf("polygon ring %d: positions must be [lon, lat]", i)
+			}
+			if !(GeoPoint{Latitude: pos[1], Longitude: pos[0]}).IsValid() {
+				return fmt.Errorf("polygon ring %d: coordinates out of range", i)
+			}
+		}
+		first, last := ring[0], ring[len(ring)-1]
+		if first[0] != last[0] || first[1] != last[1] {
+			return fmt.Errorf("polygon ring %d is not closed", i)
+		}
+		vertices += len(ring)
+	}
+	if vertices > MaxPolygonVertices {
+		return fmt.Errorf("polygon has %d positions, at most %d allowed", vertices, MaxPolygonVertices)
+	}
+	return nil
+}
+
+// GeoJSON returns the polygon as a GeoJSON string
+func (p Polygon) GeoJSON() string {
+	b, _ := json.Marshal(p)
+	return string(b)
+}
+
+// SalonPin is the minimal salon shown as a map marker
+type SalonPin struct {
+	ID       int64    `json:"id" db:"id"`
+	Name     string   `json:"name" db:"name"`
+	Location GeoPoint `json:"location"`
+	Rating   *float64 `json:"rating,omitempty" db:"rating"`
+}
+
+// PinsResponse contains the map markers matching a viewport search
+type PinsResponse struct {
+	Pins      []SalonPin `json:"pins"`
+	Total     int64      `json:"total"`
+	Truncated bool       `json:"truncated"` // More salons matched than were returned
+	Source    string     `json:"source,omitempty"`
+	Degraded  bool       `json:"degraded"`
+	Near      *Place     `json:"near,omitempty"`
+	Warnings  []string   `json:"warnings,omitempty"` // Why the pins may be incomplete
+}
+
+// ===========================================
+// Map Clusters
+// ===========================================
+
+// MaxClusterZoom is the deepest zoom level clusters can be computed for
+// (the geotile_grid precision limit)
+const MaxClusterZoom = 29
+
+// SalonCluster is a map tile with the number of matching salons in it
+type SalonCluster struct {
+	Key      string      `json:"key"` // Tile as "zoom/x/y"
+	Count    int64       `json:"count"`
+	Centroid GeoPoint    `json:"centroid"` // Mean location of the salons
+	Bounds   BoundingBox `json:"bounds"`
+	TopSalon *SalonPin   `json:"top_salon,omitempty"` // Best rated salon in the tile
+}
+
+// ClustersResponse contains the clusters of a map search
+type ClustersResponse struct {
+	Zoom     int            `json:"zoom"`
+	Clusters []SalonCluster `json:"clusters"`
+	Total    int64          `json:"total"` // Matching salons, including those outside the returned clusters
+	Source   string         `json:"source,omitempty"`
+	Degraded bool           `json:"degraded"`
+	Near     *Place         `json:"near,omitempty"`
+	Warnings []string       `json:"warnings,omitempty"` // Why the clusters may be incomplete
+}
+
+// TileKey formats a Web Mercator tile as "zoom/x/y", the geotile_grid key
+func TileKey(zoom, x, y int) string {
+	return fmt.Sprintf("%d/%d/%d", zoom, x, y)
+}
+
+// ParseTileKey parses a "zoom/x/y" tile key
+func ParseTileKey(key string) (zoom, x, y int, err error) {
+	parts := strings.Split(key, "/")
+	if len(parts) != 3 {
+		return 0, 0, 0, fmt.Errorf("invalid tile key %q", key)
+	}
+
+	var v [3]int
+	for i, part := range parts {
+		if v[i], err = strconv.Atoi(part); err != nil {
+			return 0, 0, 0, fmt.Errorf("invalid tile key %q", key)
+		}
+	}
+
+	zoom, x, y = v[0], v[1], v[2]
+	if zoom < 0 || zoom > MaxClusterZoom {
+		return 0, 0, 0, fmt.Errorf("invalid tile key %q: zoom out of range", key)
+	}
+	if n := 1 << zoom; x < 0 || x >= n || y < 0 || y >= n {
+		return 0, 0, 0, fmt.Errorf("invalid tile key %q: tile out of range", key)
+	}
+	return zoom, x, y, nil
+}
+
+// TileBounds returns the area covered by a Web Mercator tile
+func TileBounds(zoom, x, y int) BoundingBox {
+	n := float64(int(1) << zoom)
+	tileLat := func(y float64) float64 {
+		return math.Atan(math.Sinh(math.Pi*(1-2*y/n))) * 180 / math.Pi
+	}
+	return BoundingBox{
+		MinLon: float64(x)/n*360 - 180,
+		MinLat: tileLat(float64(y + 1)),
+		MaxLon: float64(x+1)/n*360 - 180,
+		MaxLat: tileLat(float64(y)),
+	}
+}
+
+// ===========================================
+// Geocoding
+// ===========================================
+
+// GeocodeStatus records how a salon's coordinates were obtained
+type GeocodeStatus string
+
+const (
+	GeocodePending  GeocodeStatus = "pending"   // Address not geocoded yet, or changed since
+	GeocodeOK       GeocodeStatus = "ok"        // Coordinates found by a geocoder
+	GeocodeNotFound GeocodeStatus = "not_found" // No geocoder knows the address
+	GeocodeFailed   GeocodeStatus = "failed"    // The geocoder errored; retried by the next backfill
+	GeocodeManual   GeocodeStatus = "manual"    // Coordinates set by whoever wrote the salon
+)
+
+// IsValid checks the status is one of the known values
+func (s GeocodeStatus) IsValid() bool {
+	switch s {
+	case GeocodePending, GeocodeOK, GeocodeNotFound, GeocodeFailed, GeocodeManual:
+		return true
+	}
+	return false
+}
+
+// ===========================================
+// Places
+// ===========================================
+
+// PlaceKind classifies a named place
+type PlaceKind string
+
+const (
+	PlaceCity         PlaceKind = "city"
+	PlaceNeighborhood PlaceKind = "neighborhood"
+	PlaceLandmark     PlaceKind = "landmark"
+)
+
+// IsValid checks the kind is one of the known values
+func (k PlaceKind) IsValid() bool {
+	switch k {
+	case PlaceCity, PlaceNeighborhood, PlaceLandmark:
+		return true
+	}
+	return false
+}
+
+// Place is a named area users search near, like a neighborhood or a
+// landmark. RadiusKm is its approximate extent around the centroid.
+type Place struct {
+	ID       int64     `json:"id,omitempty"`
+	Name     string    `json:"name"`
+	Kind     PlaceKind `json:"kind"`
+	City     string    `json:"city,omitempty"`
+	State    string    `json:"state,omitempty"`
+	Country  string    `json:"country,omitempty"`
+	Centroid GeoPoint  `json:"centroid"`
+	RadiusKm float64   `json:"radius_km,omitempty"`
+}
+
+// Validate checks the place can be stored
+func (p Place) Validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return errors.New("place name is required")
+	}
+	if !p.Kind.IsValid() {
+		return fmt.Errorf("unknown place kind %q", p.Kind)
+	}
+	if !p.Centroid.IsValid() {
+		return errors.New("place centroid out of range")
+	}
+	if p.RadiusKm < 0 {
+		return errors.New("place radius must not be negative")
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package domain
 import (
 	"errors"
 	"fmt"
+	"log/slog"
 	"math"
 	"strings"
 	"time"
@@ -48,6 +49,9 @@ type Location struct {
 	PostalCode string    `json:"postal_code,omitempty" db:"postal_code"`
 	Country    string    `json:"country,omitempty" db:"country"`
 	GeoPoint   *GeoPoint `json:"geo_point,omitempty"`
+
+	// Where GeoPoint came from (see GeocodeStatus)
+	GeocodeStatus GeocodeStatus `json:"geocode_status,omitempty" db:"geocode_status"`
 }
 
 // FullAddress returns a formatted complete address
@@ -338,17 +342,63 @@ func (oh OperatingHours) DisplayHours() string {
 
 // SalonSearchParams contains all possible search/filter parameters
 type SalonSearchParams struct {
-	Query      string     // Full-text search query
-	City       string     // Filter by city
-	CategoryID *int64     // Filter by category
-	PriceRange PriceRange // Filter by price range (1-4)
-	MinRating  *float64   // Minimum rating filter
-	IsVerified *bool      // Filter verified only
-	Location   *GeoPoint  // For geo-search
-	RadiusKm   *float64   // Radius for geo-search
-	Page       int        // Pagination
-	PageSize   int        // Results per page
-	SortBy     SortOption // Sort field
+	Query       string       // Full-text search query
+	City        string       // Filter by city
+	CategoryID  *int64       // Filter by category
+	PriceRange  PriceRange   // Filter by price range (1-4)
+	MinRating   *float64     // Minimum rating filter
+	IsVerified  *bool        // Filter verified only
+	Location    *GeoPoint    // For geo-search
+	Near        string       // Place name, resolved to Location when none is given
+	RadiusKm    *float64     // Radius for geo-search
+	BoundingBox *BoundingBox // Map viewport filter
+	Polygon     *Polygon     // GeoJSON polygon filter
+	Page        int          // Pagination
+	PageSize    int          // Results per page
+	SortBy      SortOption   // Sort field
+}
+
+// LogValue implements slog.LogValuer, logging only the parameters that are set
+func (p SalonSearchParams) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.String("q", p.Query),
+		slog.Int("page", p.Page),
+		slog.Int("page_size", p.PageSize),
+	}
+	if p.City != "" {
+		attrs = append(attrs, slog.String("city", p.City))
+	}
+	if p.CategoryID != nil {
+		attrs = append(attrs, slog.Int64("category", *p.CategoryID))
+	}
+	if p.PriceRange != 0 {
+		attrs = append(attrs, slog.Int("price_range", int(p.PriceRange)))
+	}
+	if p.MinRating != nil {
+		attrs = append(attrs, slog.Float64("min_rating", *p.MinRating))
+	}
+	if p.IsVerified != nil {
+		attrs = append(attrs, slog.Bool("verified", *p.IsVerified))
+	}
+	if p.Location != nil {
+		attrs = append(attrs, slog.Float64("lat", p.Location.Latitude), slog.Float64("lon", p.Location.Longitude))
+	}
+	if p.Near != "" {
+		attrs = append(attrs, slog.String("near", p.Near))
+	}
+	if p.RadiusKm != nil {
+		attrs = append(attrs, slog.Float64("radius_km", *p.RadiusKm))
+	}
+	if p.BoundingBox != nil {
+		attrs = append(attrs, slog.String("bbox", p.BoundingBox.String()))
+	}
+	if p.Polygon != nil && len(p.Polygon.Coordinates) > 0 {
+		attrs = append(attrs, slog.Int("polygon_vertices", len(p.Polygon.Coordinates[0])))
+	}
+	if p.SortBy != "" {
+		attrs = append(attrs, slog.String("sort", string(p.SortBy)))
+	}
+	return slog.GroupValue(attrs...)
 }
 
 // SortOption defines how results should be sorted
@@ -362,6 +412,26 @@ const (
 	SortByReviews   SortOption = "reviews"
 )
 
+// RankingWeights controls the custom relevance formula shared by both search backends
+type RankingWeights struct {
+	Rating          float64 `json:"rating" yaml:"rating"`                       // rating * weight
+	Reviews         float64 `json:"reviews" yaml:"reviews"`                     // log1p(review_count) * weight
+	Verified        float64 `json:"verified" yaml:"verified"`                   // Flat boost for verified salons
+	Distance        float64 `json:"distance" yaml:"distance"`                   // Weight of the distance decay
+	DistanceScaleKm float64 `json:"distance_scale_km" yaml:"distance_scale_km"` // Distance at which the decay halves
+}
+
+// DefaultRankingWeights returns the weights the ranking formula was tuned with
+func DefaultRankingWeights() RankingWeights {
+	return RankingWeights{
+		Rating:          2.0,
+		Reviews:         1.5,
+		Verified:        5.0,
+		Distance:        3.0,
+		DistanceScaleKm: 5.0,
+	}
+}
+
 // SalonSearchResult wraps a salon with search metadata
 type SalonSearchResult struct {
 	Salon      Salon              `json:"salon"`
@@ -379,6 +449,10 @@ type SearchResponse struct {
 	TotalPages int                 `json:"total_pages"`
 	Query      string              `json:"query,omitempty"`
 	Source     string              `json:"source,omitempty"`
+	Degraded   bool                `json:"degraded"`            // Served by the fallback backend
+	SearchID   string              `json:"search_id,omitempty"` // Echo in POST /search/click
+	Near       *Place              `json:"near,omitempty"`      // Place the near parameter resolved to
+	Warnings   []string            `json:"warnings,omitempty"`  // Why the results may be incomplete
 }
 
 // NewSearchResponse creates a SearchResponse with calculated pagination
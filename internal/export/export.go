@@ -0,0 +1,192 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"beauty-salons/internal/domain"
+	"beauty-salons/internal/importer"
+)
+
+// ===========================================
+// CATALOG EXPORT
+// ===========================================
+// Writers stream salons one at a time, so an export of any size only holds
+// the current batch in memory. Salons are written in the import
+// representation (importer.Record), so CSV and NDJSON exports can be
+// imported back.
+
+// Format is an export file format
+type Format string
+
+const (
+	FormatCSV     Format = "csv"
+	FormatNDJSON  Format = "ndjson"
+	FormatGeoJSON Format = "geojson"
+)
+
+// ParseFormat accepts a format name or a file extension
+func ParseFormat(s string) (Format, error) {
+	switch strings.TrimPrefix(strings.ToLower(strings.TrimSpace(s)), ".") {
+	case "csv":
+		return FormatCSV, nil
+	case "ndjson", "jsonl":
+		return FormatNDJSON, nil
+	case "geojson":
+		return FormatGeoJSON, nil
+	}
+	return "", fmt.Errorf("unknown export format %q (use csv, ndjson or geojson)", s)
+}
+
+// ContentType returns the MIME type of the format
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatGeoJSON:
+		return "application/geo+json"
+	default:
+		return "application/x-ndjson"
+	}
+}
+
+// Writer writes salons in an export format
+type Writer interface {
+	// Write adds a salon, which must have its category, services,
+	// amenities and hours loaded
+	Write(salon *domain.Salon) error
+	// Flush writes buffered salons to the underlying writer
+	Flush() error
+	// Close finishes the file and flushes it; an export without Close is
+	// incomplete
+	Close() error
+}
+
+// NewWriter returns a Writer for the format
+func NewWriter(w io.Writer, format Format) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return &csvWriter{w: csv.NewWriter(w)}, nil
+	case FormatNDJSON:
+		buf := bufio.NewWriter(w)
+		return &ndjsonWriter{buf: buf, enc: json.NewEncoder(buf)}, nil
+	case FormatGeoJSON:
+		buf := bufio.NewWriter(w)
+		return &geojsonWriter{buf: buf, enc: json.NewEncoder(buf)}, nil
+	}
+	return nil, fmt.Errorf("unknown export format %q", format)
+}
+
+// csvWriter writes the columns of a CSV import
+type csvWriter struct {
+	w      *csv.Writer
+	header bool
+}
+
+func (c *csvWriter) writeHeader() error {
+	if c.header {
+		return nil
+	}
+	c.header = true
+	return c.w.Write(importer.CSVHeader())
+}
+
+func (c *csvWriter) Write(salon *domain.Salon) error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+	return c.w.Write(importer.NewRecord(salon).CSVRow())
+}
+
+func (c *csvWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvWriter) Close() error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+	return c.Flush()
+}
+
+// ndjsonWriter writes one importer.Record per line
+type ndjsonWriter struct {
+	buf *bufio.Writer
+	enc *json.Encoder
+}
+
+func (n *ndjsonWriter) Write(salon *domain.Salon) error {
+	return n.enc.Encode(importer.NewRecord(salon))
+}
+
+func (n *ndjsonWriter) Flush() error {
+	return n.buf.Flush()
+}
+
+func (n *ndjsonWriter) Close() error {
+	return n.buf.Flush()
+}
+
+// geoJSONFeature is a salon as a GeoJSON Feature; salons without
+// coordinates have a null geometry
+type geoJSONFeature struct {
+	Type       string          `json:"type"`
+	ID         int64           `json:"id"`
+	Geometry   *geoJSONPoint   `json:"geometry"`
+	Properties importer.Record `json:"properties"`
+}
+
+type geoJSONPoint struct {
+	Type        string     `json:"type"`
+	Coordinates [2]float64 `json:"coordinates"`
+}
+
+// geojsonWriter streams a FeatureCollection
+type geojsonWriter struct {
+	buf      *bufio.Writer
+	enc      *json.Encoder
+	features int
+}
+
+func (g *geojsonWriter) Write(salon *domain.Salon) error {
+	prefix := ",\n"
+	if g.features == 0 {
+		prefix = `{"type":"FeatureCollection","features":[` + "\n"
+	}
+	if _, err := g.buf.WriteString(prefix); err != nil {
+		return err
+	}
+	g.features++
+
+	record := importer.NewRecord(salon)
+	feature := geoJSONFeature{Type: "Feature", ID: salon.ID}
+	if record.Latitude != nil && record.Longitude != nil {
+		feature.Geometry = &geoJSONPoint{Type: "Point", Coordinates: [2]float64{*record.Longitude, *record.Latitude}}
+	}
+	// The coordinates are in the geometry
+	record.ID, record.Latitude, record.Longitude = 0, nil, nil
+	feature.Properties = record
+
+	// Encode adds a newline after each feature
+	return g.enc.Encode(feature)
+}
+
+func (g *geojsonWriter) Flush() error {
+	return g.buf.Flush()
+}
+
+func (g *geojsonWriter) Close() error {
+	suffix := "]}\n"
+	if g.features == 0 {
+		suffix = `{"type":"FeatureCollection","features":[]}` + "\n"
+	}
+	if _, err := g.buf.WriteString(suffix); err != nil {
+		return err
+	}
+	return g.buf.Flush()
+}
@@ -0,0 +1,130 @@
+package geocode
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"beauty-salons/internal/domain"
+)
+
+// DefaultBatchSize is how many salons a backfill reads at a time
+const DefaultBatchSize = 100
+
+// Store reads and updates salons waiting for coordinates
+type Store interface {
+	// SalonsToGeocode returns salons with a pending or failed geocode
+	// status and an ID above afterID, in ID order
+	SalonsToGeocode(ctx context.Context, afterID int64, limit int) ([]domain.Salon, error)
+	// SetSalonGeocode records the outcome for a salon; point is only set
+	// for GeocodeOK
+	SetSalonGeocode(ctx context.Context, id int64, point *domain.GeoPoint, status domain.GeocodeStatus) error
+}
+
+// Stats counts the outcomes of a backfill
+type Stats struct {
+	Processed int `json:"processed"`
+	Located   int `json:"located"`
+	NotFound  int `json:"not_found"`
+	Failed    int `json:"failed"`
+
+	LocatedIDs []int64 `json:"-"` // Salons that got coordinates
+}
+
+// String summarizes the outcomes for logs
+func (s Stats) String() string {
+	return fmt.Sprintf("%d processed, %d located, %d not found, %d failed", s.Processed, s.Located, s.NotFound, s.Failed)
+}
+
+// Backfill geocodes every salon with a pending or failed status, once.
+// Geocoder errors mark the salon as failed and move on; store errors and
+// cancellation stop the backfill.
+func Backfill(ctx context.Context, store Store, g Geocoder, batchSize int) (Stats, error) {
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	var stats Stats
+	var afterID int64
+	for {
+		salons, err := store.SalonsToGeocode(ctx, afterID, batchSize)
+		if err != nil {
+			return stats, fmt.Errorf("failed to read salons: %w", err)
+		}
+
+		for i := range salons {
+			salon := &salons[i]
+			afterID = salon.ID
+
+			// Stale coordinates of a changed address are replaced
+			salon.Location.GeoPoint = nil
+			status, err := Locate(ctx, g, salon)
+			if ctx.Err() != nil {
+				return stats, ctx.Err()
+			}
+			if err != nil {
+				slog.Warn("failed to geocode salon", "salon_id", salon.ID, "error", err)
+			}
+
+			if err := store.SetSalonGeocode(ctx, salon.ID, salon.Location.GeoPoint, status); err != nil {
+				return stats, fmt.Errorf("failed to update salon %d: %w", salon.ID, err)
+			}
+
+			stats.Processed++
+			switch status {
+			case domain.GeocodeOK:
+				stats.Located++
+				stats.LocatedIDs = append(stats.LocatedIDs, salon.ID)
+			case domain.GeocodeNotFound:
+				stats.NotFound++
+			case domain.GeocodeFailed:
+				stats.Failed++
+			}
+		}
+
+		if len(salons) < batchSize {
+			return stats, nil
+		}
+	}
+}
+
+// RunBackfill runs Backfill every interval until stopping is closed, calling
+// located (if not nil) with the salons that got coordinates. A backfill in
+// progress is cancelled on stop; its remaining salons keep their status and
+// are picked up by the next run.
+func RunBackfill(ctx context.Context, stopping <-chan struct{}, store Store, g Geocoder, batchSize int, interval time.Duration, located func(ctx context.Context, ids []int64)) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-stopping:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		stats, err := Backfill(ctx, store, g, batchSize)
+		if len(stats.LocatedIDs) > 0 && located != nil {
+			located(ctx, stats.LocatedIDs)
+		}
+		switch {
+		case ctx.Err() != nil:
+			return
+		case err != nil:
+			slog.Error("geocoding backfill failed", "error", err)
+		case stats.Processed > 0:
+			slog.Info("geocoding backfill finished", "stats", stats.String())
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
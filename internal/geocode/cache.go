@@ -0,0 +1,69 @@
+package geocode
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"beauty-salons/internal/domain"
+	"beauty-salons/internal/logging"
+)
+
+// DefaultNotFoundTTL is how long an unknown address is remembered before
+// the geocoders are asked again
+const DefaultNotFoundTTL = 30 * 24 * time.Hour
+
+// Cache stores geocoding results by address key (see Key)
+type Cache interface {
+	// GetGeocode returns cached=false when the key is unknown, and a nil
+	// result when the address was not found less than notFoundTTL ago
+	GetGeocode(ctx context.Context, key string, notFoundTTL time.Duration) (result *Result, cached bool, err error)
+	// PutGeocode stores a result, or nil for an address that was not found
+	PutGeocode(ctx context.Context, key string, result *Result) error
+}
+
+// Cached is a Geocoder that remembers the results of another one, so each
+// distinct address is only looked up once. Geocoder errors are not cached;
+// cache errors are logged and the geocoder is used directly.
+type Cached struct {
+	geocoder    Geocoder
+	cache       Cache
+	notFoundTTL time.Duration
+}
+
+// NewCached wraps a geocoder with a cache. notFoundTTL <= 0 uses DefaultNotFoundTTL.
+func NewCached(g Geocoder, cache Cache, notFoundTTL time.Duration) *Cached {
+	if notFoundTTL <= 0 {
+		notFoundTTL = DefaultNotFoundTTL
+	}
+	return &Cached{geocoder: g, cache: cache, notFoundTTL: notFoundTTL}
+}
+
+// Name implements Geocoder
+func (c *Cached) Name() string {
+	return c.geocoder.Name()
+}
+
+// Geocode implements Geocoder
+func (c *Cached) Geocode(ctx context.Context, loc domain.Location) (*Result, error) {
+	key := Key(loc)
+
+	result, cached, err := c.cache.GetGeocode(ctx, key, c.notFoundTTL)
+	switch {
+	case err != nil:
+		logging.FromContext(ctx).Warn("geocode cache lookup failed", "error", err)
+	case cached && result == nil:
+		return nil, ErrNotFound
+	case cached:
+		return result, nil
+	}
+
+	result, err = c.geocoder.Geocode(ctx, loc)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	if putErr := c.cache.PutGeocode(ctx, key, result); putErr != nil {
+		logging.FromContext(ctx).Warn("failed to cache geocode result", "error", putErr)
+	}
+	return result, err
+}
@@ -0,0 +1,162 @@
+package geocode
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"beauty-salons/internal/domain"
+)
+
+// gazetteerColumns is the header a gazetteer file must start with
+var gazetteerColumns = []string{"country", "state", "city", "postal_code", "latitude", "longitude"}
+
+// Gazetteer geocodes addresses from a local list of places, at city or
+// postal code precision. It needs no network access, which makes it a
+// good first link in a Chain and a deterministic geocoder for tests.
+type Gazetteer struct {
+	places map[string]*gazetteerPlace // nil for ambiguous keys
+	count  int
+}
+
+type gazetteerPlace struct {
+	point domain.GeoPoint
+	name  string
+}
+
+// LoadGazetteer reads a gazetteer CSV file (see ParseGazetteer)
+func LoadGazetteer(path string) (*Gazetteer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open gazetteer: %w", err)
+	}
+	defer f.Close()
+
+	g, err := ParseGazetteer(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return g, nil
+}
+
+// ParseGazetteer reads CSV rows of
+//
+//	country,state,city,postal_code,latitude,longitude
+//
+// after a header row with those names. Any of country, state, city and
+// postal_code may be empty, but each row needs a city or a postal code.
+func ParseGazetteer(r io.Reader) (*Gazetteer, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = len(gazetteerColumns)
+	cr.TrimLeadingSpace = true
+	cr.Comment = '#'
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	for i, col := range gazetteerColumns {
+		if strings.TrimSpace(strings.ToLower(header[i])) != col {
+			return nil, fmt.Errorf("header must be %s", strings.Join(gazetteerColumns, ","))
+		}
+	}
+
+	g := &Gazetteer{places: map[string]*gazetteerPlace{}}
+	exact := map[string]bool{}
+	for {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+
+		loc := domain.Location{Country: row[0], State: row[1], City: row[2], PostalCode: row[3]}
+		if loc.City == "" && loc.PostalCode == "" {
+			return nil, fmt.Errorf("line %d: city or postal_code is required", line)
+		}
+		lat, latErr := strconv.ParseFloat(row[4], 64)
+		lon, lonErr := strconv.ParseFloat(row[5], 64)
+		point := domain.GeoPoint{Latitude: lat, Longitude: lon}
+		if latErr != nil || lonErr != nil || !point.IsValid() {
+			return nil, fmt.Errorf("line %d: invalid coordinates", line)
+		}
+
+		place := &gazetteerPlace{point: point, name: loc.FullAddress()}
+		// Exact keys win; less specific ones are dropped when ambiguous
+		for _, k := range gazetteerKeys(loc) {
+			if k.exact {
+				g.places[k.key] = place
+				exact[k.key] = true
+				continue
+			}
+			if exact[k.key] {
+				continue
+			}
+			if existing, ok := g.places[k.key]; !ok {
+				g.places[k.key] = place
+			} else if existing != nil && existing.point != point {
+				g.places[k.key] = nil
+			}
+		}
+		g.count++
+	}
+	return g, nil
+}
+
+type gazetteerKey struct {
+	key   string
+	exact bool // Uses every field of the location
+}
+
+// gazetteerKeys returns the lookup keys of a location, most specific first:
+// postal code, then city, each with and without country and state
+func gazetteerKeys(loc domain.Location) []gazetteerKey {
+	country, state, city, postal := normalize(loc.Country), normalize(loc.State), normalize(loc.City), normalize(loc.PostalCode)
+
+	var keys []gazetteerKey
+	if postal != "" {
+		keys = append(keys, gazetteerKey{"postal|" + country + "|" + postal, true})
+		if country != "" {
+			keys = append(keys, gazetteerKey{"postal||" + postal, false})
+		}
+	}
+	if city != "" {
+		keys = append(keys, gazetteerKey{"city|" + country + "|" + state + "|" + city, true})
+		if state != "" {
+			keys = append(keys, gazetteerKey{"city|" + country + "||" + city, false})
+		}
+		if country != "" {
+			keys = append(keys, gazetteerKey{"city|||" + city, false})
+		}
+	}
+	return keys
+}
+
+// Len returns the number of places
+func (g *Gazetteer) Len() int {
+	return g.count
+}
+
+// Name implements Geocoder
+func (g *Gazetteer) Name() string {
+	return "gazetteer"
+}
+
+// Geocode implements Geocoder. Street addresses are ignored: the result is
+// the location of the postal code or city.
+func (g *Gazetteer) Geocode(_ context.Context, loc domain.Location) (*Result, error) {
+	for _, k := range gazetteerKeys(loc) {
+		if place := g.places[k.key]; place != nil {
+			return &Result{Point: place.point, DisplayName: place.name, Provider: g.Name()}, nil
+		}
+	}
+	return nil, ErrNotFound
+}
@@ -0,0 +1,175 @@
+package geocode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"beauty-salons/internal/domain"
+	"beauty-salons/internal/metrics"
+	"beauty-salons/internal/synonyms"
+)
+
+// ===========================================
+// GEOCODING
+// ===========================================
+// A Geocoder turns a postal address into coordinates. Implementations are
+// combined with Chain (first one that knows the address wins) and wrapped
+// with Cached so each distinct address is only looked up once.
+
+// ErrNotFound is returned when a geocoder does not know an address
+var ErrNotFound = errors.New("address not found")
+
+// Result is a geocoded address
+type Result struct {
+	Point       domain.GeoPoint
+	DisplayName string // Address as the provider understood it
+	Provider    string
+}
+
+// Geocoder resolves addresses to coordinates
+type Geocoder interface {
+	// Geocode returns ErrNotFound when the address is unknown
+	Geocode(ctx context.Context, loc domain.Location) (*Result, error)
+	// Name identifies the provider in metrics, logs and the cache
+	Name() string
+}
+
+// Chain tries each geocoder in order until one finds the address. Errors
+// from a geocoder don't stop the chain, but are returned when no later
+// geocoder finds the address either.
+type Chain []Geocoder
+
+// Geocode implements Geocoder
+func (c Chain) Geocode(ctx context.Context, loc domain.Location) (*Result, error) {
+	var errs []error
+	for _, g := range c {
+		result, err := g.Geocode(ctx, loc)
+		if err == nil {
+			return result, nil
+		}
+		if !errors.Is(err, ErrNotFound) {
+			errs = append(errs, fmt.Errorf("%s: %w", g.Name(), err))
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return nil, ErrNotFound
+}
+
+// Name implements Geocoder
+func (c Chain) Name() string {
+	names := make([]string, len(c))
+	for i, g := range c {
+		names[i] = g.Name()
+	}
+	return strings.Join(names, "+")
+}
+
+// Key normalizes an address for caching: lowercased, accents folded and
+// whitespace collapsed, so trivially different spellings share an entry
+func Key(loc domain.Location) string {
+	fields := []string{loc.Address, loc.City, loc.State, loc.PostalCode, loc.Country}
+	for i, f := range fields {
+		fields[i] = normalize(f)
+	}
+	return strings.Join(fields, "|")
+}
+
+func normalize(s string) string {
+	return strings.Join(synonyms.Tokens(s), " ")
+}
+
+// Geocodable reports whether a location has enough of an address to look up
+func Geocodable(loc domain.Location) bool {
+	return loc.City != "" || loc.PostalCode != ""
+}
+
+// Locate fills in the coordinates of a salon being created or updated,
+// unless its writer set them. It returns the resulting geocode status;
+// errors are returned with GeocodeFailed so the salon can still be saved
+// and retried by the backfill.
+func Locate(ctx context.Context, g Geocoder, salon *domain.Salon) (domain.GeocodeStatus, error) {
+	if salon.Location.GeoPoint != nil {
+		salon.Location.GeocodeStatus = domain.GeocodeManual
+		return domain.GeocodeManual, nil
+	}
+	if !Geocodable(salon.Location) {
+		salon.Location.GeocodeStatus = domain.GeocodeNotFound
+		return domain.GeocodeNotFound, nil
+	}
+
+	start := time.Now()
+	result, err := g.Geocode(ctx, salon.Location)
+	status := domain.GeocodeOK
+	switch {
+	case err == nil:
+		salon.Location.GeoPoint = &result.Point
+	case errors.Is(err, ErrNotFound):
+		status, err = domain.GeocodeNotFound, nil
+	default:
+		status = domain.GeocodeFailed
+	}
+	metrics.ObserveGeocode(g.Name(), string(status), start)
+
+	salon.Location.GeocodeStatus = status
+	return status, err
+}
+
+// Provider names accepted by New
+const (
+	ProviderGazetteer = "gazetteer"
+	ProviderNominatim = "nominatim"
+)
+
+// Config selects and configures geocoders
+type Config struct {
+	Providers     []string // Tried in order; empty disables geocoding
+	GazetteerFile string
+	Nominatim     NominatimConfig
+	NotFoundTTL   time.Duration
+}
+
+// New builds the chain of configured providers, cached in cache (if not
+// nil). It returns nil when no provider is configured.
+func New(cfg Config, cache Cache) (Geocoder, error) {
+	var chain Chain
+	for _, name := range cfg.Providers {
+		switch name {
+		case ProviderGazetteer:
+			g, err := LoadGazetteer(cfg.GazetteerFile)
+			if err != nil {
+				return nil, err
+			}
+			chain = append(chain, g)
+		case ProviderNominatim:
+			n, err := NewNominatim(cfg.Nominatim)
+			if err != nil {
+				return nil, err
+			}
+			chain = append(chain, n)
+		default:
+			return nil, fmt.Errorf("unknown geocoding provider %q", name)
+		}
+	}
+
+	var g Geocoder
+	switch len(chain) {
+	case 0:
+		return nil, nil
+	case 1:
+		g = chain[0]
+	default:
+		g = chain
+	}
+	if cache != nil {
+		g = NewCached(g, cache, cfg.NotFoundTTL)
+	}
+	return g, nil
+}
@@ -0,0 +1,166 @@
+package geocode
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"beauty-salons/internal/domain"
+)
+
+// Nominatim defaults. The public instance allows at most one request per
+// second and requires an identifying User-Agent.
+const (
+	DefaultNominatimURL       = "https://nominatim.openstreetmap.org"
+	DefaultNominatimUserAgent = "beauty-salons-geocoder"
+	DefaultNominatimInterval  = time.Second
+	DefaultNominatimTimeout   = 10 * time.Second
+)
+
+// NominatimConfig configures a Nominatim-compatible geocoder
+type NominatimConfig struct {
+	BaseURL     string        // Instance URL, without /search
+	UserAgent   string        // Identifies the application to the instance
+	Email       string        // Contact address sent with each request (optional)
+	MinInterval time.Duration // Minimum time between requests (negative disables throttling)
+	Timeout     time.Duration // Per-request timeout
+}
+
+// Nominatim geocodes addresses with the search API of a Nominatim server
+// (OpenStreetMap's geocoder), or anything that speaks its protocol
+type Nominatim struct {
+	baseURL   string
+	userAgent string
+	email     string
+	interval  time.Duration
+	client    *http.Client
+
+	mu   sync.Mutex
+	next time.Time // Earliest time the next request may be sent
+}
+
+// NewNominatim creates a Nominatim geocoder; zero config values use the defaults
+func NewNominatim(cfg NominatimConfig) (*Nominatim, error) {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = DefaultNominatimURL
+	}
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = DefaultNominatimUserAgent
+	}
+	if cfg.MinInterval == 0 {
+		cfg.MinInterval = DefaultNominatimInterval
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultNominatimTimeout
+	}
+
+	u, err := url.Parse(cfg.BaseURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid Nominatim URL %q", cfg.BaseURL)
+	}
+
+	return &Nominatim{
+		baseURL:   strings.TrimSuffix(cfg.BaseURL, "/"),
+		userAgent: cfg.UserAgent,
+		email:     cfg.Email,
+		interval:  cfg.MinInterval,
+		client:    &http.Client{Timeout: cfg.Timeout},
+	}, nil
+}
+
+// Name implements Geocoder
+func (n *Nominatim) Name() string {
+	return "nominatim"
+}
+
+// Geocode implements Geocoder with a structured search query
+func (n *Nominatim) Geocode(ctx context.Context, loc domain.Location) (*Result, error) {
+	q := url.Values{}
+	q.Set("format", "jsonv2")
+	q.Set("limit", "1")
+	setIfNotEmpty(q, "street", loc.Address)
+	setIfNotEmpty(q, "city", loc.City)
+	setIfNotEmpty(q, "state", loc.State)
+	setIfNotEmpty(q, "postalcode", loc.PostalCode)
+	setIfNotEmpty(q, "country", loc.Country)
+	setIfNotEmpty(q, "email", n.email)
+
+	if err := n.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, n.baseURL+"/search?"+q.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", n.userAgent)
+	req.Header.Set("Accept", "application/json")
+
+	res, err := n.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", res.Status)
+	}
+
+	var places []struct {
+		Lat         string `json:"lat"`
+		Lon         string `json:"lon"`
+		DisplayName string `json:"display_name"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&places); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if len(places) == 0 {
+		return nil, ErrNotFound
+	}
+
+	lat, latErr := strconv.ParseFloat(places[0].Lat, 64)
+	lon, lonErr := strconv.ParseFloat(places[0].Lon, 64)
+	point := domain.GeoPoint{Latitude: lat, Longitude: lon}
+	if latErr != nil || lonErr != nil || !point.IsValid() {
+		return nil, fmt.Errorf("invalid coordinates %q, %q", places[0].Lat, places[0].Lon)
+	}
+
+	return &Result{Point: point, DisplayName: places[0].DisplayName, Provider: n.Name()}, nil
+}
+
+// wait blocks until the next request is allowed by MinInterval
+func (n *Nominatim) wait(ctx context.Context) error {
+	n.mu.Lock()
+	now := time.Now()
+	at := n.next
+	if at.Before(now) {
+		at = now
+	}
+	n.next = at.Add(n.interval)
+	n.mu.Unlock()
+
+	delay := time.Until(at)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func setIfNotEmpty(q url.Values, key, value string) {
+	if value != "" {
+		q.Set(key, value)
+	}
+}
@@ -0,0 +1,206 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"beauty-salons/internal/domain"
+	"beauty-salons/internal/synonyms"
+)
+
+// ===========================================
+// BULK IMPORT
+// ===========================================
+// Salons are read from CSV or NDJSON (see Parse), validated row by row and
+// upserted by slug in batches, one transaction per batch. A row that fails
+// is reported and skipped without affecting the rest of its batch. Each
+// committed batch is indexed in Elasticsearch.
+
+// DefaultBatchSize is how many salons are written per transaction
+const DefaultBatchSize = 100
+
+// Row is a parsed salon with its position in the file
+type Row struct {
+	Line  int // Line (CSV) or record (NDJSON) number, for the report
+	Salon domain.Salon
+	Err   error // Set when the row could not be parsed
+}
+
+// UpsertResult is the outcome of writing one salon
+type UpsertResult struct {
+	ID      int64
+	Created bool  // Inserted rather than updated
+	Err     error // The salon was skipped
+}
+
+// Store writes imported salons
+type Store interface {
+	// UpsertSalons writes a batch of salons by slug in one transaction,
+	// with one result per salon. Errors of a single salon only fail its
+	// result; the returned error means the whole batch failed. With dryRun
+	// the transaction is rolled back.
+	UpsertSalons(ctx context.Context, salons []domain.Salon, dryRun bool) ([]UpsertResult, error)
+	// GetSalonByID loads a salon with its services and amenities, for indexing
+	GetSalonByID(ctx context.Context, id int64) (*domain.Salon, error)
+}
+
+// Indexer adds salons to the search index
+type Indexer interface {
+	BulkIndexSalons(ctx context.Context, salons []domain.Salon) error
+}
+
+// Options configures an import
+type Options struct {
+	BatchSize int
+	DryRun    bool // Validate and write, then roll back
+}
+
+// RowError reports a row that was not imported
+type RowError struct {
+	Line  int    `json:"line"`
+	Slug  string `json:"slug,omitempty"`
+	Error string `json:"error"`
+}
+
+// Report summarizes an import
+type Report struct {
+	Rows       int        `json:"rows"`
+	Created    int        `json:"created"`
+	Updated    int        `json:"updated"`
+	Failed     int        `json:"failed"`
+	Indexed    int        `json:"indexed"`
+	DryRun     bool       `json:"dry_run"`
+	Errors     []RowError `json:"errors,omitempty"`
+	IndexError string     `json:"index_error,omitempty"` // Imported but not searchable until the next sync
+
+	IDs []int64 `json:"-"` // Salons written
+}
+
+// String summarizes the report for logs
+func (r Report) String() string {
+	s := fmt.Sprintf("%d rows, %d created, %d updated, %d failed, %d indexed", r.Rows, r.Created, r.Updated, r.Failed, r.Indexed)
+	if r.DryRun {
+		s += " (dry run)"
+	}
+	return s
+}
+
+func (r *Report) fail(line int, slug string, err error) {
+	r.Failed++
+	r.Errors = append(r.Errors, RowError{Line: line, Slug: slug, Error: err.Error()})
+}
+
+// Import validates rows with Salon.Validate and writes the valid ones in
+// batches. index may be nil to skip indexing; it is also skipped on dry
+// runs. The returned error means a batch could not be written at all; the
+// report then covers the batches before it.
+func Import(ctx context.Context, store Store, index Indexer, rows []Row, opts Options) (*Report, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+
+	report := &Report{Rows: len(rows), DryRun: opts.DryRun}
+	seen := map[string]int{}
+	var batch []domain.Salon
+	var lines []int
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		results, err := store.UpsertSalons(ctx, batch, opts.DryRun)
+		if err != nil {
+			return fmt.Errorf("failed to import rows %d-%d: %w", lines[0], lines[len(lines)-1], err)
+		}
+
+		var ids []int64
+		for i, result := range results {
+			switch {
+			case result.Err != nil:
+				report.fail(lines[i], batch[i].Slug, result.Err)
+			case result.Created:
+				report.Created++
+			default:
+				report.Updated++
+			}
+			if result.Err == nil {
+				ids = append(ids, result.ID)
+			}
+		}
+		report.IDs = append(report.IDs, ids...)
+		if index != nil && !opts.DryRun {
+			indexSalons(ctx, store, index, ids, report)
+		}
+
+		batch, lines = batch[:0], lines[:0]
+		return nil
+	}
+
+	for _, row := range rows {
+		salon := row.Salon
+		if row.Err != nil {
+			report.fail(row.Line, salon.Slug, row.Err)
+			continue
+		}
+		if err := salon.Validate(); err != nil {
+			report.fail(row.Line, salon.Slug, err)
+			continue
+		}
+		if first, ok := seen[salon.Slug]; ok {
+			report.fail(row.Line, salon.Slug, fmt.Errorf("duplicate slug, first used on line %d", first))
+			continue
+		}
+		seen[salon.Slug] = row.Line
+
+		batch = append(batch, salon)
+		lines = append(lines, row.Line)
+		if len(batch) >= opts.BatchSize {
+			if err := flush(); err != nil {
+				return report, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+// indexSalons loads and indexes a committed batch. Failures are reported
+// but don't fail the import: the salons are in PostgreSQL and the next
+// sync indexes them.
+func indexSalons(ctx context.Context, store Store, index Indexer, ids []int64, report *Report) {
+	salons := make([]domain.Salon, 0, len(ids))
+	var errs []error
+	for _, id := range ids {
+		salon, err := store.GetSalonByID(ctx, id)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		salons = append(salons, *salon)
+	}
+	if len(salons) > 0 {
+		if err := index.BulkIndexSalons(ctx, salons); err != nil {
+			errs = append(errs, err)
+		} else {
+			report.Indexed += len(salons)
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		slog.Warn("failed to index imported salons, run a sync to catch up", "error", err)
+		if report.IndexError == "" {
+			report.IndexError = err.Error()
+		}
+	}
+}
+
+// Slug derives a salon slug from its name and city, for rows without one:
+// "Estilo Mar", "Mar del Plata" becomes "estilo-mar-mar-del-plata"
+func Slug(name, city string) string {
+	return strings.Join(synonyms.Tokens(name+" "+city), "-")
+}
@@ -0,0 +1,536 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"beauty-salons/internal/domain"
+)
+
+// Format is an import file format
+type Format string
+
+const (
+	FormatCSV    Format = "csv"
+	FormatNDJSON Format = "ndjson"
+)
+
+// ParseFormat accepts a format name, a file extension or a Content-Type
+func ParseFormat(s string) (Format, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if i := strings.IndexByte(s, ';'); i >= 0 {
+		s = strings.TrimSpace(s[:i])
+	}
+	switch strings.TrimPrefix(s, ".") {
+	case "csv", "text/csv":
+		return FormatCSV, nil
+	case "ndjson", "jsonl", "application/x-ndjson", "application/jsonl", "application/json":
+		return FormatNDJSON, nil
+	}
+	return "", fmt.Errorf("unknown import format %q (use csv or ndjson)", s)
+}
+
+// Record is one salon as written in an import file. NDJSON lines are
+// records; CSV columns have the same names, with services, amenities and
+// hours packed into cells (see ParseCSV).
+type Record struct {
+	ID          int64    `json:"id,omitempty"` // Ignored, salons are matched by slug
+	Name        string   `json:"name"`
+	Slug        string   `json:"slug,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Address     string   `json:"address,omitempty"`
+	City        string   `json:"city,omitempty"`
+	State       string   `json:"state,omitempty"`
+	PostalCode  string   `json:"postal_code,omitempty"`
+	Country     string   `json:"country,omitempty"`
+	Latitude    *float64 `json:"latitude,omitempty"`
+	Longitude   *float64 `json:"longitude,omitempty"`
+	Phone       string   `json:"phone,omitempty"`
+	Email       string   `json:"email,omitempty"`
+	Website     string   `json:"website,omitempty"`
+	Category    string   `json:"category,omitempty"` // Category slug or name
+	PriceRange  int      `json:"price_range,omitempty"`
+	Rating      *float64 `json:"rating,omitempty"`
+	ReviewCount int      `json:"review_count,omitempty"`
+	IsVerified  bool     `json:"is_verified,omitempty"`
+	IsActive    *bool    `json:"is_active,omitempty"` // Defaults to true
+
+	// Omitted (nil) keeps what an existing salon has; empty clears it
+	Services  []ServiceRecord `json:"services"`
+	Amenities []string        `json:"amenities"` // Amenity names or icons
+	Hours     []HoursRecord   `json:"hours"`
+}
+
+// ServiceRecord is a service in an import file
+type ServiceRecord struct {
+	Name            string   `json:"name"`
+	Description     string   `json:"description,omitempty"`
+	PriceMin        *float64 `json:"price_min,omitempty"`
+	PriceMax        *float64 `json:"price_max,omitempty"`
+	DurationMinutes *int     `json:"duration_minutes,omitempty"`
+}
+
+// HoursRecord is the opening hours of one day in an import file
+type HoursRecord struct {
+	Day    int    `json:"day"`             // 0=Sunday, 6=Saturday
+	Open   string `json:"open,omitempty"`  // "09:00"
+	Close  string `json:"close,omitempty"` // "18:00"
+	Closed bool   `json:"closed,omitempty"`
+}
+
+// Salon converts the record, deriving a missing slug from name and city
+func (r Record) Salon() (domain.Salon, error) {
+	salon := domain.Salon{
+		Name: strings.TrimSpace(r.Name),
+		Slug: strings.TrimSpace(r.Slug),
+		Location: domain.Location{
+			Address:    r.Address,
+			City:       r.City,
+			State:      r.State,
+			PostalCode: r.PostalCode,
+			Country:    r.Country,
+		},
+		Contact:     domain.Contact{Phone: r.Phone, Email: r.Email, Website: r.Website},
+		PriceRange:  domain.PriceRange(r.PriceRange),
+		Rating:      r.Rating,
+		ReviewCount: r.ReviewCount,
+		IsActive:    r.IsActive == nil || *r.IsActive,
+		IsVerified:  r.IsVerified,
+	}
+	if salon.Slug == "" {
+		salon.Slug = Slug(r.Name, r.City)
+	}
+	if r.Description != "" {
+		description := r.Description
+		salon.Description = &description
+	}
+	if r.Category != "" {
+		salon.Category = &domain.Category{Slug: r.Category}
+	}
+
+	switch {
+	case r.Latitude != nil && r.Longitude != nil:
+		salon.Location.GeoPoint = &domain.GeoPoint{Latitude: *r.Latitude, Longitude: *r.Longitude}
+	case r.Latitude != nil || r.Longitude != nil:
+		return salon, errors.New("latitude and longitude must be given together")
+	}
+	if r.ReviewCount < 0 {
+		return salon, errors.New("review_count cannot be negative")
+	}
+
+	if r.Services != nil {
+		salon.Services = make([]domain.Service, 0, len(r.Services))
+		for _, s := range r.Services {
+			service := domain.Service{
+				Name:            strings.TrimSpace(s.Name),
+				PriceMin:        s.PriceMin,
+				PriceMax:        s.PriceMax,
+				DurationMinutes: s.DurationMinutes,
+			}
+			if s.Description != "" {
+				description := s.Description
+				service.Description = &description
+			}
+			salon.Services = append(salon.Services, service)
+		}
+	}
+
+	if r.Amenities != nil {
+		salon.Amenities = make([]domain.Amenity, 0, len(r.Amenities))
+		for _, name := range r.Amenities {
+			if name = strings.TrimSpace(name); name != "" {
+				salon.Amenities = append(salon.Amenities, domain.Amenity{Name: name})
+			}
+		}
+	}
+
+	if r.Hours != nil {
+		salon.OperatingHours = make([]domain.OperatingHours, 0, len(r.Hours))
+		days := map[int]bool{}
+		for _, h := range r.Hours {
+			hours, err := h.operatingHours()
+			if err != nil {
+				return salon, err
+			}
+			if days[h.Day] {
+				return salon, fmt.Errorf("hours: day %d given twice", h.Day)
+			}
+			days[h.Day] = true
+			salon.OperatingHours = append(salon.OperatingHours, hours)
+		}
+	}
+	return salon, nil
+}
+
+// operatingHours validates the record and normalizes times to "15:04:05"
+func (h HoursRecord) operatingHours() (domain.OperatingHours, error) {
+	hours := domain.OperatingHours{DayOfWeek: h.Day, IsClosed: h.Closed}
+	if h.Day < 0 || h.Day > 6 {
+		return hours, fmt.Errorf("hours: day must be between 0 (Sunday) and 6, got %d", h.Day)
+	}
+	if h.Closed {
+		return hours, nil
+	}
+
+	var err error
+	if hours.OpenTime, err = parseClock(h.Open); err != nil {
+		return hours, fmt.Errorf("hours: day %d: %w", h.Day, err)
+	}
+	if hours.CloseTime, err = parseClock(h.Close); err != nil {
+		return hours, fmt.Errorf("hours: day %d: %w", h.Day, err)
+	}
+	if hours.OpenTime >= hours.CloseTime {
+		return hours, fmt.Errorf("hours: day %d closes before it opens", h.Day)
+	}
+	return hours, nil
+}
+
+func parseClock(s string) (string, error) {
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		if t, err := time.Parse(layout, strings.TrimSpace(s)); err == nil {
+			return t.Format("15:04:05"), nil
+		}
+	}
+	return "", fmt.Errorf("invalid time %q (use HH:MM)", s)
+}
+
+// Load reads salons from a file; an empty format is taken from the
+// file extension
+func Load(path string, format Format) ([]Row, error) {
+	if format == "" {
+		var err error
+		if format, err = ParseFormat(filepath.Ext(path)); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open import file: %w", err)
+	}
+	defer f.Close()
+
+	rows, err := Parse(f, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rows, nil
+}
+
+// Parse reads salons in the given format. Rows that can't be parsed are
+// returned with Err set; the error is only for unreadable files.
+func Parse(r io.Reader, format Format) ([]Row, error) {
+	switch format {
+	case FormatCSV:
+		return ParseCSV(r)
+	case FormatNDJSON:
+		return ParseNDJSON(r)
+	}
+	return nil, fmt.Errorf("unknown import format %q", format)
+}
+
+// ParseNDJSON reads one JSON Record per line. Blank lines are skipped and
+// unknown fields are errors, to catch misspelled keys.
+func ParseNDJSON(r io.Reader) ([]Row, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+
+	var rows []Row
+	line := 0
+	for scanner.Scan() {
+		line++
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		var record Record
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&record); err != nil {
+			rows = append(rows, Row{Line: line, Err: fmt.Errorf("invalid JSON: %w", err)})
+			continue
+		}
+		salon, err := record.Salon()
+		rows = append(rows, Row{Line: line, Salon: salon, Err: err})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read NDJSON: %w", err)
+	}
+	return rows, nil
+}
+
+// csvColumns lists the columns a CSV import may have, in any order; only
+// name is required. id is ignored (salons are matched by slug) so that
+// exports can be imported back.
+var csvColumns = []string{
+	"id", "name", "slug", "description",
+	"address", "city", "state", "postal_code", "country", "latitude", "longitude",
+	"phone", "email", "website",
+	"category", "price_range", "rating", "review_count", "is_verified", "is_active",
+	"services", "amenities", "hours",
+}
+
+// ParseCSV reads salons from a CSV file whose header names the columns
+// (see csvColumns). Lists are separated by "|":
+//
+//	services   name:price:minutes, price being "min", "min-max" or "-max", e.g.
+//	           "Corte:8000-15000:60|Brushing:5000"
+//	amenities  names or icons, e.g. "WiFi Gratis|parking"
+//	hours      days (0=Sunday, ranges allowed)=open-close or =closed, e.g.
+//	           "2-5=10:00-19:00|6=10:00-14:00|0-1=closed"
+func ParseCSV(r io.Reader) ([]Row, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	cr.Comment = '#'
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !contains(csvColumns, name) {
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+		if _, ok := columns[name]; ok {
+			return nil, fmt.Errorf("column %q given twice", name)
+		}
+		columns[name] = i
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, errors.New("a name column is required")
+	}
+
+	var rows []Row
+	for {
+		cells, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				rows = append(rows, Row{Line: parseErr.StartLine, Err: err})
+				continue
+			}
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+
+		record, err := csvRecord(columns, cells)
+		if err != nil {
+			rows = append(rows, Row{Line: line, Salon: domain.Salon{Slug: record.Slug}, Err: err})
+			continue
+		}
+		salon, err := record.Salon()
+		rows = append(rows, Row{Line: line, Salon: salon, Err: err})
+	}
+	return rows, nil
+}
+
+// csvRecord maps the cells of a row to a Record
+func csvRecord(columns map[string]int, cells []string) (Record, error) {
+	var record Record
+	var errs []error
+	cell := func(name string) (string, bool) {
+		i, ok := columns[name]
+		if !ok || i >= len(cells) {
+			return "", ok
+		}
+		return strings.TrimSpace(cells[i]), true
+	}
+	text := func(name string) string {
+		v, _ := cell(name)
+		return v
+	}
+	number := func(name string) *float64 {
+		v, _ := cell(name)
+		if v == "" {
+			return nil
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: invalid number %q", name, v))
+			return nil
+		}
+		return &f
+	}
+	integer := func(name string) int {
+		v, _ := cell(name)
+		if v == "" {
+			return 0
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: invalid integer %q", name, v))
+		}
+		return n
+	}
+	boolean := func(name string) *bool {
+		v, _ := cell(name)
+		if v == "" {
+			return nil
+		}
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: invalid boolean %q", name, v))
+			return nil
+		}
+		return &b
+	}
+
+	record.Name = text("name")
+	record.Slug = text("slug")
+	record.Description = text("description")
+	record.Address = text("address")
+	record.City = text("city")
+	record.State = text("state")
+	record.PostalCode = text("postal_code")
+	record.Country = text("country")
+	record.Latitude = number("latitude")
+	record.Longitude = number("longitude")
+	record.Phone = text("phone")
+	record.Email = text("email")
+	record.Website = text("website")
+	record.Category = text("category")
+	record.PriceRange = integer("price_range")
+	record.Rating = number("rating")
+	record.ReviewCount = integer("review_count")
+	if v := boolean("is_verified"); v != nil {
+		record.IsVerified = *v
+	}
+	record.IsActive = boolean("is_active")
+
+	// A missing column keeps existing data; an empty cell clears it
+	if v, ok := cell("services"); ok {
+		services, err := parseServices(v)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		record.Services = services
+	}
+	if v, ok := cell("amenities"); ok {
+		record.Amenities = splitList(v)
+	}
+	if v, ok := cell("hours"); ok {
+		hours, err := parseHours(v)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		record.Hours = hours
+	}
+
+	if record.Slug == "" {
+		record.Slug = Slug(record.Name, record.City)
+	}
+	return record, errors.Join(errs...)
+}
+
+// splitList splits a "|" separated cell, never returning nil
+func splitList(s string) []string {
+	list := []string{}
+	for _, item := range strings.Split(s, "|") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// parseServices parses "name:price:minutes|..." (see ParseCSV)
+func parseServices(s string) ([]ServiceRecord, error) {
+	services := []ServiceRecord{}
+	for _, item := range splitList(s) {
+		parts := strings.Split(item, ":")
+		if len(parts) > 3 {
+			return nil, fmt.Errorf("services: %q must be name:price:minutes", item)
+		}
+		service := ServiceRecord{Name: strings.TrimSpace(parts[0])}
+
+		if len(parts) > 1 && strings.TrimSpace(parts[1]) != "" {
+			// "min", "min-max" or "-max"
+			lo, hi, found := strings.Cut(parts[1], "-")
+			if lo = strings.TrimSpace(lo); lo != "" {
+				v, err := strconv.ParseFloat(lo, 64)
+				if err != nil {
+					return nil, fmt.Errorf("services: %q has an invalid price", item)
+				}
+				service.PriceMin = &v
+			}
+			if hi = strings.TrimSpace(hi); found && hi != "" {
+				v, err := strconv.ParseFloat(hi, 64)
+				if err != nil {
+					return nil, fmt.Errorf("services: %q has an invalid price", item)
+				}
+				service.PriceMax = &v
+			}
+		}
+		if len(parts) > 2 && strings.TrimSpace(parts[2]) != "" {
+			minutes, err := strconv.Atoi(strings.TrimSpace(parts[2]))
+			if err != nil {
+				return nil, fmt.Errorf("services: %q has an invalid duration", item)
+			}
+			service.DurationMinutes = &minutes
+		}
+		services = append(services, service)
+	}
+	return services, nil
+}
+
+// parseHours parses "days=open-close|days=closed|..." (see ParseCSV)
+func parseHours(s string) ([]HoursRecord, error) {
+	hours := []HoursRecord{}
+	for _, item := range splitList(s) {
+		days, times, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("hours: %q must be days=open-close or days=closed", item)
+		}
+
+		first, last, isRange := strings.Cut(days, "-")
+		from, err1 := strconv.Atoi(strings.TrimSpace(first))
+		to := from
+		var err2 error
+		if isRange {
+			to, err2 = strconv.Atoi(strings.TrimSpace(last))
+		}
+		if err1 != nil || err2 != nil || from > to {
+			return nil, fmt.Errorf("hours: %q has invalid days", item)
+		}
+
+		record := HoursRecord{}
+		times = strings.TrimSpace(times)
+		if strings.EqualFold(times, "closed") {
+			record.Closed = true
+		} else if open, close, ok := strings.Cut(times, "-"); ok {
+			record.Open, record.Close = open, close
+		} else {
+			return nil, fmt.Errorf("hours: %q must be days=open-close or days=closed", item)
+		}
+		for day := from; day <= to; day++ {
+			record.Day = day
+			hours = append(hours, record)
+		}
+	}
+	return hours, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,187 @@
+package importer
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"beauty-salons/internal/domain"
+)
+
+// NewRecord converts a salon to the import representation, the inverse of
+// Record.Salon. Exports use it so their files can be imported back.
+func NewRecord(salon *domain.Salon) Record {
+	loc, contact := salon.Location, salon.Contact
+	record := Record{
+		ID:          salon.ID,
+		Name:        salon.Name,
+		Slug:        salon.Slug,
+		Address:     loc.Address,
+		City:        loc.City,
+		State:       loc.State,
+		PostalCode:  loc.PostalCode,
+		Country:     loc.Country,
+		Phone:       contact.Phone,
+		Email:       contact.Email,
+		Website:     contact.Website,
+		PriceRange:  int(salon.PriceRange),
+		Rating:      salon.Rating,
+		ReviewCount: salon.ReviewCount,
+		IsVerified:  salon.IsVerified,
+		Services:    make([]ServiceRecord, 0, len(salon.Services)),
+		Amenities:   make([]string, 0, len(salon.Amenities)),
+		Hours:       make([]HoursRecord, 0, len(salon.OperatingHours)),
+	}
+	if salon.Description != nil {
+		record.Description = *salon.Description
+	}
+	if p := loc.GeoPoint; p != nil {
+		lat, lon := p.Latitude, p.Longitude
+		record.Latitude, record.Longitude = &lat, &lon
+	}
+	if salon.Category != nil {
+		record.Category = salon.Category.Slug
+		if record.Category == "" {
+			record.Category = salon.Category.Name
+		}
+	}
+	if !salon.IsActive {
+		active := false
+		record.IsActive = &active
+	}
+
+	for _, s := range salon.Services {
+		service := ServiceRecord{Name: s.Name, PriceMin: s.PriceMin, PriceMax: s.PriceMax, DurationMinutes: s.DurationMinutes}
+		if s.Description != nil {
+			service.Description = *s.Description
+		}
+		record.Services = append(record.Services, service)
+	}
+	for _, a := range salon.Amenities {
+		record.Amenities = append(record.Amenities, a.Name)
+	}
+	for _, h := range salon.OperatingHours {
+		record.Hours = append(record.Hours, HoursRecord{
+			Day:    h.DayOfWeek,
+			Open:   shortClock(h.OpenTime),
+			Close:  shortClock(h.CloseTime),
+			Closed: h.IsClosed,
+		})
+	}
+	sort.Slice(record.Hours, func(i, j int) bool { return record.Hours[i].Day < record.Hours[j].Day })
+	return record
+}
+
+// shortClock turns "09:30:00" into "09:30", keeping other seconds
+func shortClock(s string) string {
+	if len(s) == len("15:04:05") && strings.HasSuffix(s, ":00") {
+		return s[:len("15:04")]
+	}
+	return s
+}
+
+// CSVHeader returns every CSV column, in the order CSVRow writes them
+func CSVHeader() []string {
+	return append([]string(nil), csvColumns...)
+}
+
+// CSVRow formats the record as the cells of a CSV import row
+func (r Record) CSVRow() []string {
+	number := func(v *float64) string {
+		if v == nil {
+			return ""
+		}
+		return strconv.FormatFloat(*v, 'f', -1, 64)
+	}
+	integer := func(v int) string {
+		if v == 0 {
+			return ""
+		}
+		return strconv.Itoa(v)
+	}
+
+	isActive := "true"
+	if r.IsActive != nil && !*r.IsActive {
+		isActive = "false"
+	}
+
+	cells := map[string]string{
+		"id":           strconv.FormatInt(r.ID, 10),
+		"name":         r.Name,
+		"slug":         r.Slug,
+		"description":  r.Description,
+		"address":      r.Address,
+		"city":         r.City,
+		"state":        r.State,
+		"postal_code":  r.PostalCode,
+		"country":      r.Country,
+		"latitude":     number(r.Latitude),
+		"longitude":    number(r.Longitude),
+		"phone":        r.Phone,
+		"email":        r.Email,
+		"website":      r.Website,
+		"category":     r.Category,
+		"price_range":  integer(r.PriceRange),
+		"rating":       number(r.Rating),
+		"review_count": strconv.Itoa(r.ReviewCount),
+		"is_verified":  strconv.FormatBool(r.IsVerified),
+		"is_active":    isActive,
+		"services":     formatServices(r.Services),
+		"amenities":    strings.Join(r.Amenities, "|"),
+		"hours":        formatHours(r.Hours),
+	}
+
+	row := make([]string, len(csvColumns))
+	for i, col := range csvColumns {
+		row[i] = cells[col]
+	}
+	return row
+}
+
+// formatServices is the inverse of parseServices
+func formatServices(services []ServiceRecord) string {
+	items := make([]string, len(services))
+	for i, s := range services {
+		var price string
+		if s.PriceMin != nil {
+			price = strconv.FormatFloat(*s.PriceMin, 'f', -1, 64)
+		}
+		if s.PriceMax != nil {
+			price += "-" + strconv.FormatFloat(*s.PriceMax, 'f', -1, 64)
+		}
+		var minutes string
+		if s.DurationMinutes != nil {
+			minutes = strconv.Itoa(*s.DurationMinutes)
+		}
+		items[i] = strings.TrimRight(s.Name+":"+price+":"+minutes, ":")
+	}
+	return strings.Join(items, "|")
+}
+
+// formatHours is the inverse of parseHours, joining consecutive days with
+// the same hours into ranges. hours must be sorted by day.
+func formatHours(hours []HoursRecord) string {
+	var items []string
+	for i := 0; i < len(hours); {
+		j := i
+		for j+1 < len(hours) && hours[j+1].Day == hours[j].Day+1 && sameHours(hours[j+1], hours[i]) {
+			j++
+		}
+
+		days := strconv.Itoa(hours[i].Day)
+		if j > i {
+			days += "-" + strconv.Itoa(hours[j].Day)
+		}
+		times := "closed"
+		if !hours[i].Closed {
+			times = hours[i].Open + "-" + hours[i].Close
+		}
+		items = append(items, days+"="+times)
+		i = j + 1
+	}
+	return strings.Join(items, "|")
+}
+
+func sameHours(a, b HoursRecord) bool {
+	return a.Closed == b.Closed && (a.Closed || a.Open == b.Open && a.Close == b.Close)
+}
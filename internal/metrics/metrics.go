@@ -0,0 +1,192 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// ===========================================
+// PROMETHEUS METRICS
+// ===========================================
+// All collectors live in one registry exposed at /metrics.
+// Label values are kept low-cardinality: route templates, not raw paths.
+
+const namespace = "beauty_salons"
+
+// Registry holds every metric the API exports
+var Registry = prometheus.NewRegistry()
+
+var (
+	// HTTP
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route and status code.",
+	}, []string{"method", "route", "status"})
+
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// Elasticsearch
+	ESQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "elasticsearch_request_duration_seconds",
+		Help:      "Elasticsearch request latency by operation.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation"})
+
+	ESErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "elasticsearch_errors_total",
+		Help:      "Failed Elasticsearch requests by operation.",
+	}, []string{"operation"})
+
+	ESShardFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "elasticsearch_shard_failures_total",
+		Help:      "Shards that failed to answer an otherwise successful search, by operation.",
+	}, []string{"operation"})
+
+	// PostgreSQL
+	PGQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "postgres_query_duration_seconds",
+		Help:      "PostgreSQL query latency by query name.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"query"})
+
+	PGErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "postgres_errors_total",
+		Help:      "Failed PostgreSQL queries by query name.",
+	}, []string{"query"})
+
+	PGReplicaUp = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "postgres_replica_up",
+		Help:      "1 when reads go to the read replica, 0 when they fall back to the primary.",
+	})
+
+	// Search
+	SearchRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "search_requests_total",
+		Help:      "Searches answered, by backend.",
+	}, []string{"backend"})
+
+	SearchZeroResults = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "search_zero_results_total",
+		Help:      "Searches that returned no results, by backend.",
+	}, []string{"backend"})
+
+	SearchFallbacks = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "search_fallbacks_total",
+		Help:      "Searches served by PostgreSQL because Elasticsearch failed, by reason.",
+	}, []string{"reason"})
+
+	// Analytics
+	AnalyticsEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "analytics_events_total",
+		Help:      "Search analytics events by outcome (written, dropped, failed).",
+	}, []string{"status"})
+
+	// Geocoding
+	GeocodeRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "geocode_requests_total",
+		Help:      "Salon addresses geocoded, by geocoder and resulting status (ok, not_found, failed).",
+	}, []string{"geocoder", "status"})
+
+	GeocodeDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "geocode_duration_seconds",
+		Help:      "Latency of geocoding a salon address, including cache lookups, by geocoder.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"geocoder"})
+
+	// Sync / bulk indexing
+	SyncDocumentsIndexed = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sync_documents_indexed_total",
+		Help:      "Documents successfully bulk indexed into Elasticsearch.",
+	})
+
+	SyncDocumentsFailed = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sync_documents_failed_total",
+		Help:      "Documents rejected by Elasticsearch during bulk indexing.",
+	})
+
+	SyncDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "sync_duration_seconds",
+		Help:      "Duration of full PostgreSQL to Elasticsearch syncs, by outcome.",
+		Buckets:   []float64{0.5, 1, 2.5, 5, 10, 30, 60, 120, 300},
+	}, []string{"status"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests, HTTPDuration,
+		ESQueryDuration, ESErrors, ESShardFailures,
+		PGQueryDuration, PGErrors,
+		SearchRequests, SearchZeroResults, SearchFallbacks,
+		AnalyticsEvents,
+		GeocodeRequests, GeocodeDuration,
+		SyncDocumentsIndexed, SyncDocumentsFailed, SyncDuration,
+	)
+}
+
+// Handler serves the registry in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// RegisterDBStats exports connection pool statistics for a database
+func RegisterDBStats(db *sql.DB, name string) error {
+	return Registry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// ObserveElasticsearch records the latency and outcome of an Elasticsearch request
+func ObserveElasticsearch(operation string, start time.Time, err error) {
+	ESQueryDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	if err != nil {
+		ESErrors.WithLabelValues(operation).Inc()
+	}
+}
+
+// ObservePostgres records the latency and outcome of a PostgreSQL query
+func ObservePostgres(query string, start time.Time, err error) {
+	PGQueryDuration.WithLabelValues(query).Observe(time.Since(start).Seconds())
+	if err != nil {
+		PGErrors.WithLabelValues(query).Inc()
+	}
+}
+
+// ObserveGeocode records the latency and outcome of geocoding an address
+func ObserveGeocode(geocoder, status string, start time.Time) {
+	GeocodeDuration.WithLabelValues(geocoder).Observe(time.Since(start).Seconds())
+	GeocodeRequests.WithLabelValues(geocoder, status).Inc()
+}
+
+// ObserveSearch counts a search answered by a backend and whether it was empty
+func ObserveSearch(backend string, total int) {
+	SearchRequests.WithLabelValues(backend).Inc()
+	if total == 0 {
+		SearchZeroResults.WithLabelValues(backend).Inc()
+	}
+}
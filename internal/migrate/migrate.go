@@ -0,0 +1,382 @@
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// ===========================================
+// SCHEMA MIGRATIONS
+// ===========================================
+// Migrations are NNN_name.sql files, applied in version order, each in its
+// own transaction together with its schema_migrations row. The optional
+// down/NNN_name.sql file reverts it. A PostgreSQL advisory lock keeps
+// concurrent runners (several API replicas starting at once) from applying
+// the same migration twice.
+
+// LockKey identifies the advisory lock held while migrating
+const LockKey int64 = 0x6265617574790001
+
+// DownDir is the directory of down migrations, named like their up files
+const DownDir = "down"
+
+var fileName = regexp.MustCompile(`^(\d+)_([A-Za-z0-9_]+)\.sql$`)
+
+// Migration is one schema change
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string // Empty when the migration can't be reverted
+	Checksum string // SHA-256 of Up
+}
+
+// Status is a migration and whether the database has it
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt *time.Time
+	Modified  bool // Applied with different contents than the file has now
+	Missing   bool // Applied, but the file no longer exists
+}
+
+// State returns a one-word summary of the status
+func (s Status) State() string {
+	switch {
+	case s.Missing:
+		return "missing"
+	case s.Modified:
+		return "modified"
+	case s.Applied:
+		return "applied"
+	default:
+		return "pending"
+	}
+}
+
+// Load reads the migrations in fsys, sorted by version
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	var migrations []Migration
+	byName := map[string]*Migration{}
+	versions := map[int64]string{}
+	for _, e := range entries {
+		if e.IsDir() || path.Ext(e.Name()) != ".sql" {
+			continue
+		}
+		m := fileName.FindStringSubmatch(e.Name())
+		if m == nil {
+			return nil, fmt.Errorf("migration %s: name must look like 001_description.sql", e.Name())
+		}
+		version, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", e.Name(), err)
+		}
+		if other, ok := versions[version]; ok {
+			return nil, fmt.Errorf("migrations %s and %s have the same version", other, e.Name())
+		}
+		versions[version] = e.Name()
+
+		up, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", e.Name(), err)
+		}
+		sum := sha256.Sum256(up)
+		migrations = append(migrations, Migration{
+			Version:  version,
+			Name:     m[2],
+			Up:       string(up),
+			Checksum: hex.EncodeToString(sum[:]),
+		})
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	for i := range migrations {
+		byName[versions[migrations[i].Version]] = &migrations[i]
+	}
+
+	downs, err := fs.ReadDir(fsys, DownDir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read down migrations: %w", err)
+	}
+	for _, e := range downs {
+		if e.IsDir() || path.Ext(e.Name()) != ".sql" {
+			continue
+		}
+		m, ok := byName[e.Name()]
+		if !ok {
+			return nil, fmt.Errorf("down migration %s has no up migration", e.Name())
+		}
+		down, err := fs.ReadFile(fsys, path.Join(DownDir, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read down migration %s: %w", e.Name(), err)
+		}
+		m.Down = string(down)
+	}
+
+	return migrations, nil
+}
+
+// Migrator applies migrations to a database
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// New loads the migrations in fsys for db
+func New(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+const createTable = `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT PRIMARY KEY,
+		name TEXT NOT NULL,
+		checksum TEXT NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)
+`
+
+// appliedRow is a schema_migrations row
+type appliedRow struct {
+	version   int64
+	checksum  string
+	name      string
+	appliedAt time.Time
+}
+
+// querier is what applied needs from *sql.DB and *sql.Conn
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// applied returns the schema_migrations rows by version; none when the
+// table doesn't exist yet
+func applied(ctx context.Context, q querier) (map[int64]appliedRow, error) {
+	var exists bool
+	if err := q.QueryRowContext(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to check schema_migrations: %w", err)
+	}
+	rows := map[int64]appliedRow{}
+	if !exists {
+		return rows, nil
+	}
+
+	result, err := q.QueryContext(ctx, `SELECT version, name, checksum, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer result.Close()
+	for result.Next() {
+		var row appliedRow
+		if err := result.Scan(&row.version, &row.name, &row.checksum, &row.appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+		}
+		rows[row.version] = row
+	}
+	return rows, result.Err()
+}
+
+// Status lists every migration, file or applied, by version
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	rows, err := applied(ctx, m.db)
+	if err != nil {
+		return nil, err
+	}
+	return status(m.migrations, rows), nil
+}
+
+func status(migrations []Migration, rows map[int64]appliedRow) []Status {
+	statuses := make([]Status, 0, len(migrations))
+	known := map[int64]bool{}
+	for _, mig := range migrations {
+		known[mig.Version] = true
+		s := Status{Migration: mig}
+		if row, ok := rows[mig.Version]; ok {
+			appliedAt := row.appliedAt
+			s.Applied, s.AppliedAt = true, &appliedAt
+			s.Modified = row.checksum != mig.Checksum
+		}
+		statuses = append(statuses, s)
+	}
+	for version, row := range rows {
+		if !known[version] {
+			appliedAt := row.appliedAt
+			statuses = append(statuses, Status{
+				Migration: Migration{Version: version, Name: row.name, Checksum: row.checksum},
+				Applied:   true,
+				AppliedAt: &appliedAt,
+				Missing:   true,
+			})
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses
+}
+
+// Up applies every pending migration in version order and returns the ones
+// it applied. It stops at the first failure, which is rolled back.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := m.locked(ctx, func(conn *sql.Conn, rows map[int64]appliedRow) error {
+		if len(rows) == 0 {
+			if err := checkUntracked(ctx, conn); err != nil {
+				return err
+			}
+		}
+		for _, mig := range m.migrations {
+			if _, ok := rows[mig.Version]; ok {
+				continue
+			}
+			err := inTx(ctx, conn, func(tx *sql.Tx) error {
+				if _, err := tx.ExecContext(ctx, mig.Up); err != nil {
+					return err
+				}
+				_, err := tx.ExecContext(ctx,
+					`INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)`,
+					mig.Version, mig.Name, mig.Checksum)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("failed to apply migration %03d_%s: %w", mig.Version, mig.Name, err)
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down reverts the latest steps applied migrations, newest first, and
+// returns the ones it reverted
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.locked(ctx, func(conn *sql.Conn, rows map[int64]appliedRow) error {
+		statuses := status(m.migrations, rows)
+		for i := len(statuses) - 1; i >= 0 && len(done) < steps; i-- {
+			mig := statuses[i].Migration
+			if !statuses[i].Applied {
+				continue
+			}
+			if statuses[i].Missing {
+				return fmt.Errorf("migration %03d_%s is applied but its file is missing", mig.Version, mig.Name)
+			}
+			if mig.Down == "" {
+				return fmt.Errorf("migration %03d_%s has no down migration", mig.Version, mig.Name)
+			}
+			err := inTx(ctx, conn, func(tx *sql.Tx) error {
+				if _, err := tx.ExecContext(ctx, mig.Down); err != nil {
+					return err
+				}
+				_, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, mig.Version)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("failed to revert migration %03d_%s: %w", mig.Version, mig.Name, err)
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Baseline records every migration up to version as applied without
+// running it, for databases whose schema was created another way (such as
+// docker-entrypoint-initdb.d)
+func (m *Migrator) Baseline(ctx context.Context, version int64) ([]Migration, error) {
+	var done []Migration
+	err := m.locked(ctx, func(conn *sql.Conn, rows map[int64]appliedRow) error {
+		return inTx(ctx, conn, func(tx *sql.Tx) error {
+			for _, mig := range m.migrations {
+				if mig.Version > version {
+					break
+				}
+				if _, ok := rows[mig.Version]; ok {
+					continue
+				}
+				_, err := tx.ExecContext(ctx,
+					`INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)`,
+					mig.Version, mig.Name, mig.Checksum)
+				if err != nil {
+					return fmt.Errorf("failed to record migration %03d_%s: %w", mig.Version, mig.Name, err)
+				}
+				done = append(done, mig)
+			}
+			return nil
+		})
+	})
+	return done, err
+}
+
+// locked runs fn on one connection holding the advisory lock, after
+// creating schema_migrations if needed
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn, rows map[int64]appliedRow) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get a connection: %w", err)
+	}
+	defer conn.Close()
+
+	// Waits for a concurrent runner to finish; the lock belongs to this
+	// session and is released with it
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, LockKey); err != nil {
+		return fmt.Errorf("failed to acquire the migration lock: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, LockKey)
+
+	if _, err := conn.ExecContext(ctx, createTable); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	// Read under the lock, so migrations applied by the runner that held it
+	// are seen
+	rows, err := applied(ctx, conn)
+	if err != nil {
+		return err
+	}
+	return fn(conn, rows)
+}
+
+// checkUntracked refuses to migrate a database that has a schema but no
+// migration history, which would re-run migrations such as the seed data
+func checkUntracked(ctx context.Context, conn *sql.Conn) error {
+	var exists bool
+	if err := conn.QueryRowContext(ctx, `SELECT to_regclass('salons') IS NOT NULL`).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to inspect the schema: %w", err)
+	}
+	if exists {
+		return errors.New("the database has a schema but no migration history; record the migrations it already has with `migrate baseline <version>`")
+	}
+	return nil
+}
+
+// inTx runs fn in a transaction on conn
+func inTx(ctx context.Context, conn *sql.Conn, fn func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
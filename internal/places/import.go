@@ -0,0 +1,224 @@
+package places
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"beauty-salons/internal/domain"
+)
+
+// csvColumns is the header a places CSV file must start with
+var csvColumns = []string{"name", "kind", "city", "state", "country", "latitude", "longitude", "radius_km"}
+
+// Load reads places from a .csv or .geojson (.json) file
+func Load(path string) ([]domain.Place, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open places file: %w", err)
+	}
+	defer f.Close()
+
+	var places []domain.Place
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		places, err = ParseCSV(f)
+	case ".geojson", ".json":
+		places, err = ParseGeoJSON(f)
+	default:
+		return nil, fmt.Errorf("%s: places files must be .csv or .geojson", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return places, nil
+}
+
+// ParseCSV reads rows of
+//
+//	name,kind,city,state,country,latitude,longitude,radius_km
+//
+// after a header row with those names. city, state, country and radius_km
+// may be empty; kind defaults to neighborhood.
+func ParseCSV(r io.Reader) ([]domain.Place, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = len(csvColumns)
+	cr.TrimLeadingSpace = true
+	cr.Comment = '#'
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	for i, col := range csvColumns {
+		if strings.TrimSpace(strings.ToLower(header[i])) != col {
+			return nil, fmt.Errorf("header must be %s", strings.Join(csvColumns, ","))
+		}
+	}
+
+	var places []domain.Place
+	for {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+
+		lat, latErr := strconv.ParseFloat(row[5], 64)
+		lon, lonErr := strconv.ParseFloat(row[6], 64)
+		if latErr != nil || lonErr != nil {
+			return nil, fmt.Errorf("line %d: invalid coordinates", line)
+		}
+		var radius float64
+		if row[7] != "" {
+			if radius, err = strconv.ParseFloat(row[7], 64); err != nil {
+				return nil, fmt.Errorf("line %d: invalid radius_km %q", line, row[7])
+			}
+		}
+
+		place, err := newPlace(row[0], row[1], row[2], row[3], row[4], domain.GeoPoint{Latitude: lat, Longitude: lon}, radius)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		places = append(places, place)
+	}
+	return places, nil
+}
+
+// geoJSONFeature is the part of a GeoJSON Feature used for places
+type geoJSONFeature struct {
+	Properties struct {
+		Name     string  `json:"name"`
+		Kind     string  `json:"kind"`
+		City     string  `json:"city"`
+		State    string  `json:"state"`
+		Country  string  `json:"country"`
+		RadiusKm float64 `json:"radius_km"`
+	} `json:"properties"`
+	Geometry struct {
+		Type        string          `json:"type"`
+		Coordinates json.RawMessage `json:"coordinates"`
+	} `json:"geometry"`
+}
+
+// ParseGeoJSON reads a FeatureCollection whose features have name, kind,
+// city, state, country and radius_km properties. Point features are used
+// as is; for Polygon and MultiPolygon features the centroid is the center
+// of the bounding box and, unless radius_km is set, the radius reaches the
+// farthest vertex.
+func ParseGeoJSON(r io.Reader) ([]domain.Place, error) {
+	var collection struct {
+		Type     string           `json:"type"`
+		Features []geoJSONFeature `json:"features"`
+	}
+	if err := json.NewDecoder(r).Decode(&collection); err != nil {
+		return nil, fmt.Errorf("invalid GeoJSON: %w", err)
+	}
+	if collection.Type != "FeatureCollection" {
+		return nil, fmt.Errorf("GeoJSON type must be \"FeatureCollection\", got %q", collection.Type)
+	}
+
+	places := make([]domain.Place, 0, len(collection.Features))
+	for i, feature := range collection.Features {
+		centroid, extent, err := featureCentroid(feature.Geometry.Type, feature.Geometry.Coordinates)
+		if err != nil {
+			return nil, fmt.Errorf("feature %d: %w", i, err)
+		}
+		radius := feature.Properties.RadiusKm
+		if radius == 0 {
+			radius = extent
+		}
+
+		p := feature.Properties
+		place, err := newPlace(p.Name, p.Kind, p.City, p.State, p.Country, centroid, radius)
+		if err != nil {
+			return nil, fmt.Errorf("feature %d: %w", i, err)
+		}
+		places = append(places, place)
+	}
+	return places, nil
+}
+
+// featureCentroid returns the centroid of a geometry and, for polygons, the
+// distance in km from it to the farthest vertex
+func featureCentroid(geometryType string, coordinates json.RawMessage) (domain.GeoPoint, float64, error) {
+	var positions [][]float64
+	switch geometryType {
+	case "Point":
+		var pos []float64
+		if err := json.Unmarshal(coordinates, &pos); err != nil || len(pos) < 2 {
+			return domain.GeoPoint{}, 0, errors.New("invalid Point coordinates")
+		}
+		return domain.GeoPoint{Latitude: pos[1], Longitude: pos[0]}, 0, nil
+	case "Polygon":
+		var rings [][][]float64
+		if err := json.Unmarshal(coordinates, &rings); err != nil || len(rings) == 0 {
+			return domain.GeoPoint{}, 0, errors.New("invalid Polygon coordinates")
+		}
+		positions = rings[0]
+	case "MultiPolygon":
+		var polygons [][][][]float64
+		if err := json.Unmarshal(coordinates, &polygons); err != nil {
+			return domain.GeoPoint{}, 0, errors.New("invalid MultiPolygon coordinates")
+		}
+		for _, rings := range polygons {
+			if len(rings) > 0 {
+				positions = append(positions, rings[0]...)
+			}
+		}
+	default:
+		return domain.GeoPoint{}, 0, fmt.Errorf("unsupported geometry type %q", geometryType)
+	}
+
+	if len(positions) == 0 {
+		return domain.GeoPoint{}, 0, fmt.Errorf("%s has no positions", geometryType)
+	}
+	box := domain.BoundingBox{MinLon: math.Inf(1), MinLat: math.Inf(1), MaxLon: math.Inf(-1), MaxLat: math.Inf(-1)}
+	for _, pos := range positions {
+		if len(pos) < 2 {
+			return domain.GeoPoint{}, 0, errors.New("positions must be [lon, lat]")
+		}
+		box.MinLon, box.MaxLon = math.Min(box.MinLon, pos[0]), math.Max(box.MaxLon, pos[0])
+		box.MinLat, box.MaxLat = math.Min(box.MinLat, pos[1]), math.Max(box.MaxLat, pos[1])
+	}
+
+	centroid := domain.GeoPoint{Latitude: (box.MinLat + box.MaxLat) / 2, Longitude: (box.MinLon + box.MaxLon) / 2}
+	var extent float64
+	for _, pos := range positions {
+		extent = math.Max(extent, centroid.DistanceTo(domain.GeoPoint{Latitude: pos[1], Longitude: pos[0]}))
+	}
+	return centroid, extent, nil
+}
+
+// newPlace builds and validates an imported place, filling in defaults
+func newPlace(name, kind, city, state, country string, centroid domain.GeoPoint, radiusKm float64) (domain.Place, error) {
+	place := domain.Place{
+		Name:     strings.TrimSpace(name),
+		Kind:     domain.PlaceKind(strings.ToLower(strings.TrimSpace(kind))),
+		City:     strings.TrimSpace(city),
+		State:    strings.TrimSpace(state),
+		Country:  strings.TrimSpace(country),
+		Centroid: centroid,
+		RadiusKm: radiusKm,
+	}
+	if place.Kind == "" {
+		place.Kind = domain.PlaceNeighborhood
+	}
+	if place.RadiusKm == 0 {
+		place.RadiusKm = DefaultRadiusKm(place.Kind)
+	}
+	if err := place.Validate(); err != nil {
+		return domain.Place{}, err
+	}
+	return place, nil
+}
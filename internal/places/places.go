@@ -0,0 +1,95 @@
+package places
+
+import (
+	"strings"
+
+	"beauty-salons/internal/domain"
+	"beauty-salons/internal/synonyms"
+)
+
+// ===========================================
+// PLACES
+// ===========================================
+// Named places (cities, neighborhoods, landmarks) let users search near
+// "Plaza Colón" or "Güemes, Mar del Plata" without sending coordinates.
+// Places are imported from CSV or GeoJSON into PostgreSQL, keyed by their
+// normalized name, and a near= query resolves to the best match.
+
+// Default radii for places imported without one
+const (
+	DefaultCityRadiusKm         = 10.0
+	DefaultNeighborhoodRadiusKm = 1.5
+	DefaultLandmarkRadiusKm     = 0.5
+)
+
+// DefaultRadiusKm returns the radius used for a kind of place when the
+// import doesn't give one
+func DefaultRadiusKm(kind domain.PlaceKind) float64 {
+	switch kind {
+	case domain.PlaceCity:
+		return DefaultCityRadiusKm
+	case domain.PlaceNeighborhood:
+		return DefaultNeighborhoodRadiusKm
+	default:
+		return DefaultLandmarkRadiusKm
+	}
+}
+
+// nearPrefixes are leading words of a near= value that aren't part of the
+// place name, already normalized
+var nearPrefixes = [][]string{
+	{"cerca", "de", "la"},
+	{"cerca", "de"},
+	{"cerca", "del"},
+	{"junto", "a"},
+	{"frente", "a"},
+	{"close", "to"},
+	{"near"},
+	{"en"},
+	{"in"},
+}
+
+// Normalize lowercases a name, folds accents and collapses punctuation and
+// whitespace, the way place names are stored for lookups
+func Normalize(s string) string {
+	return strings.Join(synonyms.Tokens(s), " ")
+}
+
+// Query is a parsed near= value
+type Query struct {
+	Name       string   // Normalized place name
+	Qualifiers []string // Normalized city, state or country the place must be in
+}
+
+// ParseQuery splits "cerca de Güemes, Mar del Plata" into the place name
+// "guemes" and the qualifier "mar del plata". Every comma separated part
+// after the first is a qualifier.
+func ParseQuery(s string) Query {
+	parts := strings.Split(s, ",")
+
+	var q Query
+	name := synonyms.Tokens(parts[0])
+	for _, prefix := range nearPrefixes {
+		if len(name) > len(prefix) && hasPrefix(name, prefix) {
+			name = name[len(prefix):]
+			break
+		}
+	}
+	q.Name = strings.Join(name, " ")
+
+	for _, part := range parts[1:] {
+		if qualifier := Normalize(part); qualifier != "" {
+			q.Qualifiers = append(q.Qualifiers, qualifier)
+		}
+	}
+	return q
+}
+
+func hasPrefix(tokens, prefix []string) bool {
+	for i, word := range prefix {
+		if tokens[i] != word {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,132 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// ===========================================
+// TOKEN BUCKET RATE LIMITING
+// ===========================================
+// Each client gets a bucket of Burst tokens that refills at
+// PerMinute/60 tokens per second. A request costs one token.
+
+// Limit describes a token bucket
+type Limit struct {
+	PerMinute int `yaml:"per_minute"` // Sustained requests per minute
+	Burst     int `yaml:"burst"`      // Bucket capacity
+}
+
+// rate returns the refill rate in tokens per second
+func (l Limit) rate() float64 {
+	return float64(l.PerMinute) / 60
+}
+
+// Result is the outcome of taking a token
+type Result struct {
+	Allowed    bool
+	Limit      int           // Bucket capacity
+	Remaining  int           // Whole tokens left after this request
+	Reset      time.Duration // Time until the bucket is full again
+	RetryAfter time.Duration // Time until the next token (only when not allowed)
+}
+
+// Store keeps bucket state. The in-memory store is per process; a shared
+// implementation (e.g. Redis) lets several replicas enforce one limit.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// bucket is the state of one token bucket
+type bucket struct {
+	tokens float64
+	last   time.Time
+	limit  Limit
+}
+
+// MemoryStore is an in-process Store
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// sweepInterval is how often idle, full buckets are dropped
+const sweepInterval = time.Minute
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// Take implements Store
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		s.sweep(now)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		s.buckets[key] = b
+	}
+	b.limit = limit
+
+	return take(b, limit, now), nil
+}
+
+// Len returns the number of tracked buckets
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.buckets)
+}
+
+// sweep drops buckets that have been idle long enough to be full again,
+// since a fresh bucket behaves identically
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, b := range s.buckets {
+		full := refillTime(float64(b.limit.Burst)-b.tokens, b.limit)
+		if now.Sub(b.last) >= full {
+			delete(s.buckets, key)
+		}
+	}
+	s.lastSweep = now
+}
+
+// take refills the bucket for the elapsed time and tries to spend one token
+func take(b *bucket, limit Limit, now time.Time) Result {
+	capacity := float64(limit.Burst)
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(capacity, b.tokens+elapsed*limit.rate())
+	}
+	b.last = now
+
+	res := Result{Limit: limit.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = refillTime(1-b.tokens, limit)
+	}
+	res.Remaining = int(math.Floor(b.tokens))
+	res.Reset = refillTime(capacity-b.tokens, limit)
+	return res
+}
+
+// refillTime is how long it takes to regain the given number of tokens
+func refillTime(tokens float64, limit Limit) time.Duration {
+	if tokens <= 0 || limit.rate() <= 0 {
+		return 0
+	}
+	return time.Duration(tokens / limit.rate() * float64(time.Second))
+}
@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"beauty-salons/internal/domain"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ===========================================
+// SEARCH ANALYTICS
+// ===========================================
+// Events are written in batches by the analytics recorder; reports
+// aggregate them over a time window.
+
+// searchEventColumns are inserted for every event, in this order
+const searchEventColumns = 13
+
+// InsertSearchEvents writes a batch of events in a single statement
+func (r *PostgresRepository) InsertSearchEvents(ctx context.Context, events []domain.SearchEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString(`INSERT INTO search_events (
+		event_type, search_id, query, normalized_query, filters, backend,
+		total_hits, latency_ms, page, page_size, salon_id, position, created_at
+	) VALUES `)
+
+	args := make([]interface{}, 0, len(events)*searchEventColumns)
+	for i, e := range events {
+		filters, err := json.Marshal(e.Filters)
+		if err != nil {
+			return fmt.Errorf("failed to encode filters: %w", err)
+		}
+		if e.Filters == nil {
+			filters = []byte("{}")
+		}
+
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		n := len(args)
+		fmt.Fprintf(&sb, "($%d, $%d, $%d, $%d, $%d::jsonb, NULLIF($%d, ''), $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+			n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8, n+9, n+10, n+11, n+12, n+13)
+
+		args = append(args,
+			e.Type, e.SearchID, e.Query, domain.NormalizeQuery(e.Query), string(filters), e.Backend,
+			e.TotalHits, e.LatencyMs, max(e.Page, 1), max(e.PageSize, 1), e.SalonID, e.Position, e.CreatedAt,
+		)
+	}
+
+	err := r.run(ctx, writeQuery, "insert_search_events", "INSERT INTO search_events ...", func(ctx context.Context, db *sqlx.DB) error {
+		_, err := db.ExecContext(ctx, sb.String(), args...)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to insert search events: %w", err)
+	}
+	return nil
+}
+
+// TopQueries returns the most frequent non-empty queries since the given time
+func (r *PostgresRepository) TopQueries(ctx context.Context, since time.Time, limit int) ([]domain.QueryStat, error) {
+	return r.queryStats(ctx, "top_queries", since, limit, false)
+}
+
+// ZeroResultQueries returns the most frequent queries that found nothing
+func (r *PostgresRepository) ZeroResultQueries(ctx context.Context, since time.Time, limit int) ([]domain.QueryStat, error) {
+	return r.queryStats(ctx, "zero_result_queries", since, limit, true)
+}
+
+// queryStats groups search events by normalized query
+func (r *PostgresRepository) queryStats(ctx context.Context, name string, since time.Time, limit int, zeroOnly bool) ([]domain.QueryStat, error) {
+	query := `
+		SELECT
+			s.normalized_query AS query,
+			COUNT(*) AS searches,
+			COALESCE(AVG(s.total_hits), 0) AS avg_hits,
+			COALESCE(AVG(s.latency_ms), 0) AS avg_latency_ms,
+			COALESCE(SUM(c.clicks), 0) AS clicks,
+			MAX(s.created_at) AS last_seen_at
+		FROM search_events s
+		LEFT JOIN (
+			SELECT search_id, COUNT(*) AS clicks
+			FROM search_events
+			WHERE event_type = 'click' AND created_at >= $1
+			GROUP BY search_id
+		) c ON c.search_id = s.search_id
+		WHERE s.event_type = 'search'
+		  AND s.created_at >= $1
+		  AND s.normalized_query <> ''
+		  AND ($3 = false OR s.total_hits = 0)
+		GROUP BY s.normalized_query
+		ORDER BY searches DESC, query
+		LIMIT $2
+	`
+
+	stats := []domain.QueryStat{}
+	err := r.run(ctx, readQuery, name, query, func(ctx context.Context, db *sqlx.DB) error {
+		stats = stats[:0]
+		return db.SelectContext(ctx, &stats, query, since, limit, zeroOnly)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get query stats: %w", err)
+	}
+	return stats, nil
+}
+
+// ClickThroughByPosition returns clicks and impressions for result positions
+// 1..maxPosition. A search counts as an impression for every position it
+// displayed, given its page, page size and total hits.
+func (r *PostgresRepository) ClickThroughByPosition(ctx context.Context, since time.Time, maxPosition int) ([]domain.PositionCTR, error) {
+	query := `
+		WITH positions AS (
+			SELECT generate_series(1, $2) AS position
+		),
+		impressions AS (
+			SELECT p.position, COUNT(e.id) AS impressions
+			FROM positions p
+			LEFT JOIN search_events e
+			  ON e.event_type = 'search'
+			 AND e.created_at >= $1
+			 AND p.position > (e.page - 1) * e.page_size
+			 AND p.position <= LEAST(e.page * e.page_size, e.total_hits)
+			GROUP BY p.position
+		),
+		clicks AS (
+			SELECT position, COUNT(*) AS clicks
+			FROM search_events
+			WHERE event_type = 'click' AND created_at >= $1 AND position BETWEEN 1 AND $2
+			GROUP BY position
+		)
+		SELECT i.position, i.impressions, COALESCE(c.clicks, 0) AS clicks
+		FROM impressions i
+		LEFT JOIN clicks c ON c.position = i.position
+		ORDER BY i.position
+	`
+
+	var rows []domain.PositionCTR
+	err := r.run(ctx, readQuery, "click_through_by_position", query, func(ctx context.Context, db *sqlx.DB) error {
+		rows = rows[:0]
+		return db.SelectContext(ctx, &rows, query, since, maxPosition)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get click-through rates: %w", err)
+	}
+
+	for i := range rows {
+		if rows[i].Impressions > 0 {
+			rows[i].CTR = float64(rows[i].Clicks) / float64(rows[i].Impressions)
+		}
+	}
+	return rows, nil
+}
+
+// FilterUsage returns the most used filter values
+func (r *PostgresRepository) FilterUsage(ctx context.Context, since time.Time, limit int) ([]domain.FilterUsage, error) {
+	query := `
+		SELECT f.key AS filter, f.value AS value, COUNT(*) AS searches
+		FROM search_events e, jsonb_each_text(e.filters) AS f(key, value)
+		WHERE e.event_type = 'search' AND e.created_at >= $1
+		GROUP BY f.key, f.value
+		ORDER BY searches DESC, filter, value
+		LIMIT $2
+	`
+
+	usage := []domain.FilterUsage{}
+	err := r.run(ctx, readQuery, "filter_usage", query, func(ctx context.Context, db *sqlx.DB) error {
+		usage = usage[:0]
+		return db.SelectContext(ctx, &usage, query, since, limit)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get filter usage: %w", err)
+	}
+	return usage, nil
+}
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"beauty-salons/internal/domain"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// ===========================================
+// CATALOG EXPORT
+// ===========================================
+// Exports read the catalog through a server-side cursor inside a read-only
+// snapshot, so memory stays bounded by the batch size and every batch sees
+// the same data.
+
+// ExportBatchSize is the number of salons fetched from the cursor at a time
+const ExportBatchSize = 500
+
+// ExportSalons streams the active salons matching params, in ID order, to fn
+// in batches with their category, services, amenities and hours loaded.
+// Pagination and sorting params are ignored. An error from fn stops the
+// export and is returned as is.
+func (r *PostgresRepository) ExportSalons(ctx context.Context, params domain.SalonSearchParams, fn func([]domain.Salon) error) error {
+	f := r.newSalonFilter(params)
+	query := fmt.Sprintf(`
+		DECLARE salon_export NO SCROLL CURSOR FOR
+		SELECT
+			s.id, s.name, s.slug, s.description,
+			s.address, s.city, s.state, s.postal_code, s.country,
+			s.latitude, s.longitude, s.geocode_status,
+			s.phone, s.email, s.website,
+			s.category_id, s.price_range, s.rating, s.review_count,
+			s.is_active, s.is_verified, s.created_at, s.updated_at,
+			c.name as category_name, c.slug as category_slug, c.created_at as category_created_at
+		%s
+		ORDER BY s.id
+	`, f.clause())
+
+	qctx, done := startQuery(ctx, "export_salons", query)
+	err := r.exportSalons(qctx, query, f.args, fn)
+	done(err)
+	return err
+}
+
+func (r *PostgresRepository) exportSalons(ctx context.Context, query string, args []interface{}, fn func([]domain.Salon) error) error {
+	tx, err := r.beginTx(ctx, exportQuery, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("failed to begin export: %w", err)
+	}
+	// Read-only, so rolling back just closes the cursor
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to open export cursor: %w", err)
+	}
+
+	fetch := fmt.Sprintf(`FETCH FORWARD %d FROM salon_export`, ExportBatchSize)
+	for {
+		var rows []salonRow
+		if err := tx.SelectContext(ctx, &rows, fetch); err != nil {
+			return fmt.Errorf("failed to fetch salons: %w", err)
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+
+		salons := make([]domain.Salon, len(rows))
+		for i, row := range rows {
+			salons[i] = row.toDomain()
+		}
+		if err := loadSalonDetails(ctx, tx, salons); err != nil {
+			return err
+		}
+		if err := fn(salons); err != nil {
+			return err
+		}
+		if len(rows) < ExportBatchSize {
+			return nil
+		}
+	}
+}
+
+// loadSalonDetails fills in the services, amenities and hours of a batch of
+// salons with one query each
+func loadSalonDetails(ctx context.Context, tx *sqlx.Tx, salons []domain.Salon) error {
+	ids := make([]int64, len(salons))
+	byID := make(map[int64]*domain.Salon, len(salons))
+	for i := range salons {
+		ids[i] = salons[i].ID
+		byID[ids[i]] = &salons[i]
+		// Empty rather than nil, so salons without any export empty lists
+		salons[i].Services = []domain.Service{}
+		salons[i].Amenities = []domain.Amenity{}
+		salons[i].OperatingHours = []domain.OperatingHours{}
+	}
+
+	var services []domain.Service
+	err := tx.SelectContext(ctx, &services, `
+		SELECT id, salon_id, name, description, price_min, price_max, duration_minutes, created_at
+		FROM services
+		WHERE salon_id = ANY($1)
+		ORDER BY salon_id, id
+	`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to get services: %w", err)
+	}
+	for _, s := range services {
+		salon := byID[s.SalonID]
+		salon.Services = append(salon.Services, s)
+	}
+
+	var amenities []struct {
+		SalonID int64 `db:"salon_id"`
+		domain.Amenity
+	}
+	err = tx.SelectContext(ctx, &amenities, `
+		SELECT sa.salon_id, a.id, a.name, COALESCE(a.icon, '') AS icon
+		FROM salon_amenities sa
+		JOIN amenities a ON a.id = sa.amenity_id
+		WHERE sa.salon_id = ANY($1)
+		ORDER BY sa.salon_id, a.id
+	`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to get amenities: %w", err)
+	}
+	for _, a := range amenities {
+		salon := byID[a.SalonID]
+		salon.Amenities = append(salon.Amenities, a.Amenity)
+	}
+
+	var hours []domain.OperatingHours
+	err = tx.SelectContext(ctx, &hours, `
+		SELECT id, salon_id, day_of_week,
+			COALESCE(open_time::text, '') AS open_time,
+			COALESCE(close_time::text, '') AS close_time,
+			is_closed
+		FROM operating_hours
+		WHERE salon_id = ANY($1)
+		ORDER BY salon_id, day_of_week
+	`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to get operating hours: %w", err)
+	}
+	for _, h := range hours {
+		salon := byID[h.SalonID]
+		salon.OperatingHours = append(salon.OperatingHours, h)
+	}
+
+	return nil
+}
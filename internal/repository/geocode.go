@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"beauty-salons/internal/domain"
+	"beauty-salons/internal/geocode"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ===========================================
+// GEOCODING
+// ===========================================
+// salons.geocode_status tracks where coordinates came from; a trigger marks
+// salons whose address changed as pending. Lookups are cached by address
+// in geocode_cache.
+
+// SalonsToGeocode returns salons waiting for coordinates, in ID order
+func (r *PostgresRepository) SalonsToGeocode(ctx context.Context, afterID int64, limit int) ([]domain.Salon, error) {
+	query := `
+		SELECT
+			s.id, s.name, s.slug,
+			s.address, s.city, s.state, s.postal_code, s.country,
+			s.latitude, s.longitude, s.geocode_status,
+			s.is_active, s.is_verified, s.created_at, s.updated_at
+		FROM salons s
+		WHERE s.geocode_status IN ('pending', 'failed') AND s.id > $1
+		ORDER BY s.id
+		LIMIT $2
+	`
+
+	var rows []salonRow
+	err := r.run(ctx, writeQuery, "salons_to_geocode", query, func(ctx context.Context, db *sqlx.DB) error {
+		rows = rows[:0]
+		return db.SelectContext(ctx, &rows, query, afterID, limit)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get salons to geocode: %w", err)
+	}
+
+	salons := make([]domain.Salon, len(rows))
+	for i, row := range rows {
+		salons[i] = row.toDomain()
+	}
+	return salons, nil
+}
+
+// SetSalonGeocode records a geocoding outcome, only while the salon is still
+// waiting so a concurrent manual edit wins. GeocodeOK writes the point;
+// GeocodeNotFound clears coordinates left from a previous address.
+func (r *PostgresRepository) SetSalonGeocode(ctx context.Context, id int64, point *domain.GeoPoint, status domain.GeocodeStatus) error {
+	var lat, lon *float64
+	if status == domain.GeocodeOK && point != nil {
+		lat, lon = &point.Latitude, &point.Longitude
+	}
+
+	query := `
+		UPDATE salons SET
+			latitude = CASE $2 WHEN 'ok' THEN $3 WHEN 'not_found' THEN NULL ELSE latitude END,
+			longitude = CASE $2 WHEN 'ok' THEN $4 WHEN 'not_found' THEN NULL ELSE longitude END,
+			geocode_status = $2,
+			geocoded_at = NOW()
+		WHERE id = $1 AND geocode_status IN ('pending', 'failed')
+	`
+
+	err := r.run(ctx, writeQuery, "set_salon_geocode", query, func(ctx context.Context, db *sqlx.DB) error {
+		_, err := db.ExecContext(ctx, query, id, string(status), lat, lon)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set salon geocode: %w", err)
+	}
+	return nil
+}
+
+// GetGeocode implements geocode.Cache
+func (r *PostgresRepository) GetGeocode(ctx context.Context, key string, notFoundTTL time.Duration) (*geocode.Result, bool, error) {
+	query := `
+		SELECT found, latitude, longitude, display_name, provider
+		FROM geocode_cache
+		WHERE query = $1 AND (found OR created_at > NOW() - make_interval(secs => $2))
+	`
+
+	var row struct {
+		Found       bool     `db:"found"`
+		Latitude    *float64 `db:"latitude"`
+		Longitude   *float64 `db:"longitude"`
+		DisplayName string   `db:"display_name"`
+		Provider    string   `db:"provider"`
+	}
+	cached := true
+	err := r.run(ctx, writeQuery, "get_geocode", query, func(ctx context.Context, db *sqlx.DB) error {
+		err := db.GetContext(ctx, &row, query, key, notFoundTTL.Seconds())
+		if errors.Is(err, sql.ErrNoRows) {
+			cached = false
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get cached geocode: %w", err)
+	}
+	if !cached {
+		return nil, false, nil
+	}
+
+	if !row.Found || row.Latitude == nil || row.Longitude == nil {
+		return nil, true, nil
+	}
+	return &geocode.Result{
+		Point:       domain.GeoPoint{Latitude: *row.Latitude, Longitude: *row.Longitude},
+		DisplayName: row.DisplayName,
+		Provider:    row.Provider,
+	}, true, nil
+}
+
+// PutGeocode implements geocode.Cache
+func (r *PostgresRepository) PutGeocode(ctx context.Context, key string, result *geocode.Result) error {
+	var lat, lon *float64
+	var name, provider string
+	if result != nil {
+		lat, lon = &result.Point.Latitude, &result.Point.Longitude
+		name, provider = result.DisplayName, result.Provider
+	}
+
+	query := `
+		INSERT INTO geocode_cache (query, found, latitude, longitude, display_name, provider, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		ON CONFLICT (query) DO UPDATE SET
+			found = EXCLUDED.found,
+			latitude = EXCLUDED.latitude,
+			longitude = EXCLUDED.longitude,
+			display_name = EXCLUDED.display_name,
+			provider = EXCLUDED.provider,
+			created_at = EXCLUDED.created_at
+	`
+
+	err := r.run(ctx, writeQuery, "put_geocode", query, func(ctx context.Context, db *sqlx.DB) error {
+		_, err := db.ExecContext(ctx, query, key, result != nil, lat, lon, name, provider)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to cache geocode: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,232 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"beauty-salons/internal/domain"
+	"beauty-salons/internal/importer"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ===========================================
+// BULK IMPORT
+// ===========================================
+// Each batch is one transaction with a savepoint per salon, so a salon
+// that fails (unknown category, invalid service...) is rolled back alone.
+
+// UpsertSalons implements importer.Store. Salons are matched by slug;
+// services, amenities and hours are replaced when the salon has them
+// (non-nil) and kept otherwise. Imported coordinates replace existing ones,
+// which are also cleared when the address changes without new coordinates,
+// so the geocoding backfill picks the salon up.
+func (r *PostgresRepository) UpsertSalons(ctx context.Context, salons []domain.Salon, dryRun bool) ([]importer.UpsertResult, error) {
+	qctx, done := startQuery(ctx, "upsert_salons", upsertSalonQuery)
+	results, err := r.upsertSalons(qctx, salons, dryRun)
+	done(err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to import salons: %w", err)
+	}
+	return results, nil
+}
+
+func (r *PostgresRepository) upsertSalons(ctx context.Context, salons []domain.Salon, dryRun bool) ([]importer.UpsertResult, error) {
+	tx, err := r.beginTx(ctx, writeQuery, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	results := make([]importer.UpsertResult, len(salons))
+	for i := range salons {
+		if _, err := tx.ExecContext(ctx, `SAVEPOINT import_salon`); err != nil {
+			return nil, err
+		}
+
+		id, created, err := upsertSalon(ctx, tx, &salons[i])
+		if err != nil {
+			if _, rbErr := tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT import_salon`); rbErr != nil {
+				return nil, rbErr
+			}
+			results[i].Err = err
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, `RELEASE SAVEPOINT import_salon`); err != nil {
+			return nil, err
+		}
+		results[i] = importer.UpsertResult{ID: id, Created: created}
+	}
+
+	if dryRun {
+		return results, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+const upsertSalonQuery = `
+	INSERT INTO salons (
+		name, slug, description, address, city, state, postal_code, country,
+		latitude, longitude, phone, email, website,
+		category_id, price_range, rating, review_count, is_active, is_verified
+	) VALUES (
+		$1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, ''), NULLIF($7, ''), NULLIF($8, ''),
+		$9, $10, NULLIF($11, ''), NULLIF($12, ''), NULLIF($13, ''),
+		$14, NULLIF($15, 0), $16, $17, $18, $19
+	)
+	ON CONFLICT (slug) DO UPDATE SET
+		name = EXCLUDED.name,
+		description = EXCLUDED.description,
+		address = EXCLUDED.address,
+		city = EXCLUDED.city,
+		state = EXCLUDED.state,
+		postal_code = EXCLUDED.postal_code,
+		country = EXCLUDED.country,
+		latitude = CASE
+			WHEN EXCLUDED.latitude IS NOT NULL
+				OR (EXCLUDED.address, EXCLUDED.city, EXCLUDED.state, EXCLUDED.postal_code, EXCLUDED.country)
+					IS DISTINCT FROM (salons.address, salons.city, salons.state, salons.postal_code, salons.country)
+			THEN EXCLUDED.latitude ELSE salons.latitude END,
+		longitude = CASE
+			WHEN EXCLUDED.latitude IS NOT NULL
+				OR (EXCLUDED.address, EXCLUDED.city, EXCLUDED.state, EXCLUDED.postal_code, EXCLUDED.country)
+					IS DISTINCT FROM (salons.address, salons.city, salons.state, salons.postal_code, salons.country)
+			THEN EXCLUDED.longitude ELSE salons.longitude END,
+		phone = EXCLUDED.phone,
+		email = EXCLUDED.email,
+		website = EXCLUDED.website,
+		category_id = EXCLUDED.category_id,
+		price_range = EXCLUDED.price_range,
+		-- Ratings come from reviews; rows without one keep the current values
+		rating = COALESCE(EXCLUDED.rating, salons.rating),
+		review_count = CASE WHEN EXCLUDED.rating IS NULL THEN salons.review_count ELSE EXCLUDED.review_count END,
+		is_active = EXCLUDED.is_active,
+		is_verified = EXCLUDED.is_verified,
+		updated_at = CURRENT_TIMESTAMP
+	RETURNING id, (xmax = 0) AS created
+`
+
+// upsertSalon writes one salon and its related rows
+func upsertSalon(ctx context.Context, tx *sqlx.Tx, salon *domain.Salon) (int64, bool, error) {
+	var categoryID *int64
+	if salon.Category != nil {
+		var id int64
+		err := tx.GetContext(ctx, &id,
+			`SELECT id FROM categories WHERE slug = $1 OR lower(name) = lower($1)`, salon.Category.Slug)
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, false, fmt.Errorf("unknown category %q", salon.Category.Slug)
+		}
+		if err != nil {
+			return 0, false, err
+		}
+		categoryID = &id
+	}
+
+	var lat, lon *float64
+	if p := salon.Location.GeoPoint; p != nil {
+		lat, lon = &p.Latitude, &p.Longitude
+	}
+
+	var row struct {
+		ID      int64 `db:"id"`
+		Created bool  `db:"created"`
+	}
+	loc, contact := salon.Location, salon.Contact
+	err := tx.GetContext(ctx, &row, upsertSalonQuery,
+		salon.Name, salon.Slug, salon.Description,
+		loc.Address, loc.City, loc.State, loc.PostalCode, loc.Country,
+		lat, lon, contact.Phone, contact.Email, contact.Website,
+		categoryID, int(salon.PriceRange), salon.Rating, salon.ReviewCount, salon.IsActive, salon.IsVerified,
+	)
+	if err != nil {
+		return 0, false, err
+	}
+
+	if salon.Services != nil {
+		if err := replaceServices(ctx, tx, row.ID, salon.Services); err != nil {
+			return 0, false, err
+		}
+	}
+	if salon.Amenities != nil {
+		if err := replaceAmenities(ctx, tx, row.ID, salon.Amenities); err != nil {
+			return 0, false, err
+		}
+	}
+	if salon.OperatingHours != nil {
+		if err := replaceHours(ctx, tx, row.ID, salon.OperatingHours); err != nil {
+			return 0, false, err
+		}
+	}
+	return row.ID, row.Created, nil
+}
+
+func replaceServices(ctx context.Context, tx *sqlx.Tx, salonID int64, services []domain.Service) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM services WHERE salon_id = $1`, salonID); err != nil {
+		return err
+	}
+	for _, service := range services {
+		service.SalonID = salonID
+		if err := service.Validate(); err != nil {
+			return fmt.Errorf("service %q: %w", service.Name, err)
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO services (salon_id, name, description, price_min, price_max, duration_minutes)
+			VALUES ($1, $2, $3, $4, $5, $6)`,
+			salonID, service.Name, service.Description, service.PriceMin, service.PriceMax, service.DurationMinutes,
+		); err != nil {
+			return fmt.Errorf("service %q: %w", service.Name, err)
+		}
+	}
+	return nil
+}
+
+func replaceAmenities(ctx context.Context, tx *sqlx.Tx, salonID int64, amenities []domain.Amenity) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM salon_amenities WHERE salon_id = $1`, salonID); err != nil {
+		return err
+	}
+	for _, amenity := range amenities {
+		// Matched by name or icon; the catalog isn't extended by imports
+		res, err := tx.ExecContext(ctx, `
+			INSERT INTO salon_amenities (salon_id, amenity_id)
+			SELECT $1, id FROM amenities WHERE lower(name) = lower($2) OR lower(icon) = lower($2)
+			ON CONFLICT DO NOTHING`,
+			salonID, amenity.Name,
+		)
+		if err != nil {
+			return fmt.Errorf("amenity %q: %w", amenity.Name, err)
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			var exists bool
+			if err := tx.GetContext(ctx, &exists,
+				`SELECT EXISTS (SELECT 1 FROM amenities WHERE lower(name) = lower($1) OR lower(icon) = lower($1))`,
+				amenity.Name); err != nil {
+				return err
+			}
+			if !exists {
+				return fmt.Errorf("unknown amenity %q", amenity.Name)
+			}
+		}
+	}
+	return nil
+}
+
+func replaceHours(ctx context.Context, tx *sqlx.Tx, salonID int64, hours []domain.OperatingHours) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM operating_hours WHERE salon_id = $1`, salonID); err != nil {
+		return err
+	}
+	for _, h := range hours {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO operating_hours (salon_id, day_of_week, open_time, close_time, is_closed)
+			VALUES ($1, $2, NULLIF($3, '')::time, NULLIF($4, '')::time, $5)`,
+			salonID, h.DayOfWeek, h.OpenTime, h.CloseTime, h.IsClosed,
+		); err != nil {
+			return fmt.Errorf("hours of day %d: %w", h.DayOfWeek, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"beauty-salons/internal/domain"
+	"beauty-salons/internal/places"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// ===========================================
+// PLACES
+// ===========================================
+
+// placeRow is the database representation of a place
+type placeRow struct {
+	ID        int64   `db:"id"`
+	Name      string  `db:"name"`
+	Kind      string  `db:"kind"`
+	City      string  `db:"city"`
+	State     string  `db:"state"`
+	Country   string  `db:"country"`
+	Latitude  float64 `db:"latitude"`
+	Longitude float64 `db:"longitude"`
+	RadiusKm  float64 `db:"radius_km"`
+}
+
+// FindPlace resolves a near= value to the best matching place, or returns
+// nil when none matches. Exact names beat trigram matches; among equally
+// good matches cities come first, then neighborhoods, then landmarks.
+func (r *PostgresRepository) FindPlace(ctx context.Context, near string) (*domain.Place, error) {
+	q := places.ParseQuery(near)
+	if q.Name == "" {
+		return nil, nil
+	}
+
+	query := `
+		SELECT id, name, kind, city, state, country, latitude, longitude, radius_km
+		FROM places
+		WHERE (search_name = $1 OR search_name % $1) AND search_area @> $2::text[]
+		ORDER BY
+			search_name = $1 DESC,
+			similarity(search_name, $1) DESC,
+			CASE kind WHEN 'city' THEN 0 WHEN 'neighborhood' THEN 1 ELSE 2 END,
+			id
+		LIMIT 1
+	`
+
+	var row placeRow
+	found := true
+	err := r.run(ctx, readQuery, "find_place", query, func(ctx context.Context, db *sqlx.DB) error {
+		err := db.GetContext(ctx, &row, query, q.Name, pq.Array(q.Qualifiers))
+		if errors.Is(err, sql.ErrNoRows) {
+			found = false
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find place: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+
+	return &domain.Place{
+		ID:       row.ID,
+		Name:     row.Name,
+		Kind:     domain.PlaceKind(row.Kind),
+		City:     row.City,
+		State:    row.State,
+		Country:  row.Country,
+		Centroid: domain.GeoPoint{Latitude: row.Latitude, Longitude: row.Longitude},
+		RadiusKm: row.RadiusKm,
+	}, nil
+}
+
+// ImportPlaces upserts places by kind, name and area in one transaction.
+// With replace, places missing from the import are deleted.
+func (r *PostgresRepository) ImportPlaces(ctx context.Context, imported []domain.Place, replace bool) (int, error) {
+	query := `
+		INSERT INTO places (name, kind, city, state, country, latitude, longitude, radius_km, search_name, search_area)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (kind, search_name, search_area) DO UPDATE SET
+			name = EXCLUDED.name,
+			city = EXCLUDED.city,
+			state = EXCLUDED.state,
+			country = EXCLUDED.country,
+			latitude = EXCLUDED.latitude,
+			longitude = EXCLUDED.longitude,
+			radius_km = EXCLUDED.radius_km,
+			updated_at = CURRENT_TIMESTAMP
+	`
+
+	qctx, done := startQuery(ctx, "import_places", query)
+	err := r.importPlaces(qctx, query, imported, replace)
+	done(err)
+	if err != nil {
+		return 0, fmt.Errorf("failed to import places: %w", err)
+	}
+	return len(imported), nil
+}
+
+func (r *PostgresRepository) importPlaces(ctx context.Context, query string, imported []domain.Place, replace bool) error {
+	tx, err := r.beginTx(ctx, writeQuery, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if replace {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM places`); err != nil {
+			return err
+		}
+	}
+
+	stmt, err := tx.PreparexContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, p := range imported {
+		area := []string{}
+		for _, part := range []string{p.City, p.State, p.Country} {
+			if n := places.Normalize(part); n != "" {
+				area = append(area, n)
+			}
+		}
+		if _, err := stmt.ExecContext(ctx,
+			p.Name, string(p.Kind), p.City, p.State, p.Country,
+			p.Centroid.Latitude, p.Centroid.Longitude, p.RadiusKm,
+			places.Normalize(p.Name), pq.Array(area),
+		); err != nil {
+			return fmt.Errorf("place %q: %w", p.Name, err)
+		}
+	}
+	return tx.Commit()
+}
@@ -2,42 +2,82 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	"beauty-salons/internal/domain"
+	"beauty-salons/internal/logging"
+	"beauty-salons/internal/metrics"
+	"beauty-salons/internal/synonyms"
+	"beauty-salons/internal/tracing"
 
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // salonRow represents a salon as stored in the database (flat structure)
 type salonRow struct {
-	ID          int64    `db:"id"`
-	Name        string   `db:"name"`
-	Slug        string   `db:"slug"`
-	Description *string  `db:"description"`
-	Address     *string  `db:"address"`
-	City        *string  `db:"city"`
-	State       *string  `db:"state"`
-	PostalCode  *string  `db:"postal_code"`
-	Country     *string  `db:"country"`
-	Latitude    *float64 `db:"latitude"`
-	Longitude   *float64 `db:"longitude"`
-	Phone       *string  `db:"phone"`
-	Email       *string  `db:"email"`
-	Website     *string  `db:"website"`
-	CategoryID  *int64   `db:"category_id"`
-	PriceRange  *int     `db:"price_range"`
-	Rating      *float64 `db:"rating"`
-	ReviewCount *int     `db:"review_count"`
-	IsActive    bool     `db:"is_active"`
-	IsVerified  bool     `db:"is_verified"`
-	CreatedAt   string   `db:"created_at"`
-	UpdatedAt   string   `db:"updated_at"`
+	ID            int64      `db:"id"`
+	Name          string     `db:"name"`
+	Slug          string     `db:"slug"`
+	Description   *string    `db:"description"`
+	Address       *string    `db:"address"`
+	City          *string    `db:"city"`
+	State         *string    `db:"state"`
+	PostalCode    *string    `db:"postal_code"`
+	Country       *string    `db:"country"`
+	Latitude      *float64   `db:"latitude"`
+	Longitude     *float64   `db:"longitude"`
+	GeocodeStatus *string    `db:"geocode_status"`
+	Phone         *string    `db:"phone"`
+	Email         *string    `db:"email"`
+	Website       *string    `db:"website"`
+	CategoryID    *int64     `db:"category_id"`
+	PriceRange    *int       `db:"price_range"`
+	Rating        *float64   `db:"rating"`
+	ReviewCount   *int       `db:"review_count"`
+	IsActive      bool       `db:"is_active"`
+	IsVerified    bool       `db:"is_verified"`
+	CreatedAt     *time.Time `db:"created_at"`
+	UpdatedAt     *time.Time `db:"updated_at"`
 
 	// Joined fields
-	CategoryName *string `db:"category_name"`
-	TotalCount   int     `db:"total_count"`
+	CategoryName      *string    `db:"category_name"`
+	CategorySlug      *string    `db:"category_slug"`
+	CategoryCreatedAt *time.Time `db:"category_created_at"`
+	TotalCount        int        `db:"total_count"`
+}
+
+// searchRow is a salonRow with the relevance and highlights of a search
+type searchRow struct {
+	salonRow
+	Score               float64  `db:"score"`
+	Distance            *float64 `db:"distance_km"`
+	HeadlineName        *string  `db:"headline_name"`
+	HeadlineDescription *string  `db:"headline_description"`
+}
+
+// toResult converts a search row to a SalonSearchResult. Highlights are only
+// kept for fields where a term matched, as Elasticsearch does.
+func (r *searchRow) toResult() domain.SalonSearchResult {
+	result := domain.SalonSearchResult{Salon: r.toDomain(), Score: r.Score, Distance: r.Distance}
+
+	highlights := map[string]string{}
+	if r.HeadlineName != nil && strings.Contains(*r.HeadlineName, "<em>") {
+		highlights["name"] = *r.HeadlineName
+	}
+	if r.HeadlineDescription != nil && strings.Contains(*r.HeadlineDescription, "<em>") {
+		highlights["description"] = *r.HeadlineDescription
+	}
+	if len(highlights) > 0 {
+		result.Highlights = highlights
+	}
+	return result
 }
 
 // toDomain converts a database row to a domain Salon
@@ -74,6 +114,9 @@ func (r *salonRow) toDomain() domain.Salon {
 			Longitude: *r.Longitude,
 		}
 	}
+	if r.GeocodeStatus != nil {
+		salon.Location.GeocodeStatus = domain.GeocodeStatus(*r.GeocodeStatus)
+	}
 
 	// Map Contact
 	if r.Phone != nil {
@@ -95,71 +138,178 @@ func (r *salonRow) toDomain() domain.Salon {
 		salon.ReviewCount = *r.ReviewCount
 	}
 
+	// Map timestamps
+	if r.CreatedAt != nil {
+		salon.CreatedAt = *r.CreatedAt
+	}
+	if r.UpdatedAt != nil {
+		salon.UpdatedAt = *r.UpdatedAt
+	}
+
 	// Map category if joined
 	if r.CategoryName != nil {
 		salon.Category = &domain.Category{
 			ID:   *r.CategoryID,
 			Name: *r.CategoryName,
 		}
+		if r.CategorySlug != nil {
+			salon.Category.Slug = *r.CategorySlug
+		}
+		if r.CategoryCreatedAt != nil {
+			salon.Category.CreatedAt = *r.CategoryCreatedAt
+		}
 	}
 
 	return salon
 }
 
+// Config holds connection pool and ranking settings for the repository
+type Config struct {
+	// Pool sizing, applied to the primary and the replica (zero values
+	// keep database/sql defaults)
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	StatementTimeouts StatementTimeouts
+
+	// Optional read replica for searches and reads. It is checked every
+	// ReplicaCheckInterval; reads go to the primary while it is unreachable
+	// or more than MaxReplicaLag behind (zero ignores lag).
+	ReplicaURL           string
+	ReplicaCheckInterval time.Duration
+	MaxReplicaLag        time.Duration
+
+	Ranking  domain.RankingWeights
+	Synonyms *synonyms.Set // Query expansion for full-text search (nil disables)
+}
+
+// TextSearchConfig is the PostgreSQL text search configuration used for
+// both the GIN index and queries (Spanish stemming with accents removed)
+const TextSearchConfig = "salons"
+
+// Text search ranking
+const (
+	// textRelevanceWeight scales ts_rank_cd and trigram similarity (both
+	// 0-1) to the range of Elasticsearch text scores, so a strong match
+	// outweighs popularity boosts on both backends
+	textRelevanceWeight = 20.0
+
+	// headlineOptions mark matches with the same tags as Elasticsearch highlights
+	headlineOptions = "StartSel=<em>, StopSel=</em>"
+)
+
 // PostgresRepository handles all database operations.
 type PostgresRepository struct {
-	db *sqlx.DB
+	db       *sqlx.DB
+	timeouts StatementTimeouts
+	ranking  domain.RankingWeights
+	synonyms *synonyms.Set
+
+	// Read replica (nil without one) and whether reads are routed to it
+	replica       *sqlx.DB
+	replicaUp     atomic.Bool
+	maxReplicaLag time.Duration
+	stopWatch     chan struct{}
 }
 
-// NewPostgresRepository creates a new PostgreSQL connection.
-func NewPostgresRepository(connectionString string) (*PostgresRepository, error) {
+// NewPostgresRepository creates a new PostgreSQL connection. The replica,
+// if any, need not be up: reads use the primary until it is.
+func NewPostgresRepository(connectionString string, cfg Config) (*PostgresRepository, error) {
 	db, err := sqlx.Connect("postgres", connectionString)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to postgres: %w", err)
 	}
+	configurePool(db, cfg)
+
+	if cfg.Ranking == (domain.RankingWeights{}) {
+		cfg.Ranking = domain.DefaultRankingWeights()
+	}
+
+	r := &PostgresRepository{
+		db:            db,
+		timeouts:      cfg.StatementTimeouts,
+		ranking:       cfg.Ranking,
+		synonyms:      cfg.Synonyms,
+		maxReplicaLag: cfg.MaxReplicaLag,
+	}
+
+	if cfg.ReplicaURL != "" {
+		// Open doesn't connect, so a replica that is down isn't fatal
+		replica, err := sqlx.Open("postgres", cfg.ReplicaURL)
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("invalid replica connection string: %w", err)
+		}
+		configurePool(replica, cfg)
+		r.replica = replica
+
+		err = r.checkReplica()
+		r.setReplicaUp(err == nil, err)
 
-	// Test the connection
-	if err := db.Ping(); err != nil {
-		return nil, fmt.Errorf("failed to ping postgres: %w", err)
+		interval := cfg.ReplicaCheckInterval
+		if interval <= 0 {
+			interval = DefaultReplicaCheckInterval
+		}
+		r.stopWatch = make(chan struct{})
+		go r.watchReplica(interval, r.stopWatch)
 	}
 
-	return &PostgresRepository{db: db}, nil
+	return r, nil
 }
 
-// Close closes the database connection
-func (r *PostgresRepository) Close() error {
-	return r.db.Close()
+// configurePool applies the pool sizing (zero values keep database/sql defaults)
+func configurePool(db *sqlx.DB, cfg Config) {
+	if cfg.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(cfg.MaxOpenConns)
+	}
+	if cfg.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	if cfg.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	}
+	if cfg.ConnMaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	}
 }
 
-// GetAllSalons retrieves all salons (used for initial sync to Elasticsearch)
-func (r *PostgresRepository) GetAllSalons(ctx context.Context) ([]domain.Salon, error) {
-	query := `
-		SELECT
-			s.id, s.name, s.slug, s.description,
-			s.address, s.city, s.state, s.postal_code, s.country,
-			s.latitude, s.longitude,
-			s.phone, s.email, s.website,
-			s.category_id, s.price_range, s.rating, s.review_count,
-			s.is_active, s.is_verified, s.created_at, s.updated_at,
-			c.name as category_name,
-			0 as total_count
-		FROM salons s
-		LEFT JOIN categories c ON s.category_id = c.id
-		WHERE s.is_active = true
-		ORDER BY s.id
-	`
+// Ping checks that the database is reachable
+func (r *PostgresRepository) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
 
-	var rows []salonRow
-	if err := r.db.SelectContext(ctx, &rows, query); err != nil {
-		return nil, fmt.Errorf("failed to get salons: %w", err)
+// startQuery opens a span for a query. The returned function records the
+// outcome in metrics, logs and the span, and ends it.
+func startQuery(ctx context.Context, name, statement string) (context.Context, func(error)) {
+	start := time.Now()
+	ctx, span := tracing.Start(ctx, "postgres "+name, trace.SpanKindClient,
+		semconv.DBSystemPostgreSQL,
+		semconv.DBOperation(name),
+		semconv.DBStatement(statement),
+	)
+
+	return ctx, func(err error) {
+		metrics.ObservePostgres(name, start, err)
+		logging.FromContext(ctx).Debug("postgres query",
+			"query", name, "latency_ms", time.Since(start).Milliseconds(), "error", err)
+		tracing.End(span, err)
 	}
+}
 
-	salons := make([]domain.Salon, len(rows))
-	for i, row := range rows {
-		salons[i] = row.toDomain()
-	}
+// DB returns the underlying connection pool (for pool statistics)
+func (r *PostgresRepository) DB() *sql.DB {
+	return r.db.DB
+}
 
-	return salons, nil
+// Close closes the database connections
+func (r *PostgresRepository) Close() error {
+	if r.replica != nil {
+		close(r.stopWatch)
+		r.replica.Close()
+	}
+	return r.db.Close()
 }
 
 // GetSalonByID retrieves a single salon by ID
@@ -168,11 +318,11 @@ func (r *PostgresRepository) GetSalonByID(ctx context.Context, id int64) (*domai
 		SELECT
 			s.id, s.name, s.slug, s.description,
 			s.address, s.city, s.state, s.postal_code, s.country,
-			s.latitude, s.longitude,
+			s.latitude, s.longitude, s.geocode_status,
 			s.phone, s.email, s.website,
 			s.category_id, s.price_range, s.rating, s.review_count,
 			s.is_active, s.is_verified, s.created_at, s.updated_at,
-			c.name as category_name,
+			c.name as category_name, c.slug as category_slug, c.created_at as category_created_at,
 			0 as total_count
 		FROM salons s
 		LEFT JOIN categories c ON s.category_id = c.id
@@ -180,7 +330,10 @@ func (r *PostgresRepository) GetSalonByID(ctx context.Context, id int64) (*domai
 	`
 
 	var row salonRow
-	if err := r.db.GetContext(ctx, &row, query, id); err != nil {
+	err := r.run(ctx, readQuery, "get_salon", query, func(ctx context.Context, db *sqlx.DB) error {
+		return db.GetContext(ctx, &row, query, id)
+	})
+	if err != nil {
 		return nil, fmt.Errorf("failed to get salon: %w", err)
 	}
 
@@ -188,7 +341,11 @@ func (r *PostgresRepository) GetSalonByID(ctx context.Context, id int64) (*domai
 
 	// Get services for this salon
 	servicesQuery := `SELECT id, salon_id, name, description, price_min, price_max, duration_minutes, created_at FROM services WHERE salon_id = $1`
-	if err := r.db.SelectContext(ctx, &salon.Services, servicesQuery, id); err != nil {
+	err = r.run(ctx, readQuery, "get_salon_services", servicesQuery, func(ctx context.Context, db *sqlx.DB) error {
+		salon.Services = salon.Services[:0]
+		return db.SelectContext(ctx, &salon.Services, servicesQuery, id)
+	})
+	if err != nil {
 		return nil, fmt.Errorf("failed to get services: %w", err)
 	}
 
@@ -199,128 +356,176 @@ func (r *PostgresRepository) GetSalonByID(ctx context.Context, id int64) (*domai
 		JOIN salon_amenities sa ON a.id = sa.amenity_id
 		WHERE sa.salon_id = $1
 	`
-	if err := r.db.SelectContext(ctx, &salon.Amenities, amenitiesQuery, id); err != nil {
+	err = r.run(ctx, readQuery, "get_salon_amenities", amenitiesQuery, func(ctx context.Context, db *sqlx.DB) error {
+		salon.Amenities = salon.Amenities[:0]
+		return db.SelectContext(ctx, &salon.Amenities, amenitiesQuery, id)
+	})
+	if err != nil {
 		return nil, fmt.Errorf("failed to get amenities: %w", err)
 	}
 
-	// Get operating hours for this salon
+	// Get operating hours for this salon; closed days have no times
 	hoursQuery := `
-		SELECT id, salon_id, day_of_week, open_time, close_time, is_closed
+		SELECT id, salon_id, day_of_week,
+			COALESCE(open_time::text, '') AS open_time,
+			COALESCE(close_time::text, '') AS close_time,
+			is_closed
 		FROM operating_hours
 		WHERE salon_id = $1
 		ORDER BY day_of_week
 	`
-	if err := r.db.SelectContext(ctx, &salon.OperatingHours, hoursQuery, id); err != nil {
+	err = r.run(ctx, readQuery, "get_salon_hours", hoursQuery, func(ctx context.Context, db *sqlx.DB) error {
+		salon.OperatingHours = salon.OperatingHours[:0]
+		return db.SelectContext(ctx, &salon.OperatingHours, hoursQuery, id)
+	})
+	if err != nil {
 		return nil, fmt.Errorf("failed to get operating hours: %w", err)
 	}
 
 	return &salon, nil
 }
 
-// SearchSalons performs a search using PostgreSQL's full-text search.
-func (r *PostgresRepository) SearchSalons(ctx context.Context, params domain.SalonSearchParams) ([]domain.Salon, int, error) {
-	// Base query with full-text search
-	query := `
-		SELECT
-			s.id, s.name, s.slug, s.description,
-			s.address, s.city, s.state, s.postal_code, s.country,
-			s.latitude, s.longitude,
-			s.phone, s.email, s.website,
-			s.category_id, s.price_range, s.rating, s.review_count,
-			s.is_active, s.is_verified, s.created_at, s.updated_at,
-			c.name as category_name,
-			COUNT(*) OVER() as total_count
-		FROM salons s
-		LEFT JOIN categories c ON s.category_id = c.id
-		WHERE s.is_active = true
-	`
+// salonFilter holds the FROM and WHERE clauses shared by salon searches.
+// Placeholders are numbered in the order arguments are added.
+type salonFilter struct {
+	args     []interface{}
+	from     string
+	where    []string
+	queryArg string // Placeholder of the text query, if any
+	origin   string // Geography of the search location, if any
+}
 
-	args := []interface{}{}
-	argNum := 1
+// arg adds a query argument and returns its placeholder
+func (f *salonFilter) arg(v interface{}) string {
+	f.args = append(f.args, v)
+	return fmt.Sprintf("$%d", len(f.args))
+}
+
+// clause returns the FROM ... WHERE ... part of the query
+func (f *salonFilter) clause() string {
+	return "FROM " + f.from + "\n\t\tWHERE " + strings.Join(f.where, "\n\t\t  AND ")
+}
+
+// newSalonFilter translates search params into SQL conditions.
+// Text queries match the weighted search_vector (name, services,
+// description) or, for typos, the salon name by trigram similarity.
+func (r *PostgresRepository) newSalonFilter(params domain.SalonSearchParams) *salonFilter {
+	f := &salonFilter{
+		from:  `salons s LEFT JOIN categories c ON s.category_id = c.id`,
+		where: []string{`s.is_active = true`},
+	}
 
-	// Full-text search using PostgreSQL's to_tsvector
+	// Full-text search; synonyms match the query or any of its expansions
 	if params.Query != "" {
-		query += fmt.Sprintf(` AND to_tsvector('spanish', coalesce(s.name, '') || ' ' || coalesce(s.description, '')) @@ plainto_tsquery('spanish', $%d)`, argNum)
-		args = append(args, params.Query)
-		argNum++
+		f.queryArg = f.arg(params.Query)
+		tsquery := fmt.Sprintf(`plainto_tsquery('%s', %s)`, TextSearchConfig, f.queryArg)
+		for _, alt := range r.synonyms.Expand(params.Query) {
+			tsquery += fmt.Sprintf(` || plainto_tsquery('%s', %s)`, TextSearchConfig, f.arg(alt))
+		}
+		f.from += fmt.Sprintf(` CROSS JOIN (SELECT %s AS query) tsq`, tsquery)
+		f.where = append(f.where, fmt.Sprintf(`(s.search_vector @@ tsq.query OR f_unaccent(%s) <%% f_unaccent(s.name))`, f.queryArg))
 	}
 
-	// City filter
 	if params.City != "" {
-		query += fmt.Sprintf(` AND LOWER(s.city) = LOWER($%d)`, argNum)
-		args = append(args, params.City)
-		argNum++
+		f.where = append(f.where, fmt.Sprintf(`LOWER(s.city) = LOWER(%s)`, f.arg(params.City)))
 	}
-
-	// Category filter
 	if params.CategoryID != nil {
-		query += fmt.Sprintf(` AND s.category_id = $%d`, argNum)
-		args = append(args, *params.CategoryID)
-		argNum++
+		f.where = append(f.where, fmt.Sprintf(`s.category_id = %s`, f.arg(*params.CategoryID)))
 	}
-
-	// Price range filter
 	if params.PriceRange != 0 {
-		query += fmt.Sprintf(` AND s.price_range = $%d`, argNum)
-		args = append(args, params.PriceRange)
-		argNum++
+		f.where = append(f.where, fmt.Sprintf(`s.price_range = %s`, f.arg(params.PriceRange)))
 	}
-
-	// Rating filter
 	if params.MinRating != nil {
-		query += fmt.Sprintf(` AND s.rating >= $%d`, argNum)
-		args = append(args, *params.MinRating)
-		argNum++
+		f.where = append(f.where, fmt.Sprintf(`s.rating >= %s`, f.arg(*params.MinRating)))
 	}
-
-	// Verified filter
 	if params.IsVerified != nil && *params.IsVerified {
-		query += ` AND s.is_verified = true`
+		f.where = append(f.where, `s.is_verified = true`)
+	}
+
+	// Geo search (within radius), served by the GiST index on s.location.
+	// Distances are measured on a sphere, like Elasticsearch's arc distance.
+	if params.Location != nil {
+		f.origin = fmt.Sprintf(`ST_SetSRID(ST_MakePoint(%s, %s), 4326)::geography`,
+			f.arg(params.Location.Longitude), f.arg(params.Location.Latitude))
+		if params.RadiusKm != nil {
+			f.where = append(f.where, fmt.Sprintf(`ST_DWithin(s.location, %s, %s * 1000, false)`, f.origin, f.arg(*params.RadiusKm)))
+		}
+	}
+
+	// Map viewport and polygon, in planar lon/lat like Elasticsearch's
+	// geo_bounding_box and geo_shape (served by idx_salons_geometry)
+	if b := params.BoundingBox; b != nil {
+		f.where = append(f.where, fmt.Sprintf(`s.location::geometry && ST_MakeEnvelope(%s, %s, %s, %s, 4326)`,
+			f.arg(b.MinLon), f.arg(b.MinLat), f.arg(b.MaxLon), f.arg(b.MaxLat)))
+	}
+	if params.Polygon != nil {
+		f.where = append(f.where, fmt.Sprintf(`ST_Intersects(s.location::geometry, ST_SetSRID(ST_GeomFromGeoJSON(%s), 4326))`,
+			f.arg(params.Polygon.GeoJSON())))
 	}
 
-	// Geo search (within radius)
-	if params.Location != nil && params.RadiusKm != nil {
-		// Using Haversine formula approximation
-		query += fmt.Sprintf(` AND (
-			6371 * acos(
-				cos(radians($%d)) * cos(radians(s.latitude)) *
-				cos(radians(s.longitude) - radians($%d)) +
-				sin(radians($%d)) * sin(radians(s.latitude))
-			)
-		) <= $%d`, argNum, argNum+1, argNum+2, argNum+3)
-		args = append(args, params.Location.Latitude, params.Location.Longitude, params.Location.Latitude, *params.RadiusKm)
-		argNum += 4
+	return f
+}
+
+// score returns the ranking expression: text relevance plus the popularity
+// and distance boosts of the Elasticsearch function_score
+func (r *PostgresRepository) score(f *salonFilter) string {
+	// rating*w1 + log(1+reviews)*w2 + verified bonus
+	score := fmt.Sprintf(`COALESCE(s.rating, 0) * %s
+			+ LN(1 + COALESCE(s.review_count, 0)) * %s
+			+ CASE WHEN s.is_verified THEN %s ELSE 0.0 END`,
+		f.arg(r.ranking.Rating), f.arg(r.ranking.Reviews), f.arg(r.ranking.Verified))
+
+	if f.queryArg != "" {
+		score = fmt.Sprintf(`(ts_rank_cd(s.search_vector, tsq.query, 32) + word_similarity(f_unaccent(%s), f_unaccent(s.name))) * %g
+			+ %s`, f.queryArg, textRelevanceWeight, score)
 	}
 
-	// Order by
-	switch params.SortBy {
+	// Gaussian distance decay, as the Elasticsearch gauss function:
+	// 1 at the origin, 0.5 at DistanceScaleKm, 1 when the salon has no location
+	if f.origin != "" {
+		score += fmt.Sprintf(`
+			+ COALESCE(power(0.5, power(ST_Distance(s.location, %s, false) / 1000 / %s, 2)), 1) * %s`,
+			f.origin, f.arg(r.ranking.DistanceScaleKm), f.arg(r.ranking.Distance))
+	}
+	return score
+}
+
+// orderBy returns the ORDER BY clause for a sort option. score must be
+// selected as an output column.
+func orderBy(sortBy domain.SortOption, f *salonFilter) string {
+	switch sortBy {
 	case domain.SortByRating:
-		query += ` ORDER BY s.rating DESC NULLS LAST, s.review_count DESC`
+		return `ORDER BY s.rating DESC NULLS LAST, s.review_count DESC, s.id`
 	case domain.SortByReviews:
-		query += ` ORDER BY s.review_count DESC, s.rating DESC NULLS LAST`
+		return `ORDER BY s.review_count DESC, s.rating DESC NULLS LAST, s.id`
 	case domain.SortByNewest:
-		query += ` ORDER BY s.created_at DESC`
+		return `ORDER BY s.created_at DESC, s.id`
 	case domain.SortByDistance:
-		if params.Location != nil {
-			query += fmt.Sprintf(` ORDER BY (
-				6371 * acos(
-					cos(radians($%d)) * cos(radians(s.latitude)) *
-					cos(radians(s.longitude) - radians($%d)) +
-					sin(radians($%d)) * sin(radians(s.latitude))
-				)
-			) ASC`, argNum, argNum+1, argNum+2)
-			args = append(args, params.Location.Latitude, params.Location.Longitude, params.Location.Latitude)
-			argNum += 3
-		} else {
-			query += ` ORDER BY s.rating DESC NULLS LAST`
+		if f.origin != "" {
+			// KNN ordering, also index assisted
+			return fmt.Sprintf(`ORDER BY s.location <-> %s, s.id`, f.origin)
 		}
+		return `ORDER BY s.rating DESC NULLS LAST, s.id`
 	default:
-		// Weighted ranking: rating*2 + log(1+reviews)*1.5 + verified bonus
-		query += ` ORDER BY (
-			COALESCE(s.rating, 0) * 2.0
-			+ LN(1 + COALESCE(s.review_count, 0)) * 1.5
-			+ CASE WHEN s.is_verified THEN 5.0 ELSE 0.0 END
-		) DESC`
+		// Text relevance plus popularity boosts
+		return `ORDER BY score DESC, s.id`
+	}
+}
+
+// SearchSalons performs a search using PostgreSQL's full-text search.
+func (r *PostgresRepository) SearchSalons(ctx context.Context, params domain.SalonSearchParams) ([]domain.SalonSearchResult, int, error) {
+	f := r.newSalonFilter(params)
+
+	distance := `NULL::float8`
+	if f.origin != "" {
+		distance = fmt.Sprintf(`ST_Distance(s.location, %s, false) / 1000`, f.origin)
+	}
+
+	headlines := `NULL::text AS headline_name, NULL::text AS headline_description`
+	if f.queryArg != "" {
+		headlines = fmt.Sprintf(`ts_headline('%[1]s', s.name, tsq.query, '%[2]s') AS headline_name,
+			ts_headline('%[1]s', coalesce(s.description, ''), tsq.query, '%[2]s, MaxFragments=1, MaxWords=30, MinWords=10') AS headline_description`,
+			TextSearchConfig, headlineOptions)
 	}
 
 	// Pagination
@@ -332,28 +537,199 @@ func (r *PostgresRepository) SearchSalons(ctx context.Context, params domain.Sal
 	}
 	offset := (params.Page - 1) * params.PageSize
 
-	query += fmt.Sprintf(` LIMIT $%d OFFSET $%d`, argNum, argNum+1)
-	args = append(args, params.PageSize, offset)
-
-	var rows []salonRow
-	if err := r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+	// Headlines are only computed for the rows of the requested page, as
+	// PostgreSQL evaluates non-sort output columns after LIMIT
+	query := fmt.Sprintf(`
+		SELECT
+			s.id, s.name, s.slug, s.description,
+			s.address, s.city, s.state, s.postal_code, s.country,
+			s.latitude, s.longitude,
+			s.phone, s.email, s.website,
+			s.category_id, s.price_range, s.rating, s.review_count,
+			s.is_active, s.is_verified, s.created_at, s.updated_at,
+			c.name as category_name,
+			COUNT(*) OVER() as total_count,
+			%s AS score,
+			%s AS distance_km,
+			%s
+		%s
+		%s
+		LIMIT %s OFFSET %s
+	`, r.score(f), distance, headlines, f.clause(), orderBy(params.SortBy, f), f.arg(params.PageSize), f.arg(offset))
+
+	var rows []searchRow
+	err := r.run(ctx, searchQuery, "search_salons", query, func(ctx context.Context, db *sqlx.DB) error {
+		rows = rows[:0]
+		return db.SelectContext(ctx, &rows, query, f.args...)
+	})
+	if err != nil {
 		return nil, 0, fmt.Errorf("failed to search salons: %w", err)
 	}
 
-	salons := make([]domain.Salon, len(rows))
+	results := make([]domain.SalonSearchResult, len(rows))
 	totalCount := 0
 	for i, row := range rows {
-		salons[i] = row.toDomain()
+		results[i] = row.toResult()
 		totalCount = row.TotalCount
 	}
 
-	return salons, totalCount, nil
+	return results, totalCount, nil
+}
+
+// SearchPins returns up to limit map markers for a search, best ranked first
+func (r *PostgresRepository) SearchPins(ctx context.Context, params domain.SalonSearchParams, limit int) ([]domain.SalonPin, int, error) {
+	f := r.newSalonFilter(params)
+	f.where = append(f.where, `s.location IS NOT NULL`)
+
+	query := fmt.Sprintf(`
+		SELECT
+			s.id, s.name, s.latitude, s.longitude, s.rating,
+			COUNT(*) OVER() as total_count,
+			%s AS score
+		%s
+		%s
+		LIMIT %s
+	`, r.score(f), f.clause(), orderBy(params.SortBy, f), f.arg(limit))
+
+	var rows []struct {
+		ID         int64    `db:"id"`
+		Name       string   `db:"name"`
+		Latitude   float64  `db:"latitude"`
+		Longitude  float64  `db:"longitude"`
+		Rating     *float64 `db:"rating"`
+		TotalCount int      `db:"total_count"`
+		Score      float64  `db:"score"`
+	}
+	err := r.run(ctx, searchQuery, "search_pins", query, func(ctx context.Context, db *sqlx.DB) error {
+		rows = rows[:0]
+		return db.SelectContext(ctx, &rows, query, f.args...)
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search pins: %w", err)
+	}
+
+	pins := make([]domain.SalonPin, len(rows))
+	totalCount := 0
+	for i, row := range rows {
+		pins[i] = domain.SalonPin{
+			ID:       row.ID,
+			Name:     row.Name,
+			Location: domain.GeoPoint{Latitude: row.Latitude, Longitude: row.Longitude},
+			Rating:   row.Rating,
+		}
+		totalCount = row.TotalCount
+	}
+
+	return pins, totalCount, nil
+}
+
+// Web Mercator (EPSG:3857) bounds: half the width of the world in meters,
+// and the latitude where the projection is cut to make the world square
+const (
+	webMercatorExtent = 20037508.342789244
+	webMercatorMaxLat = 85.0511287798066
+)
+
+// SearchClusters groups the salons matching a search into the Web Mercator
+// tiles of the given zoom, like Elasticsearch's geotile_grid: locations are
+// snapped to the tile centers with ST_SnapToGrid. It returns up to limit
+// tiles with the most salons, and the number of matching salons.
+func (r *PostgresRepository) SearchClusters(ctx context.Context, params domain.SalonSearchParams, zoom, limit int) ([]domain.SalonCluster, int, error) {
+	f := r.newSalonFilter(params)
+	f.where = append(f.where, `s.location IS NOT NULL`)
+
+	tiles := int(1) << zoom
+	tileSize := 2 * webMercatorExtent / float64(tiles)
+	size := f.arg(tileSize)
+	// Grid origin at the center of the bottom left tile
+	origin := f.arg(-webMercatorExtent + tileSize/2)
+	extent := f.arg(webMercatorExtent)
+	minLat, maxLat := f.arg(-webMercatorMaxLat), f.arg(webMercatorMaxLat)
+	lastTile := f.arg(tiles - 1)
+
+	query := fmt.Sprintf(`
+		WITH located AS (
+			SELECT
+				s.id, s.name, s.latitude, s.longitude, s.rating, s.review_count,
+				ST_SnapToGrid(ST_Transform(ST_SetSRID(ST_MakePoint(
+					s.longitude::float8, LEAST(GREATEST(s.latitude::float8, %[6]s), %[7]s)
+				), 4326), 3857), %[1]s, %[1]s, %[2]s, %[2]s) AS tile
+			%[3]s
+		), ranked AS (
+			SELECT *, ROW_NUMBER() OVER (
+				PARTITION BY tile ORDER BY rating DESC NULLS LAST, review_count DESC, id
+			) AS tile_rank
+			FROM located
+		)
+		SELECT
+			LEAST(floor((ST_X(tile) + %[4]s) / %[2]s)::int, %[8]s) AS x,
+			LEAST(floor((%[4]s - ST_Y(tile)) / %[2]s)::int, %[8]s) AS y,
+			COUNT(*) AS count,
+			AVG(latitude) AS centroid_lat,
+			AVG(longitude) AS centroid_lon,
+			MAX(id) FILTER (WHERE tile_rank = 1) AS top_id,
+			MAX(name) FILTER (WHERE tile_rank = 1) AS top_name,
+			MAX(latitude) FILTER (WHERE tile_rank = 1) AS top_lat,
+			MAX(longitude) FILTER (WHERE tile_rank = 1) AS top_lon,
+			MAX(rating) FILTER (WHERE tile_rank = 1) AS top_rating,
+			(SUM(COUNT(*)) OVER ())::bigint AS total_count
+		FROM ranked
+		GROUP BY tile
+		ORDER BY count DESC, x, y
+		LIMIT %[5]s
+	`, origin, size, f.clause(), extent, f.arg(limit), minLat, maxLat, lastTile)
+
+	var rows []struct {
+		X           int      `db:"x"`
+		Y           int      `db:"y"`
+		Count       int64    `db:"count"`
+		CentroidLat float64  `db:"centroid_lat"`
+		CentroidLon float64  `db:"centroid_lon"`
+		TopID       int64    `db:"top_id"`
+		TopName     string   `db:"top_name"`
+		TopLat      float64  `db:"top_lat"`
+		TopLon      float64  `db:"top_lon"`
+		TopRating   *float64 `db:"top_rating"`
+		TotalCount  int      `db:"total_count"`
+	}
+	err := r.run(ctx, searchQuery, "search_clusters", query, func(ctx context.Context, db *sqlx.DB) error {
+		rows = rows[:0]
+		return db.SelectContext(ctx, &rows, query, f.args...)
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search clusters: %w", err)
+	}
+
+	clusters := make([]domain.SalonCluster, len(rows))
+	totalCount := 0
+	for i, row := range rows {
+		clusters[i] = domain.SalonCluster{
+			Key:      domain.TileKey(zoom, row.X, row.Y),
+			Count:    row.Count,
+			Centroid: domain.GeoPoint{Latitude: row.CentroidLat, Longitude: row.CentroidLon},
+			Bounds:   domain.TileBounds(zoom, row.X, row.Y),
+			TopSalon: &domain.SalonPin{
+				ID:       row.TopID,
+				Name:     row.TopName,
+				Location: domain.GeoPoint{Latitude: row.TopLat, Longitude: row.TopLon},
+				Rating:   row.TopRating,
+			},
+		}
+		totalCount = row.TotalCount
+	}
+
+	return clusters, totalCount, nil
 }
 
 // GetCategories retrieves all categories
 func (r *PostgresRepository) GetCategories(ctx context.Context) ([]domain.Category, error) {
 	var categories []domain.Category
-	if err := r.db.SelectContext(ctx, &categories, "SELECT * FROM categories ORDER BY name"); err != nil {
+	query := "SELECT * FROM categories ORDER BY name"
+	err := r.run(ctx, readQuery, "get_categories", query, func(ctx context.Context, db *sqlx.DB) error {
+		categories = categories[:0]
+		return db.SelectContext(ctx, &categories, query)
+	})
+	if err != nil {
 		return nil, fmt.Errorf("failed to get categories: %w", err)
 	}
 	return categories, nil
@@ -0,0 +1,220 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"time"
+
+	"beauty-salons/internal/metrics"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ===========================================
+// QUERY ROUTING AND TIMEOUTS
+// ===========================================
+// Every query has a kind, which picks its connection pool and its
+// statement timeout. Reads go to the read replica when one is configured
+// and healthy; writes, and reads that must see them, go to the primary.
+// A read that cannot reach the replica is retried once on the primary.
+//
+// Single statements are bounded through their context (lib/pq cancels the
+// statement on the server when it expires); statements in a transaction
+// each get the timeout with SET LOCAL statement_timeout.
+
+// queryKind classifies a query for routing and statement timeouts
+type queryKind int
+
+const (
+	searchQuery queryKind = iota // Full-text and map searches (replica)
+	readQuery                    // Point reads, lists and reports (replica)
+	exportQuery                  // Whole-catalog reads for sync and export (replica)
+	writeQuery                   // Writes and the reads of write workflows (primary)
+)
+
+// String returns the kind's name
+func (k queryKind) String() string {
+	switch k {
+	case searchQuery:
+		return "search"
+	case readQuery:
+		return "read"
+	case exportQuery:
+		return "export"
+	default:
+		return "write"
+	}
+}
+
+// Replica health check defaults
+const (
+	DefaultReplicaCheckInterval = 5 * time.Second
+	replicaCheckTimeout         = 2 * time.Second
+)
+
+// StatementTimeouts bound queries by kind; zero leaves them unbounded
+type StatementTimeouts struct {
+	Search time.Duration
+	Read   time.Duration
+	Write  time.Duration
+	Export time.Duration
+}
+
+func (t StatementTimeouts) of(kind queryKind) time.Duration {
+	switch kind {
+	case searchQuery:
+		return t.Search
+	case readQuery:
+		return t.Read
+	case exportQuery:
+		return t.Export
+	default:
+		return t.Write
+	}
+}
+
+type primaryKey struct{}
+
+// WithPrimary routes the reads made with ctx to the primary, for callers
+// that must see their own writes (replicas may lag behind)
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// pool returns the connection pool for a query of the given kind
+func (r *PostgresRepository) pool(ctx context.Context, kind queryKind) *sqlx.DB {
+	if kind == writeQuery || r.replica == nil || !r.replicaUp.Load() {
+		return r.db
+	}
+	if primary, _ := ctx.Value(primaryKey{}).(bool); primary {
+		return r.db
+	}
+	return r.replica
+}
+
+// run runs a single-statement query with fn on the pool for its kind,
+// within the kind's statement timeout and a span. When the replica can't be
+// reached it is marked down and fn runs again on the primary, so fn must
+// drop whatever a failed attempt left in its destination.
+func (r *PostgresRepository) run(ctx context.Context, kind queryKind, name, statement string, fn func(context.Context, *sqlx.DB) error) error {
+	if timeout := r.timeouts.of(kind); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	qctx, done := startQuery(ctx, name, statement)
+
+	db := r.pool(ctx, kind)
+	err := fn(qctx, db)
+	if db == r.replica && isConnectionError(qctx, err) {
+		r.setReplicaUp(false, err)
+		err = fn(qctx, r.db)
+	}
+	done(err)
+	return err
+}
+
+// beginTx starts a transaction on the pool for kind, with the kind's
+// statement timeout applied to each of its statements
+func (r *PostgresRepository) beginTx(ctx context.Context, kind queryKind, opts *sql.TxOptions) (*sqlx.Tx, error) {
+	tx, err := r.pool(ctx, kind).BeginTxx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	if timeout := r.timeouts.of(kind); timeout > 0 {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(`SET LOCAL statement_timeout = %d`, timeout.Milliseconds())); err != nil {
+			_ = tx.Rollback()
+			return nil, fmt.Errorf("failed to set statement timeout: %w", err)
+		}
+	}
+	return tx, nil
+}
+
+// isConnectionError reports whether err means the server could not be
+// reached or dropped the connection, rather than the query failing
+func isConnectionError(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	var netErr net.Error
+	return errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) || errors.As(err, &netErr)
+}
+
+// ReplicaDB returns the read replica's connection pool, or nil without one
+func (r *PostgresRepository) ReplicaDB() *sql.DB {
+	if r.replica == nil {
+		return nil
+	}
+	return r.replica.DB
+}
+
+// ReplicaHealthy reports whether reads are going to the replica
+func (r *PostgresRepository) ReplicaHealthy() bool {
+	return r.replica != nil && r.replicaUp.Load()
+}
+
+// watchReplica checks the replica every interval until stop is closed
+func (r *PostgresRepository) watchReplica(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			err := r.checkReplica()
+			r.setReplicaUp(err == nil, err)
+		}
+	}
+}
+
+// checkReplica pings the replica and compares its replication lag with
+// the maximum
+func (r *PostgresRepository) checkReplica() error {
+	ctx, cancel := context.WithTimeout(context.Background(), replicaCheckTimeout)
+	defer cancel()
+
+	if err := r.replica.PingContext(ctx); err != nil {
+		return err
+	}
+	if r.maxReplicaLag <= 0 {
+		return nil
+	}
+
+	// Without new writes the last replayed transaction ages too, so a
+	// replica that has replayed everything it received has no lag
+	var lag float64
+	err := r.replica.GetContext(ctx, &lag, `
+		SELECT CASE
+			WHEN NOT pg_is_in_recovery() OR pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+			ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+		END`)
+	if err != nil {
+		return fmt.Errorf("failed to read replication lag: %w", err)
+	}
+	if lagDuration := time.Duration(lag * float64(time.Second)); lagDuration > r.maxReplicaLag {
+		return fmt.Errorf("replica is %s behind (max %s)", lagDuration.Round(time.Millisecond), r.maxReplicaLag)
+	}
+	return nil
+}
+
+// setReplicaUp records the replica's health, logging changes
+func (r *PostgresRepository) setReplicaUp(up bool, err error) {
+	if r.replicaUp.Swap(up) == up {
+		return
+	}
+	if up {
+		metrics.PGReplicaUp.Set(1)
+		slog.Info("read replica healthy, routing reads to it")
+	} else {
+		metrics.PGReplicaUp.Set(0)
+		slog.Warn("read replica unhealthy, routing reads to the primary", "error", err)
+	}
+}
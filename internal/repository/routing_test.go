@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"beauty-salons/internal/metrics"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// fakeDriver connects to named fake servers, failing to reach the ones
+// marked down; statements only count executions
+type fakeDriver struct{}
+
+var fakeServers sync.Map // name -> *fakeServer
+
+type fakeServer struct {
+	down  atomic.Bool
+	execs atomic.Int32
+}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	v, _ := fakeServers.Load(name)
+	srv := v.(*fakeServer)
+	if srv.down.Load() {
+		return nil, &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	}
+	return fakeConn{srv}, nil
+}
+
+type fakeConn struct{ srv *fakeServer }
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) { return fakeStmt(c), nil }
+func (fakeConn) Close() error                          { return nil }
+func (fakeConn) Begin() (driver.Tx, error)             { return nil, errors.New("not supported") }
+
+type fakeStmt struct{ srv *fakeServer }
+
+func (fakeStmt) Close() error  { return nil }
+func (fakeStmt) NumInput() int { return -1 }
+func (s fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	s.srv.execs.Add(1)
+	return driver.RowsAffected(1), nil
+}
+func (fakeStmt) Query([]driver.Value) (driver.Rows, error) { return nil, errors.New("not supported") }
+
+// fakeRepository has a primary and, with a replica, a healthy replica
+func fakeRepository(t *testing.T, withReplica bool) (*PostgresRepository, *fakeServer, *fakeServer) {
+	t.Helper()
+	open := func(name string) (*sqlx.DB, *fakeServer) {
+		srv := &fakeServer{}
+		fakeServers.Store(t.Name()+"/"+name, srv)
+		db := sqlx.NewDb(sql.OpenDB(fakeConnector{t.Name() + "/" + name}), "postgres")
+		t.Cleanup(func() { db.Close() })
+		return db, srv
+	}
+
+	r := &PostgresRepository{}
+	var primary, replica *fakeServer
+	r.db, primary = open("primary")
+	if withReplica {
+		r.replica, replica = open("replica")
+		r.replicaUp.Store(true)
+	}
+	return r, primary, replica
+}
+
+type fakeConnector struct{ name string }
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) {
+	return fakeDriver{}.Open(c.name)
+}
+func (fakeConnector) Driver() driver.Driver { return fakeDriver{} }
+
+func TestPool_RoutesByKind(t *testing.T) {
+	ctx := context.Background()
+
+	r, _, _ := fakeRepository(t, false)
+	for _, kind := range []queryKind{searchQuery, readQuery, exportQuery, writeQuery} {
+		if r.pool(ctx, kind) != r.db {
+			t.Errorf("without a replica, %s queries should use the primary", kind)
+		}
+	}
+
+	r, _, _ = fakeRepository(t, true)
+	for _, kind := range []queryKind{searchQuery, readQuery, exportQuery} {
+		if r.pool(ctx, kind) != r.replica {
+			t.Errorf("%s queries should use the healthy replica", kind)
+		}
+		if r.pool(WithPrimary(ctx), kind) != r.db {
+			t.Errorf("%s queries with WithPrimary should use the primary", kind)
+		}
+	}
+	if r.pool(ctx, writeQuery) != r.db {
+		t.Error("writes should use the primary")
+	}
+
+	r.replicaUp.Store(false)
+	if r.pool(ctx, readQuery) != r.db {
+		t.Error("reads should fall back to the primary while the replica is down")
+	}
+}
+
+func TestSetReplicaUp_Transitions(t *testing.T) {
+	r, _, _ := fakeRepository(t, true)
+
+	r.setReplicaUp(false, errors.New("replica is 1m0s behind"))
+	if r.ReplicaHealthy() || testutil.ToFloat64(metrics.PGReplicaUp) != 0 {
+		t.Errorf("after going down: healthy %v, gauge %v", r.ReplicaHealthy(), testutil.ToFloat64(metrics.PGReplicaUp))
+	}
+	r.setReplicaUp(false, errors.New("still behind"))
+	if r.ReplicaHealthy() {
+		t.Error("repeated failures should keep the replica down")
+	}
+
+	r.setReplicaUp(true, nil)
+	if !r.ReplicaHealthy() || testutil.ToFloat64(metrics.PGReplicaUp) != 1 {
+		t.Errorf("after recovering: healthy %v, gauge %v", r.ReplicaHealthy(), testutil.ToFloat64(metrics.PGReplicaUp))
+	}
+}
+
+func TestRun_FallsBackToPrimary(t *testing.T) {
+	r, primary, replica := fakeRepository(t, true)
+	exec := func(ctx context.Context, db *sqlx.DB) error {
+		_, err := db.ExecContext(ctx, "SELECT 1")
+		return err
+	}
+
+	if err := r.run(context.Background(), readQuery, "test_read", "SELECT 1", exec); err != nil {
+		t.Fatalf("run() on the replica error = %v", err)
+	}
+	if replica.execs.Load() != 1 || primary.execs.Load() != 0 {
+		t.Fatalf("replica ran %d, primary %d, want the read on the replica", replica.execs.Load(), primary.execs.Load())
+	}
+
+	// The replica becomes unreachable: the read is retried on the primary
+	// and later reads skip the replica until it is healthy again
+	replica.down.Store(true)
+	r.replica.SetMaxIdleConns(0)
+	if err := r.run(context.Background(), readQuery, "test_read", "SELECT 1", exec); err != nil {
+		t.Fatalf("run() with the replica down error = %v, want it retried on the primary", err)
+	}
+	if primary.execs.Load() != 1 {
+		t.Errorf("primary ran %d statements, want the failed read retried there", primary.execs.Load())
+	}
+	if r.ReplicaHealthy() {
+		t.Error("a connection error should mark the replica down")
+	}
+}
@@ -0,0 +1,119 @@
+package search
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ===========================================
+// CIRCUIT BREAKER
+// ===========================================
+// Protects callers from hammering an unhealthy Elasticsearch cluster.
+// - closed:    requests flow normally, consecutive failures are counted
+// - open:      requests are rejected immediately until the cooldown elapses
+// - half_open: a single trial request decides whether to close or re-open
+
+// ErrCircuitOpen is returned when the breaker rejects a request
+var ErrCircuitOpen = errors.New("elasticsearch circuit breaker is open")
+
+// BreakerState describes the current state of a CircuitBreaker
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"
+	BreakerOpen     BreakerState = "open"
+	BreakerHalfOpen BreakerState = "half_open"
+)
+
+// CircuitBreaker is a consecutive-failure circuit breaker safe for concurrent use
+type CircuitBreaker struct {
+	mu               sync.Mutex
+	failureThreshold int
+	cooldown         time.Duration
+	state            BreakerState
+	failures         int
+	openedAt         time.Time
+	trialInFlight    bool
+}
+
+// NewCircuitBreaker creates a breaker that opens after failureThreshold
+// consecutive failures and allows a trial request after cooldown
+func NewCircuitBreaker(failureThreshold int, cooldown time.Duration) *CircuitBreaker {
+	if failureThreshold <= 0 {
+		failureThreshold = 1
+	}
+	return &CircuitBreaker{
+		failureThreshold: failureThreshold,
+		cooldown:         cooldown,
+		state:            BreakerClosed,
+	}
+}
+
+// Allow reports whether a request may be sent to Elasticsearch
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		b.trialInFlight = true
+		return true
+	case BreakerHalfOpen:
+		// Only one trial request at a time
+		if b.trialInFlight {
+			return false
+		}
+		b.trialInFlight = true
+		return true
+	default:
+		return true
+	}
+}
+
+// RecordSuccess closes the breaker and resets the failure count
+func (b *CircuitBreaker) RecordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = BreakerClosed
+	b.failures = 0
+	b.trialInFlight = false
+}
+
+// RecordFailure counts a failure and opens the breaker when the threshold is reached
+func (b *CircuitBreaker) RecordFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.trialInFlight = false
+	if b.state == BreakerHalfOpen || b.failures >= b.failureThreshold {
+		b.state = BreakerOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// Release gives back a trial slot without recording an outcome, for requests
+// abandoned by the caller (e.g. the HTTP client disconnected)
+func (b *CircuitBreaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trialInFlight = false
+}
+
+// State returns the current breaker state
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerOpen && time.Since(b.openedAt) >= b.cooldown {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
@@ -3,15 +3,26 @@ package search
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
-	"log"
+	"net/http"
+	"os"
 	"strings"
+	"time"
 
 	"beauty-salons/internal/domain"
+	"beauty-salons/internal/logging"
+	"beauty-salons/internal/metrics"
+	"beauty-salons/internal/synonyms"
+	"beauty-salons/internal/tracing"
 
 	"github.com/elastic/go-elasticsearch/v8"
 	"github.com/elastic/go-elasticsearch/v8/esapi"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // ===========================================
@@ -26,157 +37,226 @@ const (
 	SalonIndex = "salons"
 )
 
+// Retry defaults, used when Config leaves them zero
+const (
+	DefaultRetryBackoff    = 100 * time.Millisecond
+	DefaultMaxRetryBackoff = 5 * time.Second
+)
+
+// retryOnStatus are the responses worth retrying: rate limiting and the
+// cluster or a proxy in front of it being unavailable
+var retryOnStatus = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// Config holds connection and ranking settings for the Elasticsearch client
+type Config struct {
+	Addresses []string // Nodes, tried in turn
+	CloudID   string   // Elastic Cloud deployment, used instead of Addresses
+
+	// Credentials: an API key (base64 "id:key") or basic auth
+	APIKey   string
+	Username string
+	Password string
+
+	CACertPath         string // PEM file used to verify the cluster certificate
+	InsecureSkipVerify bool   // Accept any certificate; for local clusters only
+
+	// Failed requests and 429, 502, 503 and 504 responses are retried up to
+	// MaxRetries times, waiting RetryBackoff, then twice as long each time
+	// (with jitter) up to MaxRetryBackoff
+	MaxRetries      int
+	RetryBackoff    time.Duration
+	MaxRetryBackoff time.Duration
+
+	RequestTimeout time.Duration // Per attempt, reading the response included; zero waits for the context
+	Sniff          bool          // Discover the cluster's nodes on start and every SniffInterval
+	SniffInterval  time.Duration
+	Compress       bool // Gzip request bodies
+
+	Index   string // Index or alias name (defaults to SalonIndex)
+	Ranking domain.RankingWeights
+
+	// Search-time synonyms. With SynonymsPath (relative to the Elasticsearch
+	// config directory) the rules are read from a file on every node and can
+	// be reloaded without reindexing; otherwise Synonyms are sent inline.
+	SynonymsPath string
+	Synonyms     *synonyms.Set
+}
+
 // ElasticsearchClient wraps the Elasticsearch client
 type ElasticsearchClient struct {
-	client *elasticsearch.Client
+	client    *elasticsearch.Client
+	transport *http.Transport
+	index     string
+	ranking   domain.RankingWeights
+
+	synonymsPath string
+	synonyms     *synonyms.Set
+	definition   indexDefinition
 }
 
-// NewElasticsearchClient creates a new Elasticsearch connection
-func NewElasticsearchClient(addresses []string) (*ElasticsearchClient, error) {
-	cfg := elasticsearch.Config{
-		Addresses: addresses,
+// NewElasticsearchClient creates a new Elasticsearch client.
+// The cluster is not contacted here so the API can start while it is down;
+// use Ping to test the connection.
+func NewElasticsearchClient(cfg Config) (*ElasticsearchClient, error) {
+	// Own transport so idle connections can be closed on shutdown
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	esCfg := elasticsearch.Config{
+		Addresses: cfg.Addresses,
+		CloudID:   cfg.CloudID,
+		APIKey:    cfg.APIKey,
+		Username:  cfg.Username,
+		Password:  cfg.Password,
+		Transport: &contextTransport{base: transport, timeout: cfg.RequestTimeout},
+
+		CompressRequestBody: cfg.Compress,
+	}
+	if cfg.CloudID != "" {
+		// The client refuses both; the Cloud ID encodes the endpoint
+		esCfg.Addresses = nil
+	}
+
+	if cfg.MaxRetries > 0 {
+		esCfg.MaxRetries = cfg.MaxRetries
+		esCfg.RetryOnStatus = retryOnStatus
+		esCfg.RetryOnError = retryOnError
+		esCfg.RetryBackoff = exponentialBackoff(cfg.RetryBackoff, cfg.MaxRetryBackoff)
+	} else {
+		esCfg.DisableRetry = true
+	}
+
+	if cfg.Sniff {
+		esCfg.DiscoverNodesOnStart = true
+		esCfg.DiscoverNodesInterval = cfg.SniffInterval
 	}
 
-	client, err := elasticsearch.NewClient(cfg)
+	if cfg.CACertPath != "" || cfg.InsecureSkipVerify {
+		transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: cfg.InsecureSkipVerify}
+	}
+	if cfg.CACertPath != "" {
+		caCert, err := os.ReadFile(cfg.CACertPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("no valid certificates found in %s", cfg.CACertPath)
+		}
+		transport.TLSClientConfig.RootCAs = pool
+	}
+
+	client, err := elasticsearch.NewClient(esCfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create elasticsearch client: %w", err)
 	}
 
-	// Test the connection
-	res, err := client.Info()
+	definition, err := loadIndexDefinition(mappingFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Index == "" {
+		cfg.Index = SalonIndex
+	}
+	if cfg.Ranking == (domain.RankingWeights{}) {
+		cfg.Ranking = domain.DefaultRankingWeights()
+	}
+
+	return &ElasticsearchClient{
+		client:    client,
+		transport: transport,
+		index:     cfg.Index,
+		ranking:   cfg.Ranking,
+
+		synonymsPath: cfg.SynonymsPath,
+		synonyms:     cfg.Synonyms,
+		definition:   definition,
+	}, nil
+}
+
+// Index returns the name of the index or alias searched by this client
+func (es *ElasticsearchClient) Index() string {
+	return es.index
+}
+
+// Close releases idle connections to the cluster
+func (es *ElasticsearchClient) Close() {
+	es.transport.CloseIdleConnections()
+}
+
+// startSpan opens a client span for an Elasticsearch call
+func (es *ElasticsearchClient) startSpan(ctx context.Context, operation string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs,
+		semconv.DBSystemElasticsearch,
+		semconv.DBOperation(operation),
+		attribute.String("elasticsearch.index", es.index),
+	)
+	return tracing.Start(ctx, "elasticsearch "+operation, trace.SpanKindClient, attrs...)
+}
+
+// queryType classifies a search for tracing
+func queryType(params domain.SalonSearchParams) string {
+	switch {
+	case params.Query != "" && params.Location != nil:
+		return "full_text_geo"
+	case params.Query != "":
+		return "full_text"
+	case params.Location != nil:
+		return "geo"
+	default:
+		return "browse"
+	}
+}
+
+// Ping checks that the cluster is reachable
+func (es *ElasticsearchClient) Ping(ctx context.Context) (err error) {
+	ctx, span := es.startSpan(ctx, "ping")
+	defer func() { tracing.End(span, err) }()
+
+	res, err := es.client.Info(es.client.Info.WithContext(ctx))
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to elasticsearch: %w", err)
+		return fmt.Errorf("failed to connect to elasticsearch: %w", err)
 	}
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return nil, fmt.Errorf("elasticsearch error: %s", res.String())
+		return fmt.Errorf("elasticsearch error: %s", res.String())
 	}
 
-	log.Println("Connected to Elasticsearch cluster")
-	return &ElasticsearchClient{client: client}, nil
+	return nil
 }
 
-// CreateIndex creates the salons index with proper mappings.
-// The mapping defines HOW each field is indexed and searched.
-func (es *ElasticsearchClient) CreateIndex(ctx context.Context) error {
+// CreateIndex creates the salons index with the latest versioned mapping
+// (see mapping.go), unless it already exists.
+func (es *ElasticsearchClient) CreateIndex(ctx context.Context) (err error) {
+	ctx, span := es.startSpan(ctx, "create_index")
+	defer func() { tracing.End(span, err) }()
+
 	// Check if index already exists
-	res, err := es.client.Indices.Exists([]string{SalonIndex})
+	res, err := es.client.Indices.Exists([]string{es.index}, es.client.Indices.Exists.WithContext(ctx))
 	if err != nil {
 		return fmt.Errorf("failed to check index existence: %w", err)
 	}
 	defer res.Body.Close()
 
 	if res.StatusCode == 200 {
-		log.Printf("Index %s already exists", SalonIndex)
+		logging.FromContext(ctx).Info("index already exists", "index", es.index)
 		return nil
 	}
 
-	// Define the index mapping
-	mapping := map[string]interface{}{
-		"settings": map[string]interface{}{
-			// NUMBER OF SHARDS: How data is distributed
-			"number_of_shards": 1,
-			// NUMBER OF REPLICAS:
-			"number_of_replicas": 0,
-			"analysis": map[string]interface{}{
-				"analyzer": map[string]interface{}{
-					// Custom analyzer for Spanish text
-					"spanish_analyzer": map[string]interface{}{
-						"type":      "custom",
-						"tokenizer": "standard",
-						"filter":    []string{"lowercase", "spanish_stemmer", "asciifolding"},
-					},
-				},
-				"filter": map[string]interface{}{
-					"spanish_stemmer": map[string]interface{}{
-						"type":     "stemmer",
-						"language": "spanish",
-					},
-				},
-			},
-		},
-		"mappings": map[string]interface{}{
-			"properties": map[string]interface{}{
-				// Text fields are analyzed for full-text search
-				"name": map[string]interface{}{
-					"type":     "text",
-					"analyzer": "spanish_analyzer",
-					"fields": map[string]interface{}{
-						// Also store as keyword for exact matching & sorting
-						"keyword": map[string]interface{}{
-							"type": "keyword",
-						},
-					},
-				},
-				"description": map[string]interface{}{
-					"type":     "text",
-					"analyzer": "spanish_analyzer",
-				},
-				// Keyword fields are for exact matches and aggregations
-				"slug": map[string]interface{}{
-					"type": "keyword",
-				},
-				"city": map[string]interface{}{
-					"type": "keyword",
-				},
-				"state": map[string]interface{}{
-					"type": "keyword",
-				},
-				"country": map[string]interface{}{
-					"type": "keyword",
-				},
-				"category_name": map[string]interface{}{
-					"type": "keyword",
-				},
-				// Numeric fields
-				"price_range": map[string]interface{}{
-					"type": "integer",
-				},
-				"rating": map[string]interface{}{
-					"type": "float",
-				},
-				"review_count": map[string]interface{}{
-					"type": "integer",
-				},
-				// Boolean fields
-				"is_active": map[string]interface{}{
-					"type": "boolean",
-				},
-				"is_verified": map[string]interface{}{
-					"type": "boolean",
-				},
-				// Geo-point for location-based search!
-				"location": map[string]interface{}{
-					"type": "geo_point",
-				},
-				// Nested array of services
-				"services": map[string]interface{}{
-					"type": "nested",
-					"properties": map[string]interface{}{
-						"name": map[string]interface{}{
-							"type":     "text",
-							"analyzer": "spanish_analyzer",
-						},
-						"price_min": map[string]interface{}{
-							"type": "float",
-						},
-						"price_max": map[string]interface{}{
-							"type": "float",
-						},
-					},
-				},
-				// Array of amenity names
-				"amenities": map[string]interface{}{
-					"type": "keyword",
-				},
-			},
-		},
+	mapping, meta, err := es.indexBody()
+	if err != nil {
+		return err
 	}
 
 	body, _ := json.Marshal(mapping)
 	res, err = es.client.Indices.Create(
-		SalonIndex,
+		es.index,
 		es.client.Indices.Create.WithBody(bytes.NewReader(body)),
 		es.client.Indices.Create.WithContext(ctx),
 	)
@@ -189,22 +269,69 @@ func (es *ElasticsearchClient) CreateIndex(ctx context.Context) error {
 		return fmt.Errorf("failed to create index: %s", res.String())
 	}
 
-	log.Printf("Created index %s", SalonIndex)
+	logging.FromContext(ctx).Info("created index", "index", es.index, "mapping_version", meta.Version)
 	return nil
 }
 
+// completeSynonymFilter points the salon_synonyms filter at the synonyms
+// file or fills in the rules. Documents are indexed without synonyms;
+// queries expand them at search time, so the rules can change without
+// reindexing.
+func (es *ElasticsearchClient) completeSynonymFilter(filter map[string]interface{}) {
+	if es.synonymsPath != "" {
+		filter["synonyms_path"] = es.synonymsPath
+		filter["updateable"] = true
+		return
+	}
+	rules := es.synonyms.Rules()
+	if rules == nil {
+		rules = []string{}
+	}
+	filter["synonyms"] = rules
+}
+
+// ReloadSynonyms reloads search analyzers so edits to the synonyms file on
+// the Elasticsearch nodes take effect without reindexing
+func (es *ElasticsearchClient) ReloadSynonyms(ctx context.Context) (_ map[string]interface{}, err error) {
+	ctx, span := es.startSpan(ctx, "reload_search_analyzers")
+	defer func() { tracing.End(span, err) }()
+
+	if es.synonymsPath == "" {
+		return nil, fmt.Errorf("synonyms are inline in the index settings; set a synonyms path to reload them")
+	}
+
+	res, err := es.client.Indices.ReloadSearchAnalyzers(
+		[]string{es.index},
+		es.client.Indices.ReloadSearchAnalyzers.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reload search analyzers: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, fmt.Errorf("reload search analyzers error: %s", res.String())
+	}
+
+	var result map[string]interface{}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to parse reload response: %w", err)
+	}
+	return result, nil
+}
+
 // IndexSalon indexes a single salon document
-func (es *ElasticsearchClient) IndexSalon(ctx context.Context, salon *domain.Salon) error {
-	// Transform to ES document format
-	doc := es.salonToDocument(salon)
+func (es *ElasticsearchClient) IndexSalon(ctx context.Context, salon *domain.Salon) (err error) {
+	ctx, span := es.startSpan(ctx, "index")
+	defer func() { tracing.End(span, err) }()
 
-	body, err := json.Marshal(doc)
+	body, err := json.Marshal(newSalonDocument(salon))
 	if err != nil {
 		return fmt.Errorf("failed to marshal salon: %w", err)
 	}
 
 	req := esapi.IndexRequest{
-		Index:      SalonIndex,
+		Index:      es.index,
 		DocumentID: fmt.Sprintf("%d", salon.ID),
 		Body:       bytes.NewReader(body),
 		Refresh:    "true", // Make immediately searchable (slower)
@@ -223,8 +350,12 @@ func (es *ElasticsearchClient) IndexSalon(ctx context.Context, salon *domain.Sal
 	return nil
 }
 
-// BulkIndexSalons indexes multiple salons at once
-func (es *ElasticsearchClient) BulkIndexSalons(ctx context.Context, salons []domain.Salon) error {
+// BulkIndexSalons indexes multiple salons at once. Documents the cluster
+// rejects make it fail, after the accepted ones are indexed.
+func (es *ElasticsearchClient) BulkIndexSalons(ctx context.Context, salons []domain.Salon) (err error) {
+	ctx, span := es.startSpan(ctx, "bulk")
+	defer func() { tracing.End(span, err) }()
+
 	if len(salons) == 0 {
 		return nil
 	}
@@ -234,7 +365,7 @@ func (es *ElasticsearchClient) BulkIndexSalons(ctx context.Context, salons []dom
 		// Action line
 		meta := map[string]interface{}{
 			"index": map[string]interface{}{
-				"_index": SalonIndex,
+				"_index": es.index,
 				"_id":    fmt.Sprintf("%d", salons[i].ID),
 			},
 		}
@@ -243,95 +374,115 @@ func (es *ElasticsearchClient) BulkIndexSalons(ctx context.Context, salons []dom
 		buf.WriteByte('\n')
 
 		// Document line
-		doc := es.salonToDocument(&salons[i])
-		docBytes, _ := json.Marshal(doc)
+		docBytes, _ := json.Marshal(newSalonDocument(&salons[i]))
 		buf.Write(docBytes)
 		buf.WriteByte('\n')
 	}
 
+	start := time.Now()
 	res, err := es.client.Bulk(
 		bytes.NewReader(buf.Bytes()),
 		es.client.Bulk.WithContext(ctx),
 		es.client.Bulk.WithRefresh("true"),
 	)
 	if err != nil {
+		metrics.ObserveElasticsearch("bulk", start, err)
+		metrics.SyncDocumentsFailed.Add(float64(len(salons)))
 		return fmt.Errorf("failed to bulk index: %w", err)
 	}
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return fmt.Errorf("bulk index error: %s", res.String())
+		err := fmt.Errorf("bulk index error: %s", res.String())
+		metrics.ObserveElasticsearch("bulk", start, err)
+		metrics.SyncDocumentsFailed.Add(float64(len(salons)))
+		return err
 	}
 
-	log.Printf("Indexed %d salons", len(salons))
-	return nil
-}
-
-// Search performs a search query against Elasticsearch
-func (es *ElasticsearchClient) Search(ctx context.Context, params domain.SalonSearchParams) ([]domain.SalonSearchResult, int, error) {
-	// Build the query
-	query := es.buildQuery(params)
-
-	body, _ := json.Marshal(query)
-
-	res, err := es.client.Search(
-		es.client.Search.WithContext(ctx),
-		es.client.Search.WithIndex(SalonIndex),
-		es.client.Search.WithBody(bytes.NewReader(body)),
-	)
-	if err != nil {
-		return nil, 0, fmt.Errorf("search failed: %w", err)
+	// A successful bulk response can still reject individual documents
+	var bulkRes bulkResponse
+	if err := json.NewDecoder(res.Body).Decode(&bulkRes); err != nil {
+		metrics.ObserveElasticsearch("bulk", start, err)
+		return fmt.Errorf("failed to parse bulk response: %w", err)
 	}
-	defer res.Body.Close()
+	metrics.ObserveElasticsearch("bulk", start, nil)
 
-	if res.IsError() {
-		return nil, 0, fmt.Errorf("search error: %s", res.String())
+	failed := 0
+	var firstError *errorCause
+	for _, item := range bulkRes.Items {
+		for _, action := range item {
+			if action.Status >= 300 {
+				failed++
+				if firstError == nil {
+					firstError = action.Error
+				}
+			}
+		}
 	}
+	metrics.SyncDocumentsIndexed.Add(float64(len(salons) - failed))
+	metrics.SyncDocumentsFailed.Add(float64(failed))
 
-	// Parse response
-	var result map[string]interface{}
-	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
-		return nil, 0, fmt.Errorf("failed to parse response: %w", err)
+	if failed > 0 {
+		// With a strict mapping this is usually a field the index lacks
+		err := fmt.Errorf("bulk index rejected %d of %d documents", failed, len(salons))
+		if firstError != nil {
+			err = fmt.Errorf("%w, first: %s", err, firstError.String())
+		}
+		return err
 	}
+	logging.FromContext(ctx).Info("bulk indexed salons", "index", es.index, "indexed", len(salons), "latency_ms", time.Since(start).Milliseconds())
+	return nil
+}
 
-	hits := result["hits"].(map[string]interface{})
-	total := int(hits["total"].(map[string]interface{})["value"].(float64))
+// Search performs a search query against Elasticsearch. The warnings
+// describe shard failures or a timeout that left the results incomplete.
+func (es *ElasticsearchClient) Search(ctx context.Context, params domain.SalonSearchParams) (results []domain.SalonSearchResult, total int, warnings []string, err error) {
+	start := time.Now()
+	ctx, span := es.startSpan(ctx, "search", attribute.String("search.query_type", queryType(params)))
+	defer func() {
+		span.SetAttributes(attribute.Int("search.hits", total))
+		tracing.End(span, err)
+		metrics.ObserveElasticsearch("search", start, err)
+		logging.FromContext(ctx).Debug("elasticsearch query",
+			"index", es.index, "hits", total, "latency_ms", time.Since(start).Milliseconds(), "error", err)
+	}()
 
-	hitsList := hits["hits"].([]interface{})
-	results := make([]domain.SalonSearchResult, 0, len(hitsList))
+	query := es.buildQuery(params)
+	from, _ := query["from"].(int)
 
-	for _, hit := range hitsList {
-		hitMap := hit.(map[string]interface{})
-		source := hitMap["_source"].(map[string]interface{})
+	var result searchResponse[salonDocument, struct{}]
+	if err := es.search(ctx, query, &result); err != nil {
+		return nil, 0, nil, err
+	}
+	if warnings, err = es.partialResults(ctx, "search", result.responseHeader); err != nil {
+		return nil, 0, nil, err
+	}
 
-		salon := es.documentToSalon(source)
+	results = make([]domain.SalonSearchResult, 0, len(result.Hits.Hits))
+	for _, hit := range result.Hits.Hits {
 		searchResult := domain.SalonSearchResult{
-			Salon: salon,
+			Salon: hit.Source.toDomain(),
 		}
 
 		// Extract relevance score
-		if score, ok := hitMap["_score"].(float64); ok {
-			searchResult.Score = score
+		if hit.Score != nil {
+			searchResult.Score = *hit.Score
 		}
 
-		// Extract distance from sort values (present when geo_distance sort is used)
-		if sortValues, ok := hitMap["sort"].([]interface{}); ok && params.Location != nil {
-			for _, sv := range sortValues {
-				if dist, ok := sv.(float64); ok && dist >= 0 && dist < 40075 {
-					searchResult.Distance = &dist
-					break
-				}
+		// Extract distance from sort values: with a location the geo_distance
+		// sort is always last (earlier values may be _score or rating)
+		if n := len(hit.Sort); params.Location != nil && n > 0 {
+			if dist := hit.Sort[n-1].Number; dist != nil && *dist >= 0 && *dist < 40075 {
+				searchResult.Distance = dist
 			}
 		}
 
 		// Extract highlights
-		if highlightMap, ok := hitMap["highlight"].(map[string]interface{}); ok {
+		if len(hit.Highlight) > 0 {
 			searchResult.Highlights = make(map[string]string)
-			for field, fragments := range highlightMap {
-				if frags, ok := fragments.([]interface{}); ok && len(frags) > 0 {
-					if s, ok := frags[0].(string); ok {
-						searchResult.Highlights[field] = s
-					}
+			for field, fragments := range hit.Highlight {
+				if len(fragments) > 0 {
+					searchResult.Highlights[field] = fragments[0]
 				}
 			}
 		}
@@ -339,11 +490,178 @@ func (es *ElasticsearchClient) Search(ctx context.Context, params domain.SalonSe
 		results = append(results, searchResult)
 	}
 
-	return results, total, nil
+	return results, result.total(from), warnings, nil
+}
+
+// SearchPins returns up to limit map markers for a search, best ranked
+// first, fetching only the fields a marker needs
+func (es *ElasticsearchClient) SearchPins(ctx context.Context, params domain.SalonSearchParams, limit int) (pins []domain.SalonPin, total int, warnings []string, err error) {
+	start := time.Now()
+	ctx, span := es.startSpan(ctx, "search_pins", attribute.String("search.query_type", queryType(params)))
+	defer func() {
+		span.SetAttributes(attribute.Int("search.hits", total))
+		tracing.End(span, err)
+		metrics.ObserveElasticsearch("search_pins", start, err)
+	}()
+
+	query := es.buildQuery(params)
+	query["from"] = 0
+	query["size"] = limit
+	query["_source"] = pinSource
+	query["track_total_hits"] = true
+	delete(query, "highlight")
+
+	var result searchResponse[pinDocument, struct{}]
+	if err := es.search(ctx, query, &result); err != nil {
+		return nil, 0, nil, err
+	}
+	if warnings, err = es.partialResults(ctx, "search_pins", result.responseHeader); err != nil {
+		return nil, 0, nil, err
+	}
+
+	pins = make([]domain.SalonPin, 0, len(result.Hits.Hits))
+	for _, hit := range result.Hits.Hits {
+		if pin, ok := hit.Source.toPin(); ok {
+			pins = append(pins, pin)
+		}
+	}
+
+	return pins, result.total(0), warnings, nil
+}
+
+// SearchClusters groups the salons matching a search into map tiles at the
+// given zoom, returning up to limit tiles with the most salons. Each tile
+// has the centroid of its salons and its best rated salon.
+func (es *ElasticsearchClient) SearchClusters(ctx context.Context, params domain.SalonSearchParams, zoom, limit int) (clusters []domain.SalonCluster, total int, warnings []string, err error) {
+	start := time.Now()
+	ctx, span := es.startSpan(ctx, "search_clusters",
+		attribute.String("search.query_type", queryType(params)),
+		attribute.Int("search.zoom", zoom))
+	defer func() {
+		span.SetAttributes(attribute.Int("search.hits", total))
+		tracing.End(span, err)
+		metrics.ObserveElasticsearch("search_clusters", start, err)
+	}()
+
+	grid := map[string]interface{}{
+		"field":     "location",
+		"precision": zoom,
+		"size":      limit,
+	}
+	// Skip building tiles outside the viewport
+	if b := params.BoundingBox; b != nil {
+		grid["bounds"] = map[string]interface{}{
+			"top_left":     map[string]interface{}{"lat": b.MaxLat, "lon": b.MinLon},
+			"bottom_right": map[string]interface{}{"lat": b.MinLat, "lon": b.MaxLon},
+		}
+	}
+
+	query := es.buildQuery(params)
+	query["from"] = 0
+	query["size"] = 0
+	query["track_total_hits"] = true
+	delete(query, "sort")
+	delete(query, "highlight")
+	query["aggs"] = map[string]interface{}{
+		"clusters": map[string]interface{}{
+			"geotile_grid": grid,
+			"aggs": map[string]interface{}{
+				"centroid": map[string]interface{}{
+					"geo_centroid": map[string]interface{}{"field": "location"},
+				},
+				"top_salon": map[string]interface{}{
+					"top_hits": map[string]interface{}{
+						"size": 1,
+						"sort": []map[string]interface{}{
+							{"rating": map[string]interface{}{"order": "desc", "missing": "_last"}},
+							{"review_count": map[string]interface{}{"order": "desc"}},
+							{"id": map[string]interface{}{"order": "asc"}},
+						},
+						"_source": pinSource,
+					},
+				},
+			},
+		},
+	}
+
+	var result searchResponse[struct{}, clusterAggregations]
+	if err := es.search(ctx, query, &result); err != nil {
+		return nil, 0, nil, err
+	}
+	if warnings, err = es.partialResults(ctx, "search_clusters", result.responseHeader); err != nil {
+		return nil, 0, nil, err
+	}
+
+	clusters = make([]domain.SalonCluster, 0, len(result.Aggregations.Clusters.Buckets))
+	for _, bucket := range result.Aggregations.Clusters.Buckets {
+		z, x, y, err := domain.ParseTileKey(bucket.Key)
+		if err != nil {
+			return nil, 0, nil, fmt.Errorf("failed to parse response: %w", err)
+		}
+
+		cluster := domain.SalonCluster{
+			Key:      bucket.Key,
+			Count:    bucket.DocCount,
+			Centroid: bucket.Centroid.Location,
+			Bounds:   domain.TileBounds(z, x, y),
+		}
+		if hits := bucket.TopSalon.Hits.Hits; len(hits) > 0 {
+			if pin, ok := hits[0].Source.toPin(); ok {
+				cluster.TopSalon = &pin
+			}
+		}
+		clusters = append(clusters, cluster)
+	}
+
+	return clusters, result.total(0), warnings, nil
+}
+
+// search runs a search request and decodes the response into result
+func (es *ElasticsearchClient) search(ctx context.Context, query map[string]interface{}, result interface{}) error {
+	body, err := json.Marshal(query)
+	if err != nil {
+		return fmt.Errorf("failed to marshal query: %w", err)
+	}
+
+	res, err := es.client.Search(
+		es.client.Search.WithContext(ctx),
+		es.client.Search.WithIndex(es.index),
+		es.client.Search.WithBody(bytes.NewReader(body)),
+	)
+	if err != nil {
+		return fmt.Errorf("search failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("search error: %s", res.String())
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+// partialResults logs and counts the shard failures of a search that
+// succeeded on some shards, returning them as warnings
+func (es *ElasticsearchClient) partialResults(ctx context.Context, operation string, header responseHeader) ([]string, error) {
+	warnings, err := header.warnings()
+	if header.Shards.Failed > 0 {
+		metrics.ESShardFailures.WithLabelValues(operation).Add(float64(header.Shards.Failed))
+	}
+	if err == nil && len(warnings) > 0 {
+		logging.FromContext(ctx).Warn("elasticsearch returned partial results",
+			"operation", operation, "index", es.index, "warnings", warnings)
+	}
+	return warnings, err
 }
 
 // GetClusterHealth returns cluster health information
-func (es *ElasticsearchClient) GetClusterHealth(ctx context.Context) (map[string]interface{}, error) {
+func (es *ElasticsearchClient) GetClusterHealth(ctx context.Context) (_ map[string]interface{}, err error) {
+	ctx, span := es.startSpan(ctx, "cluster_health")
+	defer func() { tracing.End(span, err) }()
+
 	res, err := es.client.Cluster.Health(
 		es.client.Cluster.Health.WithContext(ctx),
 	)
@@ -360,10 +678,71 @@ func (es *ElasticsearchClient) GetClusterHealth(ctx context.Context) (map[string
 	return health, nil
 }
 
+// ClusterStatus returns the cluster health status (green, yellow or red)
+func (es *ElasticsearchClient) ClusterStatus(ctx context.Context) (_ string, err error) {
+	ctx, span := es.startSpan(ctx, "cluster_health")
+	defer func() { tracing.End(span, err) }()
+
+	res, err := es.client.Cluster.Health(
+		es.client.Cluster.Health.WithContext(ctx),
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to get cluster health: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return "", fmt.Errorf("cluster health error: %s", res.String())
+	}
+
+	var health struct {
+		Status string `json:"status"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&health); err != nil {
+		return "", fmt.Errorf("failed to parse cluster health: %w", err)
+	}
+
+	return health.Status, nil
+}
+
+// CountDocuments returns the number of documents in the salons index or alias
+func (es *ElasticsearchClient) CountDocuments(ctx context.Context) (_ int64, err error) {
+	ctx, span := es.startSpan(ctx, "count")
+	defer func() { tracing.End(span, err) }()
+
+	res, err := es.client.Count(
+		es.client.Count.WithIndex(es.index),
+		es.client.Count.WithContext(ctx),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count documents: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == 404 {
+		return 0, fmt.Errorf("index %s does not exist", es.index)
+	}
+	if res.IsError() {
+		return 0, fmt.Errorf("count error: %s", res.String())
+	}
+
+	var count struct {
+		Count int64 `json:"count"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&count); err != nil {
+		return 0, fmt.Errorf("failed to parse count: %w", err)
+	}
+
+	return count.Count, nil
+}
+
 // GetIndexStats returns index statistics
-func (es *ElasticsearchClient) GetIndexStats(ctx context.Context) (map[string]interface{}, error) {
+func (es *ElasticsearchClient) GetIndexStats(ctx context.Context) (_ map[string]interface{}, err error) {
+	ctx, span := es.startSpan(ctx, "index_stats")
+	defer func() { tracing.End(span, err) }()
+
 	res, err := es.client.Indices.Stats(
-		es.client.Indices.Stats.WithIndex(SalonIndex),
+		es.client.Indices.Stats.WithIndex(es.index),
 		es.client.Indices.Stats.WithContext(ctx),
 	)
 	if err != nil {
@@ -389,7 +768,7 @@ func (es *ElasticsearchClient) buildQuery(params domain.SalonSearchParams) map[s
 		must = append(must, map[string]interface{}{
 			"multi_match": map[string]interface{}{
 				"query":     params.Query,
-				"fields":    []string{"name^3", "description", "services.name"},
+				"fields":    []string{"name^3", "name.english^2", "description", "description.english", "services.name"},
 				"fuzziness": "AUTO", // Typo tolerance!
 			},
 		})
@@ -457,6 +836,39 @@ func (es *ElasticsearchClient) buildQuery(params domain.SalonSearchParams) map[s
 		})
 	}
 
+	// Map viewport
+	if params.BoundingBox != nil {
+		filter = append(filter, map[string]interface{}{
+			"geo_bounding_box": map[string]interface{}{
+				"location": map[string]interface{}{
+					"top_left": map[string]interface{}{
+						"lat": params.BoundingBox.MaxLat,
+						"lon": params.BoundingBox.MinLon,
+					},
+					"bottom_right": map[string]interface{}{
+						"lat": params.BoundingBox.MinLat,
+						"lon": params.BoundingBox.MaxLon,
+					},
+				},
+			},
+		})
+	}
+
+	// Polygon (geo_shape queries also work on geo_point fields)
+	if params.Polygon != nil {
+		filter = append(filter, map[string]interface{}{
+			"geo_shape": map[string]interface{}{
+				"location": map[string]interface{}{
+					"shape": map[string]interface{}{
+						"type":        "polygon",
+						"coordinates": params.Polygon.Coordinates,
+					},
+					"relation": "intersects",
+				},
+			},
+		})
+	}
+
 	// If no text query, match all
 	if len(must) == 0 {
 		must = append(must, map[string]interface{}{
@@ -504,11 +916,11 @@ func (es *ElasticsearchClient) buildQuery(params domain.SalonSearchParams) map[s
 
 	// Build scoring functions for custom ranking
 	functions := []map[string]interface{}{
-		// Rating boost: rating * 2 (e.g., rating 4.5 adds +9)
+		// Rating boost: rating * 2 by default (e.g., rating 4.5 adds +9)
 		{
 			"field_value_factor": map[string]interface{}{
 				"field":    "rating",
-				"factor":   2,
+				"factor":   es.ranking.Rating,
 				"modifier": "none",
 				"missing":  0,
 			},
@@ -523,16 +935,16 @@ func (es *ElasticsearchClient) buildQuery(params domain.SalonSearchParams) map[s
 				"modifier": "log1p",
 				"missing":  0,
 			},
-			"weight": 1.5,
+			"weight": es.ranking.Reviews,
 		},
-		// Verified boost: verified salons get +5
+		// Verified boost: verified salons get +5 by default
 		{
 			"filter": map[string]interface{}{
 				"term": map[string]interface{}{
 					"is_verified": true,
 				},
 			},
-			"weight": 5,
+			"weight": es.ranking.Verified,
 		},
 	}
 
@@ -545,12 +957,12 @@ func (es *ElasticsearchClient) buildQuery(params domain.SalonSearchParams) map[s
 						"lat": params.Location.Latitude,
 						"lon": params.Location.Longitude,
 					},
-					"scale":  "5km",
+					"scale":  fmt.Sprintf("%gkm", es.ranking.DistanceScaleKm),
 					"offset": "0km",
 					"decay":  0.5,
 				},
 			},
-			"weight": 3,
+			"weight": es.ranking.Distance,
 		})
 	}
 
@@ -596,155 +1008,13 @@ func (es *ElasticsearchClient) buildQuery(params domain.SalonSearchParams) map[s
 	}
 }
 
-// salonToDocument converts a Salon to an ES document
-func (es *ElasticsearchClient) salonToDocument(salon *domain.Salon) map[string]interface{} {
-	doc := map[string]interface{}{
-		"id":           salon.ID,
-		"name":         salon.Name,
-		"slug":         salon.Slug,
-		"description":  salon.Description,
-		"address":      salon.Location.Address,
-		"city":         salon.Location.City,
-		"state":        salon.Location.State,
-		"country":      salon.Location.Country,
-		"phone":        salon.Contact.Phone,
-		"email":        salon.Contact.Email,
-		"website":      salon.Contact.Website,
-		"category_id":  salon.CategoryID,
-		"price_range":  salon.PriceRange,
-		"rating":       salon.Rating,
-		"review_count": salon.ReviewCount,
-		"is_active":    salon.IsActive,
-		"is_verified":  salon.IsVerified,
-	}
-
-	// Add geo-point if coordinates exist
-	if salon.Location.GeoPoint != nil {
-		doc["location"] = map[string]interface{}{
-			"lat": salon.Location.GeoPoint.Latitude,
-			"lon": salon.Location.GeoPoint.Longitude,
-		}
-	}
-
-	if salon.Category != nil {
-		doc["category_name"] = salon.Category.Name
-	}
-
-	if len(salon.Services) > 0 {
-		services := make([]map[string]interface{}, len(salon.Services))
-		for i, s := range salon.Services {
-			services[i] = map[string]interface{}{
-				"name":      s.Name,
-				"price_min": s.PriceMin,
-				"price_max": s.PriceMax,
-			}
-		}
-		doc["services"] = services
-	}
-
-	if len(salon.Amenities) > 0 {
-		amenityNames := make([]string, len(salon.Amenities))
-		for i, a := range salon.Amenities {
-			amenityNames[i] = a.Name
-		}
-		doc["amenities"] = amenityNames
-	}
-
-	return doc
-}
-
-// documentToSalon converts an ES document back to a Salon
-func (es *ElasticsearchClient) documentToSalon(doc map[string]interface{}) domain.Salon {
-	salon := domain.Salon{}
-
-	if v, ok := doc["id"].(float64); ok {
-		salon.ID = int64(v)
-	}
-	if v, ok := doc["name"].(string); ok {
-		salon.Name = v
-	}
-	if v, ok := doc["slug"].(string); ok {
-		salon.Slug = v
-	}
-	if v, ok := doc["description"].(string); ok {
-		salon.Description = &v
-	}
-
-	// Location fields
-	if v, ok := doc["address"].(string); ok {
-		salon.Location.Address = v
-	}
-	if v, ok := doc["city"].(string); ok {
-		salon.Location.City = v
-	}
-	if v, ok := doc["state"].(string); ok {
-		salon.Location.State = v
-	}
-	if v, ok := doc["country"].(string); ok {
-		salon.Location.Country = v
-	}
-	if loc, ok := doc["location"].(map[string]interface{}); ok {
-		if lat, ok := loc["lat"].(float64); ok {
-			if lon, ok := loc["lon"].(float64); ok {
-				salon.Location.GeoPoint = &domain.GeoPoint{
-					Latitude:  lat,
-					Longitude: lon,
-				}
-			}
-		}
-	}
-
-	// Contact fields
-	if v, ok := doc["phone"].(string); ok {
-		salon.Contact.Phone = v
-	}
-	if v, ok := doc["email"].(string); ok {
-		salon.Contact.Email = v
-	}
-	if v, ok := doc["website"].(string); ok {
-		salon.Contact.Website = v
-	}
-
-	// Business info
-	if v, ok := doc["rating"].(float64); ok {
-		salon.Rating = &v
-	}
-	if v, ok := doc["review_count"].(float64); ok {
-		salon.ReviewCount = int(v)
-	}
-	if v, ok := doc["price_range"].(float64); ok {
-		salon.PriceRange = domain.PriceRange(int(v))
-	}
-	if v, ok := doc["is_verified"].(bool); ok {
-		salon.IsVerified = v
-	}
-	if v, ok := doc["is_active"].(bool); ok {
-		salon.IsActive = v
-	}
-	if v, ok := doc["category_name"].(string); ok {
-		salon.Category = &domain.Category{Name: v}
-	}
-	if v, ok := doc["category_id"].(float64); ok {
-		catID := int64(v)
-		salon.CategoryID = &catID
-	}
-
-	// Handle amenities array
-	if v, ok := doc["amenities"].([]interface{}); ok {
-		for _, a := range v {
-			if s, ok := a.(string); ok {
-				salon.Amenities = append(salon.Amenities, domain.Amenity{Name: s})
-			}
-		}
-	}
-
-	return salon
-}
-
 // DeleteIndex removes the index
-func (es *ElasticsearchClient) DeleteIndex(ctx context.Context) error {
+func (es *ElasticsearchClient) DeleteIndex(ctx context.Context) (err error) {
+	ctx, span := es.startSpan(ctx, "delete_index")
+	defer func() { tracing.End(span, err) }()
+
 	res, err := es.client.Indices.Delete(
-		[]string{SalonIndex},
+		[]string{es.index},
 		es.client.Indices.Delete.WithContext(ctx),
 	)
 	if err != nil {
@@ -0,0 +1,197 @@
+package search
+
+import (
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path"
+	"regexp"
+	"strconv"
+
+	"beauty-salons/internal/tracing"
+)
+
+// ===========================================
+// INDEX MAPPINGS
+// ===========================================
+// The settings and mappings of the salons index live in mappings/vN.json;
+// the highest version is the one indexes are created with. Changing them
+// means adding a new version rather than editing an old one.
+//
+// The salon_synonyms filter is completed at runtime with the synonyms file
+// path or the inline rules. A hash of the analysis settings and mappings is
+// stored in the mapping's _meta, so a live index can be compared with the
+// code (CheckMapping) and reindexed when they differ.
+
+//go:embed mappings/*.json
+var mappingFiles embed.FS
+
+var mappingFileName = regexp.MustCompile(`^v(\d+)\.json$`)
+
+// indexDefinition is one version of the index settings and mappings
+type indexDefinition struct {
+	version int
+	raw     []byte
+}
+
+// loadIndexDefinition returns the latest mapping version
+func loadIndexDefinition(fsys fs.FS) (indexDefinition, error) {
+	entries, err := fs.ReadDir(fsys, "mappings")
+	if err != nil {
+		return indexDefinition{}, fmt.Errorf("failed to read index mappings: %w", err)
+	}
+
+	var latest indexDefinition
+	for _, e := range entries {
+		m := mappingFileName.FindStringSubmatch(e.Name())
+		if m == nil {
+			continue
+		}
+		version, _ := strconv.Atoi(m[1])
+		raw, err := fs.ReadFile(fsys, path.Join("mappings", e.Name()))
+		if err != nil {
+			return indexDefinition{}, fmt.Errorf("failed to read index mapping %s: %w", e.Name(), err)
+		}
+
+		var body struct {
+			Settings map[string]interface{} `json:"settings"`
+			Mappings map[string]interface{} `json:"mappings"`
+		}
+		if err := json.Unmarshal(raw, &body); err != nil {
+			return indexDefinition{}, fmt.Errorf("invalid index mapping %s: %w", e.Name(), err)
+		}
+		if body.Mappings["properties"] == nil {
+			return indexDefinition{}, fmt.Errorf("invalid index mapping %s: no mappings.properties", e.Name())
+		}
+		if version > latest.version {
+			latest = indexDefinition{version: version, raw: raw}
+		}
+	}
+	if latest.version == 0 {
+		return indexDefinition{}, fmt.Errorf("no index mappings found")
+	}
+	return latest, nil
+}
+
+// MappingMeta is what the index records about the mapping it was created with
+type MappingMeta struct {
+	Version int    `json:"mapping_version"`
+	Hash    string `json:"mapping_hash"`
+}
+
+// indexBody returns the create index request body, with the synonyms filled
+// in and the mapping version and hash in mappings._meta
+func (es *ElasticsearchClient) indexBody() (map[string]interface{}, MappingMeta, error) {
+	var body map[string]interface{}
+	if err := json.Unmarshal(es.definition.raw, &body); err != nil {
+		return nil, MappingMeta{}, fmt.Errorf("invalid index mapping: %w", err)
+	}
+	settings, _ := body["settings"].(map[string]interface{})
+	mappings, _ := body["mappings"].(map[string]interface{})
+	analysis, _ := settings["analysis"].(map[string]interface{})
+	filters, _ := analysis["filter"].(map[string]interface{})
+	synonymFilter, ok := filters["salon_synonyms"].(map[string]interface{})
+	if !ok {
+		return nil, MappingMeta{}, fmt.Errorf("invalid index mapping: no salon_synonyms filter")
+	}
+	es.completeSynonymFilter(synonymFilter)
+
+	// Replica and shard counts can change without reindexing, so only the
+	// analysis settings and the mappings are hashed (map keys are sorted
+	// when marshalled)
+	hashed, err := json.Marshal(map[string]interface{}{"analysis": analysis, "mappings": mappings})
+	if err != nil {
+		return nil, MappingMeta{}, fmt.Errorf("failed to hash index mapping: %w", err)
+	}
+	sum := sha256.Sum256(hashed)
+	meta := MappingMeta{Version: es.definition.version, Hash: hex.EncodeToString(sum[:])}
+
+	mappings["_meta"] = map[string]interface{}{
+		"mapping_version": meta.Version,
+		"mapping_hash":    meta.Hash,
+	}
+	return body, meta, nil
+}
+
+// MappingStatus compares the live index mapping with the code's
+type MappingStatus struct {
+	Index    string       `json:"index"`          // Concrete index behind the configured name
+	Exists   bool         `json:"exists"`         // False when the index hasn't been created
+	Live     *MappingMeta `json:"live,omitempty"` // Nil for indexes created without a hash
+	Expected MappingMeta  `json:"expected"`
+}
+
+// InSync reports whether the live index has the code's mapping
+func (s MappingStatus) InSync() bool {
+	return s.Exists && s.Live != nil && s.Live.Hash == s.Expected.Hash
+}
+
+// String describes the status in one line
+func (s MappingStatus) String() string {
+	switch {
+	case !s.Exists:
+		return fmt.Sprintf("index %s does not exist", s.Index)
+	case s.Live == nil:
+		return fmt.Sprintf("index %s has no mapping hash (created before versioned mappings); expected v%d", s.Index, s.Expected.Version)
+	case !s.InSync():
+		return fmt.Sprintf("index %s has mapping v%d (%.12s), expected v%d (%.12s)",
+			s.Index, s.Live.Version, s.Live.Hash, s.Expected.Version, s.Expected.Hash)
+	default:
+		return fmt.Sprintf("index %s is up to date (mapping v%d)", s.Index, s.Live.Version)
+	}
+}
+
+// CheckMapping compares the mapping of the live index with the one the
+// index would be created with now
+func (es *ElasticsearchClient) CheckMapping(ctx context.Context) (_ *MappingStatus, err error) {
+	ctx, span := es.startSpan(ctx, "get_mapping")
+	defer func() { tracing.End(span, err) }()
+
+	_, expected, err := es.indexBody()
+	if err != nil {
+		return nil, err
+	}
+	status := &MappingStatus{Index: es.index, Expected: expected}
+
+	res, err := es.client.Indices.GetMapping(
+		es.client.Indices.GetMapping.WithIndex(es.index),
+		es.client.Indices.GetMapping.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get index mapping: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return status, nil
+	}
+	if res.IsError() {
+		return nil, fmt.Errorf("get mapping error: %s", res.String())
+	}
+
+	// Keyed by concrete index, which differs from es.index for an alias
+	var indices map[string]struct {
+		Mappings struct {
+			Meta *MappingMeta `json:"_meta"`
+		} `json:"mappings"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&indices); err != nil {
+		return nil, fmt.Errorf("failed to parse mapping response: %w", err)
+	}
+	if len(indices) != 1 {
+		return nil, fmt.Errorf("%s resolves to %d indices, expected 1", es.index, len(indices))
+	}
+	for name, index := range indices {
+		status.Index = name
+		status.Exists = true
+		if meta := index.Mappings.Meta; meta != nil && meta.Hash != "" {
+			status.Live = meta
+		}
+	}
+	return status, nil
+}
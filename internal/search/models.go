@@ -0,0 +1,410 @@
+package search
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"beauty-salons/internal/domain"
+)
+
+// ===========================================
+// DOCUMENTS AND RESPONSES
+// ===========================================
+// Typed models of the salon document and of the parts of Elasticsearch
+// responses the client reads. Responses are decoded into these instead of
+// map[string]interface{}, so a missing or unexpected field is a zero value
+// (or a decode error) rather than a panicking type assertion.
+
+// salonDocument is a salon as stored in the index. It holds every
+// domain.Salon field, so a search hit converts back to the same salon.
+type salonDocument struct {
+	ID          int64   `json:"id"`
+	Name        string  `json:"name"`
+	Slug        string  `json:"slug"`
+	Description *string `json:"description,omitempty"`
+
+	Address       string               `json:"address"`
+	City          string               `json:"city"`
+	State         string               `json:"state"`
+	PostalCode    string               `json:"postal_code"`
+	Country       string               `json:"country"`
+	Location      *domain.GeoPoint     `json:"location,omitempty"`
+	GeocodeStatus domain.GeocodeStatus `json:"geocode_status,omitempty"`
+
+	Phone   string `json:"phone"`
+	Email   string `json:"email"`
+	Website string `json:"website"`
+
+	CategoryID  *int64            `json:"category_id,omitempty"`
+	Category    *categoryDocument `json:"category,omitempty"`
+	PriceRange  domain.PriceRange `json:"price_range"`
+	Rating      *float64          `json:"rating,omitempty"`
+	ReviewCount int               `json:"review_count"`
+	IsActive    bool              `json:"is_active"`
+	IsVerified  bool              `json:"is_verified"`
+	CreatedAt   *time.Time        `json:"created_at,omitempty"`
+	UpdatedAt   *time.Time        `json:"updated_at,omitempty"`
+
+	Services       []serviceDocument `json:"services,omitempty"`
+	Amenities      []amenityDocument `json:"amenities,omitempty"`
+	OperatingHours []hoursDocument   `json:"operating_hours,omitempty"`
+
+	// Written by mappings before v3, read until the index is rebuilt
+	LegacyCategoryName string `json:"category_name,omitempty"`
+}
+
+type categoryDocument struct {
+	ID        int64      `json:"id"`
+	Name      string     `json:"name"`
+	Slug      string     `json:"slug"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+// serviceDocument is a service without its salon_id, which is the salon's
+type serviceDocument struct {
+	ID              int64      `json:"id"`
+	Name            string     `json:"name"`
+	Description     *string    `json:"description,omitempty"`
+	PriceMin        *float64   `json:"price_min,omitempty"`
+	PriceMax        *float64   `json:"price_max,omitempty"`
+	DurationMinutes *int       `json:"duration_minutes,omitempty"`
+	CreatedAt       *time.Time `json:"created_at,omitempty"`
+}
+
+type amenityDocument struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	Icon string `json:"icon,omitempty"`
+}
+
+// UnmarshalJSON also accepts a bare name, how amenities were indexed
+// before mapping v3
+func (a *amenityDocument) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		*a = amenityDocument{}
+		return json.Unmarshal(data, &a.Name)
+	}
+	type plain amenityDocument
+	return json.Unmarshal(data, (*plain)(a))
+}
+
+// hoursDocument is one day of operating hours without its salon_id
+type hoursDocument struct {
+	ID        int64  `json:"id"`
+	DayOfWeek int    `json:"day_of_week"`
+	OpenTime  string `json:"open_time"`
+	CloseTime string `json:"close_time"`
+	IsClosed  bool   `json:"is_closed"`
+}
+
+// newSalonDocument converts a Salon to its index document
+func newSalonDocument(salon *domain.Salon) salonDocument {
+	doc := salonDocument{
+		ID:            salon.ID,
+		Name:          salon.Name,
+		Slug:          salon.Slug,
+		Description:   salon.Description,
+		Address:       salon.Location.Address,
+		City:          salon.Location.City,
+		State:         salon.Location.State,
+		PostalCode:    salon.Location.PostalCode,
+		Country:       salon.Location.Country,
+		Location:      salon.Location.GeoPoint,
+		GeocodeStatus: salon.Location.GeocodeStatus,
+		Phone:         salon.Contact.Phone,
+		Email:         salon.Contact.Email,
+		Website:       salon.Contact.Website,
+		CategoryID:    salon.CategoryID,
+		PriceRange:    salon.PriceRange,
+		Rating:        salon.Rating,
+		ReviewCount:   salon.ReviewCount,
+		IsActive:      salon.IsActive,
+		IsVerified:    salon.IsVerified,
+		CreatedAt:     optionalTime(salon.CreatedAt),
+		UpdatedAt:     optionalTime(salon.UpdatedAt),
+	}
+
+	if c := salon.Category; c != nil {
+		doc.Category = &categoryDocument{ID: c.ID, Name: c.Name, Slug: c.Slug, CreatedAt: optionalTime(c.CreatedAt)}
+	}
+	for _, s := range salon.Services {
+		doc.Services = append(doc.Services, serviceDocument{
+			ID:              s.ID,
+			Name:            s.Name,
+			Description:     s.Description,
+			PriceMin:        s.PriceMin,
+			PriceMax:        s.PriceMax,
+			DurationMinutes: s.DurationMinutes,
+			CreatedAt:       optionalTime(s.CreatedAt),
+		})
+	}
+	for _, a := range salon.Amenities {
+		doc.Amenities = append(doc.Amenities, amenityDocument(a))
+	}
+	for _, oh := range salon.OperatingHours {
+		doc.OperatingHours = append(doc.OperatingHours, hoursDocument{
+			ID:        oh.ID,
+			DayOfWeek: oh.DayOfWeek,
+			OpenTime:  oh.OpenTime,
+			CloseTime: oh.CloseTime,
+			IsClosed:  oh.IsClosed,
+		})
+	}
+	return doc
+}
+
+// toDomain converts an index document back to a Salon
+func (d salonDocument) toDomain() domain.Salon {
+	salon := domain.Salon{
+		ID:          d.ID,
+		Name:        d.Name,
+		Slug:        d.Slug,
+		Description: d.Description,
+		Location: domain.Location{
+			Address:       d.Address,
+			City:          d.City,
+			State:         d.State,
+			PostalCode:    d.PostalCode,
+			Country:       d.Country,
+			GeoPoint:      d.Location,
+			GeocodeStatus: d.GeocodeStatus,
+		},
+		Contact: domain.Contact{
+			Phone:   d.Phone,
+			Email:   d.Email,
+			Website: d.Website,
+		},
+		CategoryID:  d.CategoryID,
+		PriceRange:  d.PriceRange,
+		Rating:      d.Rating,
+		ReviewCount: d.ReviewCount,
+		IsActive:    d.IsActive,
+		IsVerified:  d.IsVerified,
+		CreatedAt:   timeValue(d.CreatedAt),
+		UpdatedAt:   timeValue(d.UpdatedAt),
+	}
+
+	switch {
+	case d.Category != nil:
+		salon.Category = &domain.Category{
+			ID:        d.Category.ID,
+			Name:      d.Category.Name,
+			Slug:      d.Category.Slug,
+			CreatedAt: timeValue(d.Category.CreatedAt),
+		}
+	case d.LegacyCategoryName != "":
+		salon.Category = &domain.Category{Name: d.LegacyCategoryName}
+		if d.CategoryID != nil {
+			salon.Category.ID = *d.CategoryID
+		}
+	}
+	for _, s := range d.Services {
+		salon.Services = append(salon.Services, domain.Service{
+			ID:              s.ID,
+			SalonID:         d.ID,
+			Name:            s.Name,
+			Description:     s.Description,
+			PriceMin:        s.PriceMin,
+			PriceMax:        s.PriceMax,
+			DurationMinutes: s.DurationMinutes,
+			CreatedAt:       timeValue(s.CreatedAt),
+		})
+	}
+	for _, a := range d.Amenities {
+		salon.Amenities = append(salon.Amenities, domain.Amenity(a))
+	}
+	for _, oh := range d.OperatingHours {
+		salon.OperatingHours = append(salon.OperatingHours, domain.OperatingHours{
+			ID:        oh.ID,
+			SalonID:   d.ID,
+			DayOfWeek: oh.DayOfWeek,
+			OpenTime:  oh.OpenTime,
+			CloseTime: oh.CloseTime,
+			IsClosed:  oh.IsClosed,
+		})
+	}
+	return salon
+}
+
+// optionalTime leaves zero times out of the document
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func timeValue(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
+
+// pinDocument is the part of a salon document a map marker needs
+type pinDocument struct {
+	ID       int64            `json:"id"`
+	Name     string           `json:"name"`
+	Location *domain.GeoPoint `json:"location"`
+	Rating   *float64         `json:"rating"`
+}
+
+// pinSource is the _source filter that fetches a pinDocument
+var pinSource = []string{"id", "name", "location", "rating"}
+
+// toPin returns the marker, or false for a salon without coordinates
+func (d pinDocument) toPin() (domain.SalonPin, bool) {
+	if d.Location == nil {
+		return domain.SalonPin{}, false
+	}
+	return domain.SalonPin{ID: d.ID, Name: d.Name, Location: *d.Location, Rating: d.Rating}, true
+}
+
+// searchResponse is a _search response with S documents and A aggregations
+type searchResponse[S, A any] struct {
+	responseHeader
+	Hits struct {
+		Total *hitsTotal     `json:"total"` // Nil with track_total_hits=false
+		Hits  []searchHit[S] `json:"hits"`
+	} `json:"hits"`
+	Aggregations A `json:"aggregations"`
+}
+
+// total returns the number of matches, or the hits seen so far when the
+// search did not count them
+func (r *searchResponse[S, A]) total(from int) int {
+	if r.Hits.Total == nil {
+		return from + len(r.Hits.Hits)
+	}
+	return r.Hits.Total.Value
+}
+
+type hitsTotal struct {
+	Value    int    `json:"value"`
+	Relation string `json:"relation"` // "eq", or "gte" when counting stopped early
+}
+
+// searchHit is one hit of a search response
+type searchHit[S any] struct {
+	ID        string              `json:"_id"`
+	Score     *float64            `json:"_score"` // Null when sorted by a field only
+	Source    S                   `json:"_source"`
+	Sort      []sortValue         `json:"sort"`
+	Highlight map[string][]string `json:"highlight"`
+}
+
+// sortValue is one value of a hit's sort key: a number, a string
+// (keyword sorts) or null
+type sortValue struct {
+	Number *float64
+	String *string
+}
+
+// UnmarshalJSON decodes whichever type the value has
+func (v *sortValue) UnmarshalJSON(data []byte) error {
+	*v = sortValue{}
+	switch {
+	case string(data) == "null":
+		return nil
+	case len(data) > 0 && data[0] == '"':
+		return json.Unmarshal(data, &v.String)
+	default:
+		return json.Unmarshal(data, &v.Number)
+	}
+}
+
+// responseHeader holds what every search response reports about its
+// execution
+type responseHeader struct {
+	TimedOut bool       `json:"timed_out"`
+	Shards   shardStats `json:"_shards"`
+}
+
+type shardStats struct {
+	Total      int            `json:"total"`
+	Successful int            `json:"successful"`
+	Skipped    int            `json:"skipped"`
+	Failed     int            `json:"failed"`
+	Failures   []shardFailure `json:"failures"`
+}
+
+// shardFailure is why a shard failed to answer a search
+type shardFailure struct {
+	Shard  int        `json:"shard"`
+	Index  string     `json:"index"`
+	Node   string     `json:"node"`
+	Reason errorCause `json:"reason"`
+}
+
+// errorCause is an Elasticsearch error
+type errorCause struct {
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+}
+
+func (e errorCause) String() string {
+	if e.Reason == "" {
+		return e.Type
+	}
+	return e.Type + ": " + e.Reason
+}
+
+// warnings describes how a response that succeeded may be incomplete. It
+// returns an error when no shard answered, as there are no results at all.
+func (h responseHeader) warnings() ([]string, error) {
+	var warnings []string
+	if h.Shards.Failed > 0 {
+		var reasons []string
+		seen := make(map[string]bool)
+		for _, f := range h.Shards.Failures {
+			reason := fmt.Sprintf("%s[%d]: %s", f.Index, f.Shard, f.Reason)
+			if key := f.Reason.String(); !seen[key] {
+				seen[key] = true
+				reasons = append(reasons, reason)
+			}
+		}
+		if h.Shards.Successful == 0 {
+			return nil, fmt.Errorf("search failed on all %d shards: %s", h.Shards.Failed, strings.Join(reasons, "; "))
+		}
+		warnings = append(warnings, fmt.Sprintf("%d of %d shards failed, results may be incomplete", h.Shards.Failed, h.Shards.Total))
+		for _, reason := range reasons {
+			warnings = append(warnings, "shard failure: "+reason)
+		}
+	}
+	if h.TimedOut {
+		warnings = append(warnings, "search timed out, results may be incomplete")
+	}
+	return warnings, nil
+}
+
+// clusterAggregations are the aggregations of a SearchClusters response
+type clusterAggregations struct {
+	Clusters struct {
+		Buckets []struct {
+			Key      string `json:"key"`
+			DocCount int64  `json:"doc_count"`
+			Centroid struct {
+				Location domain.GeoPoint `json:"location"`
+			} `json:"centroid"`
+			TopSalon struct {
+				Hits struct {
+					Hits []searchHit[pinDocument] `json:"hits"`
+				} `json:"hits"`
+			} `json:"top_salon"`
+		} `json:"buckets"`
+	} `json:"clusters"`
+}
+
+// bulkResponse is the response of a _bulk request
+type bulkResponse struct {
+	Errors bool                     `json:"errors"`
+	Items  []map[string]bulkOutcome `json:"items"` // Keyed by action
+}
+
+type bulkOutcome struct {
+	ID     string      `json:"_id"`
+	Status int         `json:"status"`
+	Error  *errorCause `json:"error"`
+}
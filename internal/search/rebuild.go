@@ -0,0 +1,126 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"beauty-salons/internal/domain"
+	"beauty-salons/internal/logging"
+	"beauty-salons/internal/tracing"
+)
+
+// ===========================================
+// INDEX REBUILDS
+// ===========================================
+// The configured index name is an alias over a concrete index named
+// <alias>_v<mapping version>_<timestamp>. A rebuild loads a new concrete
+// index while searches keep using the old one, then points the alias at
+// the new index and deletes the old one in a single atomic _aliases
+// request. An index created under the alias name itself (before rebuilds
+// used aliases) is replaced the same way.
+
+// Rebuild creates a new index with the current mapping, fills it with load
+// and switches the alias to it, returning the new index name. On error the
+// alias still points at the old index and the new one is deleted. Salons
+// written to the old index during the load are not copied over.
+func (es *ElasticsearchClient) Rebuild(ctx context.Context, load func(index func([]domain.Salon) error) error) (string, error) {
+	target := *es
+	target.index = fmt.Sprintf("%s_v%d_%s", es.index, es.definition.version, time.Now().UTC().Format("20060102150405"))
+
+	if err := target.CreateIndex(ctx); err != nil {
+		return "", err
+	}
+	err := load(func(salons []domain.Salon) error {
+		return target.BulkIndexSalons(ctx, salons)
+	})
+	if err == nil {
+		err = es.swapAlias(ctx, target.index)
+	}
+	if err != nil {
+		// The load may have been cancelled, but the partial index must go
+		if delErr := target.DeleteIndex(context.WithoutCancel(ctx)); delErr != nil {
+			logging.FromContext(ctx).Warn("failed to delete partial index", "index", target.index, "error", delErr)
+		}
+		return "", err
+	}
+	return target.index, nil
+}
+
+// swapAlias points the alias at index and deletes the indexes it replaces
+func (es *ElasticsearchClient) swapAlias(ctx context.Context, index string) (err error) {
+	ctx, span := es.startSpan(ctx, "update_aliases")
+	defer func() { tracing.End(span, err) }()
+
+	replaced, err := es.aliasedIndices(ctx)
+	if err != nil {
+		return err
+	}
+
+	actions := []map[string]interface{}{
+		{"add": map[string]interface{}{"index": index, "alias": es.index, "is_write_index": true}},
+	}
+	for _, old := range replaced {
+		actions = append(actions, map[string]interface{}{"remove_index": map[string]interface{}{"index": old}})
+	}
+	body, _ := json.Marshal(map[string]interface{}{"actions": actions})
+
+	res, err := es.client.Indices.UpdateAliases(
+		bytes.NewReader(body),
+		es.client.Indices.UpdateAliases.WithContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to switch alias: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("failed to switch alias: %s", res.String())
+	}
+
+	logging.FromContext(ctx).Info("switched alias", "alias", es.index, "index", index, "replaced", replaced)
+	return nil
+}
+
+// aliasedIndices returns the indexes behind the alias, or the index of the
+// same name when it isn't an alias yet
+func (es *ElasticsearchClient) aliasedIndices(ctx context.Context) ([]string, error) {
+	res, err := es.client.Indices.GetAlias(
+		es.client.Indices.GetAlias.WithName(es.index),
+		es.client.Indices.GetAlias.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get alias: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusOK {
+		var indices map[string]json.RawMessage
+		if err := json.NewDecoder(res.Body).Decode(&indices); err != nil {
+			return nil, fmt.Errorf("failed to parse alias response: %w", err)
+		}
+		names := make([]string, 0, len(indices))
+		for name := range indices {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names, nil
+	}
+	if res.StatusCode != http.StatusNotFound {
+		return nil, fmt.Errorf("get alias error: %s", res.String())
+	}
+
+	exists, err := es.client.Indices.Exists([]string{es.index}, es.client.Indices.Exists.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check index existence: %w", err)
+	}
+	defer exists.Body.Close()
+	if exists.StatusCode == http.StatusOK {
+		return []string{es.index}, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,83 @@
+package search
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"beauty-salons/internal/logging"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// contextTransport copies request-scoped context onto every call to the
+// cluster: the request ID as X-Opaque-Id (visible in slow logs and tasks)
+// and the W3C trace context. With a timeout, each attempt must be done
+// within it, reading the response body included.
+type contextTransport struct {
+	base    http.RoundTripper
+	timeout time.Duration
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	// RoundTrippers must not modify the original request
+	req = req.Clone(ctx)
+	if id := logging.RequestID(ctx); id != "" && req.Header.Get("X-Opaque-Id") == "" {
+		req.Header.Set("X-Opaque-Id", id)
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	if t.timeout <= 0 {
+		return t.base.RoundTrip(req)
+	}
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	res, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	res.Body = &cancelBody{ReadCloser: res.Body, cancel: cancel}
+	return res, nil
+}
+
+// cancelBody releases the attempt's timeout once the body is closed
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// retryOnError retries failed requests unless the caller has given up
+func retryOnError(req *http.Request, _ error) bool {
+	return req.Context().Err() == nil
+}
+
+// exponentialBackoff waits base, 2*base, 4*base... up to max before each
+// retry, randomised by up to half so clients don't retry in lockstep
+func exponentialBackoff(base, max time.Duration) func(attempt int) time.Duration {
+	if base <= 0 {
+		base = DefaultRetryBackoff
+	}
+	if max <= 0 {
+		max = DefaultMaxRetryBackoff
+	}
+	return func(attempt int) time.Duration {
+		wait := base
+		for i := 1; i < attempt && wait < max; i++ {
+			wait *= 2
+		}
+		wait = min(wait, max)
+		return wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+	}
+}
@@ -0,0 +1,192 @@
+package synonyms
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ===========================================
+// SEARCH SYNONYMS
+// ===========================================
+// One rules file (Solr format: comma-separated equivalent terms per line)
+// drives both backends: Elasticsearch applies it with a synonym_graph
+// filter, PostgreSQL by expanding the query into alternatives.
+
+//go:embed salons.txt
+var defaultRules string
+
+// MaxExpansions caps how many alternative queries Expand returns
+const MaxExpansions = 8
+
+// Set is a parsed list of synonym groups
+type Set struct {
+	rules  []string     // Original lines, as sent to Elasticsearch
+	groups [][][]string // Groups of equivalent terms, each term folded into tokens
+}
+
+// Default returns the rules shipped with the application
+func Default() *Set {
+	set, err := Parse(strings.NewReader(defaultRules))
+	if err != nil {
+		panic(fmt.Sprintf("invalid embedded synonyms: %v", err))
+	}
+	return set
+}
+
+// Load reads a rules file
+func Load(path string) (*Set, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open synonyms file: %w", err)
+	}
+	defer f.Close()
+
+	set, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return set, nil
+}
+
+// Parse reads rules in Solr format. Blank lines and # comments are ignored.
+// Explicit mappings ("a => b") are not supported.
+func Parse(r io.Reader) (*Set, error) {
+	set := &Set{}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.Contains(line, "=>") {
+			return nil, fmt.Errorf("line %d: explicit mappings (=>) are not supported", n)
+		}
+
+		var group [][]string
+		for _, term := range strings.Split(line, ",") {
+			if tokens := Tokens(term); len(tokens) > 0 {
+				group = append(group, tokens)
+			}
+		}
+		if len(group) < 2 {
+			return nil, fmt.Errorf("line %d: a synonym group needs at least two terms", n)
+		}
+
+		set.rules = append(set.rules, line)
+		set.groups = append(set.groups, group)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read synonyms: %w", err)
+	}
+	return set, nil
+}
+
+// Rules returns the rule lines in Solr format
+func (s *Set) Rules() []string {
+	if s == nil {
+		return nil
+	}
+	return s.rules
+}
+
+// Len returns the number of synonym groups
+func (s *Set) Len() int {
+	if s == nil {
+		return 0
+	}
+	return len(s.groups)
+}
+
+// Expand returns alternative phrasings of query, each with one matched
+// term replaced by an equivalent. The original query is not included.
+func (s *Set) Expand(query string) []string {
+	if s == nil {
+		return nil
+	}
+
+	tokens := Tokens(query)
+	seen := map[string]bool{strings.Join(tokens, " "): true}
+	var out []string
+
+	for _, group := range s.groups {
+		// Replace the longest matching term, so "barber shop" is not also
+		// treated as "barber" followed by "shop"
+		var term []string
+		at := -1
+		for _, t := range group {
+			if i := indexOf(tokens, t); i >= 0 && len(t) > len(term) {
+				term, at = t, i
+			}
+		}
+		if at < 0 {
+			continue
+		}
+
+		for _, alt := range group {
+			expanded := make([]string, 0, len(tokens)-len(term)+len(alt))
+			expanded = append(expanded, tokens[:at]...)
+			expanded = append(expanded, alt...)
+			expanded = append(expanded, tokens[at+len(term):]...)
+
+			q := strings.Join(expanded, " ")
+			if seen[q] {
+				continue
+			}
+			seen[q] = true
+			out = append(out, q)
+			if len(out) == MaxExpansions {
+				return out
+			}
+		}
+	}
+	return out
+}
+
+// indexOf returns where term occurs as a contiguous run in tokens, or -1
+func indexOf(tokens, term []string) int {
+	for i := 0; i+len(term) <= len(tokens); i++ {
+		match := true
+		for j := range term {
+			if tokens[i+j] != term[j] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return i
+		}
+	}
+	return -1
+}
+
+// Tokens lowercases s, strips Spanish accents and splits it into words,
+// mirroring the lowercase and asciifolding filters in Elasticsearch
+func Tokens(s string) []string {
+	return strings.FieldsFunc(strings.Map(fold, strings.ToLower(s)), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	})
+}
+
+func fold(r rune) rune {
+	switch r {
+	case 'á', 'à', 'ä', 'â':
+		return 'a'
+	case 'é', 'è', 'ë', 'ê':
+		return 'e'
+	case 'í', 'ì', 'ï', 'î':
+		return 'i'
+	case 'ó', 'ò', 'ö', 'ô':
+		return 'o'
+	case 'ú', 'ù', 'ü', 'û':
+		return 'u'
+	case 'ñ':
+		return 'n'
+	case 'ç':
+		return 'c'
+	}
+	return r
+}
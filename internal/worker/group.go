@@ -0,0 +1,86 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// ===========================================
+// BACKGROUND WORKERS
+// ===========================================
+// Long-running goroutines (sync jobs, event writers, ...) are started through
+// a Group so that shutdown can drain them before the process exits.
+// - Stopping() is closed when shutdown begins: loops should flush and return
+// - the ctx passed to each worker is cancelled only when the drain deadline
+//   expires, so in-progress jobs get a chance to finish
+
+// Group tracks background goroutines and drains them on shutdown
+type Group struct {
+	ctx      context.Context
+	cancel   context.CancelFunc
+	stopping chan struct{}
+	wg       sync.WaitGroup
+
+	mu      sync.Mutex
+	stopped bool
+}
+
+// NewGroup creates an empty worker group
+func NewGroup() *Group {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Group{
+		ctx:      ctx,
+		cancel:   cancel,
+		stopping: make(chan struct{}),
+	}
+}
+
+// Go starts fn in a goroutine tracked by the group.
+// Returns false if the group is already shutting down.
+func (g *Group) Go(fn func(ctx context.Context)) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.stopped {
+		return false
+	}
+
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		fn(g.ctx)
+	}()
+	return true
+}
+
+// Stopping returns a channel that is closed when shutdown begins
+func (g *Group) Stopping() <-chan struct{} {
+	return g.stopping
+}
+
+// Shutdown asks workers to stop and waits for them to return.
+// If ctx expires first, worker contexts are cancelled and an error is returned.
+func (g *Group) Shutdown(ctx context.Context) error {
+	g.mu.Lock()
+	if !g.stopped {
+		g.stopped = true
+		close(g.stopping)
+	}
+	g.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		g.cancel()
+		return nil
+	case <-ctx.Done():
+		g.cancel()
+		return fmt.Errorf("background workers did not stop in time: %w", ctx.Err())
+	}
+}
@@ -89,14 +89,14 @@ CREATE TABLE IF NOT EXISTS operating_hours (
 -- These are B-TREE indexes (different from Elasticsearch's inverted index!)
 -- They speed up lookups but don't help with fulsl-text search.
 
-CREATE INDEX idx_salons_city ON salons(city);
-CREATE INDEX idx_salons_category ON salons(category_id);
-CREATE INDEX idx_salons_rating ON salons(rating DESC);
-CREATE INDEX idx_salons_location ON salons(latitude, longitude);
-CREATE INDEX idx_services_salon ON services(salon_id);
+CREATE INDEX IF NOT EXISTS idx_salons_city ON salons(city);
+CREATE INDEX IF NOT EXISTS idx_salons_category ON salons(category_id);
+CREATE INDEX IF NOT EXISTS idx_salons_rating ON salons(rating DESC);
+CREATE INDEX IF NOT EXISTS idx_salons_location ON salons(latitude, longitude);
+CREATE INDEX IF NOT EXISTS idx_services_salon ON services(salon_id);
 
 -- Full-text search in PostgreSQL (for comparison with Elasticsearch)
 -- This creates a GIN index with tsvector - PostgreSQL's way of doing text search
-CREATE INDEX idx_salons_search ON salons USING GIN (
+CREATE INDEX IF NOT EXISTS idx_salons_search ON salons USING GIN (
     to_tsvector('english', coalesce(name, '') || ' ' || coalesce(description, ''))
 );
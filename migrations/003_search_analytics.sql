@@ -0,0 +1,31 @@
+-- ===========================================
+-- Search Analytics
+-- ===========================================
+-- One row per answered search and one per reported click.
+-- Clicks reference their search through search_id.
+
+CREATE TABLE IF NOT EXISTS search_events (
+    id BIGSERIAL PRIMARY KEY,
+    event_type VARCHAR(10) NOT NULL CHECK (event_type IN ('search', 'click')),
+    search_id VARCHAR(64) NOT NULL,
+
+    -- Search events
+    query TEXT NOT NULL DEFAULT '',
+    normalized_query TEXT NOT NULL DEFAULT '',
+    filters JSONB NOT NULL DEFAULT '{}',
+    backend VARCHAR(20),
+    total_hits INTEGER NOT NULL DEFAULT 0,
+    latency_ms REAL NOT NULL DEFAULT 0,
+    page INTEGER NOT NULL DEFAULT 1,
+    page_size INTEGER NOT NULL DEFAULT 10,
+
+    -- Click events
+    salon_id INTEGER,
+    position INTEGER,
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_search_events_type_created ON search_events(event_type, created_at);
+CREATE INDEX IF NOT EXISTS idx_search_events_search_id ON search_events(search_id);
+CREATE INDEX IF NOT EXISTS idx_search_events_query ON search_events(normalized_query) WHERE event_type = 'search';
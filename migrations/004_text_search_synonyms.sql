@@ -0,0 +1,24 @@
+-- ===========================================
+-- TEXT SEARCH CONFIGURATION
+-- ===========================================
+-- The GIN index used 'english' while queries used 'spanish', so the index was
+-- never used. Both now use the 'salons' configuration: Spanish stemming with
+-- accents removed first, matching the Elasticsearch spanish_analyzer
+-- (lowercase, asciifolding, spanish_stemmer).
+--
+-- Synonyms are not part of the configuration: the API expands queries with
+-- the same rules file Elasticsearch uses (internal/synonyms/salons.txt).
+
+CREATE EXTENSION IF NOT EXISTS unaccent;
+
+DROP TEXT SEARCH CONFIGURATION IF EXISTS salons;
+CREATE TEXT SEARCH CONFIGURATION salons (COPY = pg_catalog.spanish);
+ALTER TEXT SEARCH CONFIGURATION salons
+    ALTER MAPPING FOR hword, hword_part, word
+    WITH unaccent, spanish_stem;
+
+-- Rebuild the full-text index with the aligned configuration
+DROP INDEX IF EXISTS idx_salons_search;
+CREATE INDEX IF NOT EXISTS idx_salons_search ON salons USING GIN (
+    to_tsvector('salons', coalesce(name, '') || ' ' || coalesce(description, ''))
+);
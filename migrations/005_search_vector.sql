@@ -0,0 +1,71 @@
+-- ===========================================
+-- POSTGRESQL SEARCH PARITY
+-- ===========================================
+-- A weighted search_vector per salon, so the PostgreSQL fallback ranks like
+-- Elasticsearch: name (A), service names (B), description (C).
+--
+-- A GENERATED column cannot read the services table, so the column is kept
+-- up to date by triggers on salons and services instead.
+-- Trigram indexes on the unaccented name give typo tolerance.
+
+CREATE EXTENSION IF NOT EXISTS unaccent;
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- unaccent() is only STABLE; this wrapper can be used in indexes
+CREATE OR REPLACE FUNCTION f_unaccent(text) RETURNS text
+LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT AS $$
+    SELECT public.unaccent('public.unaccent'::regdictionary, $1)
+$$;
+
+ALTER TABLE salons ADD COLUMN IF NOT EXISTS search_vector tsvector;
+
+-- Builds the weighted document for one salon
+CREATE OR REPLACE FUNCTION salon_search_vector(p_salon_id INTEGER, p_name TEXT, p_description TEXT)
+RETURNS tsvector LANGUAGE sql STABLE AS $$
+    SELECT setweight(to_tsvector('salons', coalesce(p_name, '')), 'A')
+        || setweight(to_tsvector('salons', coalesce(
+               (SELECT string_agg(name, ' ') FROM services WHERE salon_id = p_salon_id), '')), 'B')
+        || setweight(to_tsvector('salons', coalesce(p_description, '')), 'C')
+$$;
+
+CREATE OR REPLACE FUNCTION salons_search_vector_trigger() RETURNS trigger
+LANGUAGE plpgsql AS $$
+BEGIN
+    NEW.search_vector := salon_search_vector(NEW.id, NEW.name, NEW.description);
+    RETURN NEW;
+END
+$$;
+
+DROP TRIGGER IF EXISTS salons_search_vector_update ON salons;
+CREATE TRIGGER salons_search_vector_update
+    BEFORE INSERT OR UPDATE OF name, description ON salons
+    FOR EACH ROW EXECUTE FUNCTION salons_search_vector_trigger();
+
+-- Service changes refresh the owning salon(s)
+CREATE OR REPLACE FUNCTION services_search_vector_trigger() RETURNS trigger
+LANGUAGE plpgsql AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE salons SET search_vector = salon_search_vector(id, name, description)
+        WHERE id = OLD.salon_id;
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        UPDATE salons SET search_vector = salon_search_vector(id, name, description)
+        WHERE id = NEW.salon_id;
+    END IF;
+    RETURN NULL;
+END
+$$;
+
+DROP TRIGGER IF EXISTS services_search_vector_update ON services;
+CREATE TRIGGER services_search_vector_update
+    AFTER INSERT OR DELETE OR UPDATE OF name, salon_id ON services
+    FOR EACH ROW EXECUTE FUNCTION services_search_vector_trigger();
+
+-- Backfill existing rows
+UPDATE salons SET search_vector = salon_search_vector(id, name, description);
+
+-- The expression index from 004 is replaced by the column index
+DROP INDEX IF EXISTS idx_salons_search;
+CREATE INDEX IF NOT EXISTS idx_salons_search_vector ON salons USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_salons_name_trgm ON salons USING GIN (f_unaccent(name) gin_trgm_ops);
@@ -0,0 +1,21 @@
+-- ===========================================
+-- POSTGIS GEO SEARCH
+-- ===========================================
+-- Replaces the Haversine expression over DECIMAL latitude/longitude, which
+-- could not use an index (idx_salons_location is a btree) and could hit acos
+-- domain errors from rounding. location is derived from latitude/longitude,
+-- so writers keep setting those two columns.
+-- Requires the PostGIS extension (docker-compose uses the postgis image).
+
+CREATE EXTENSION IF NOT EXISTS postgis;
+
+ALTER TABLE salons ADD COLUMN IF NOT EXISTS location geography(Point, 4326)
+    GENERATED ALWAYS AS (
+        CASE WHEN latitude IS NOT NULL AND longitude IS NOT NULL
+             THEN ST_SetSRID(ST_MakePoint(longitude::float8, latitude::float8), 4326)::geography
+        END
+    ) STORED;
+
+-- Serves ST_DWithin radius filters and <-> nearest-first ordering
+CREATE INDEX IF NOT EXISTS idx_salons_geo ON salons USING GIST (location);
+DROP INDEX IF EXISTS idx_salons_location;
@@ -0,0 +1,8 @@
+-- ===========================================
+-- MAP VIEWPORT SEARCH
+-- ===========================================
+-- Bounding box (&&) and polygon (ST_Intersects) filters compare planar
+-- lon/lat geometry, like Elasticsearch's geo_bounding_box and geo_shape,
+-- so they need an index on the geometry cast of location.
+
+CREATE INDEX IF NOT EXISTS idx_salons_geometry ON salons USING GIST ((location::geometry));
@@ -0,0 +1,71 @@
+-- ===========================================
+-- GEOCODING
+-- ===========================================
+-- Salons without latitude/longitude never match geo searches. Their
+-- addresses are geocoded by the API's background worker or by
+-- `go run ./cmd/geocode`; geocode_status records where the coordinates of
+-- each salon came from:
+--   pending    not geocoded yet, or the address changed since
+--   ok         found by a geocoder
+--   not_found  no geocoder knows the address
+--   failed     the geocoder errored (retried by the next backfill)
+--   manual     set by whoever wrote the salon
+
+ALTER TABLE salons ADD COLUMN IF NOT EXISTS geocode_status VARCHAR(20) NOT NULL DEFAULT 'pending'
+    CHECK (geocode_status IN ('pending', 'ok', 'not_found', 'failed', 'manual'));
+ALTER TABLE salons ADD COLUMN IF NOT EXISTS geocoded_at TIMESTAMP;
+
+UPDATE salons SET geocode_status = 'manual'
+WHERE geocode_status = 'pending' AND latitude IS NOT NULL AND longitude IS NOT NULL;
+
+-- Keeps geocode_status in step with writes that don't set it themselves:
+-- coordinates written directly are manual, and a changed address needs
+-- geocoding again (unless its coordinates are manual or were edited in the
+-- same write)
+CREATE OR REPLACE FUNCTION salons_geocode_status() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        IF NEW.geocode_status = 'pending' AND NEW.latitude IS NOT NULL AND NEW.longitude IS NOT NULL THEN
+            NEW.geocode_status := 'manual';
+        END IF;
+        RETURN NEW;
+    END IF;
+
+    IF NEW.geocode_status IS DISTINCT FROM OLD.geocode_status THEN
+        RETURN NEW;
+    END IF;
+
+    IF NEW.latitude IS DISTINCT FROM OLD.latitude OR NEW.longitude IS DISTINCT FROM OLD.longitude THEN
+        NEW.geocode_status := CASE
+            WHEN NEW.latitude IS NOT NULL AND NEW.longitude IS NOT NULL THEN 'manual'
+            ELSE 'pending'
+        END;
+    ELSIF (NEW.address, NEW.city, NEW.state, NEW.postal_code, NEW.country)
+          IS DISTINCT FROM (OLD.address, OLD.city, OLD.state, OLD.postal_code, OLD.country)
+          AND NEW.geocode_status <> 'manual' THEN
+        NEW.geocode_status := 'pending';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS salons_geocode_status ON salons;
+CREATE TRIGGER salons_geocode_status
+    BEFORE INSERT OR UPDATE ON salons
+    FOR EACH ROW EXECUTE FUNCTION salons_geocode_status();
+
+-- Salons waiting for the backfill
+CREATE INDEX IF NOT EXISTS idx_salons_geocode_pending ON salons (id)
+    WHERE geocode_status IN ('pending', 'failed');
+
+-- Geocoding results by normalized address, so each address is looked up once.
+-- Not-found entries expire (GEOCODER_NOT_FOUND_TTL).
+CREATE TABLE IF NOT EXISTS geocode_cache (
+    query VARCHAR(1000) PRIMARY KEY,
+    found BOOLEAN NOT NULL,
+    latitude DOUBLE PRECISION,
+    longitude DOUBLE PRECISION,
+    display_name TEXT NOT NULL DEFAULT '',
+    provider VARCHAR(100) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
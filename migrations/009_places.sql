@@ -0,0 +1,33 @@
+-- ===========================================
+-- PLACES
+-- ===========================================
+-- Named places (cities, neighborhoods, landmarks) that the near= search
+-- parameter resolves to coordinates. Loaded with `go run ./cmd/places`
+-- from CSV or GeoJSON.
+--
+-- search_name and search_area hold the normalized (lowercase, unaccented)
+-- name and city/state/country, computed by the importer the same way the
+-- API normalizes near= values.
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE TABLE IF NOT EXISTS places (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('city', 'neighborhood', 'landmark')),
+    city VARCHAR(100) NOT NULL DEFAULT '',
+    state VARCHAR(100) NOT NULL DEFAULT '',
+    country VARCHAR(100) NOT NULL DEFAULT '',
+    latitude DECIMAL(10, 8) NOT NULL,
+    longitude DECIMAL(11, 8) NOT NULL,
+    radius_km DOUBLE PRECISION NOT NULL CHECK (radius_km >= 0),
+    search_name TEXT NOT NULL,
+    search_area TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (kind, search_name, search_area)
+);
+
+-- Typo tolerant lookups ("guemez") and qualifier filters
+CREATE INDEX IF NOT EXISTS idx_places_search_name_trgm ON places USING GIN (search_name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_places_search_area ON places USING GIN (search_area);
@@ -0,0 +1,9 @@
+-- Reverts 001_initial_schema.sql: drops every table of the base schema
+-- and their data.
+
+DROP TABLE IF EXISTS operating_hours;
+DROP TABLE IF EXISTS salon_amenities;
+DROP TABLE IF EXISTS amenities;
+DROP TABLE IF EXISTS services;
+DROP TABLE IF EXISTS salons;
+DROP TABLE IF EXISTS categories;
@@ -0,0 +1,19 @@
+-- Reverts 002_seed_data.sql. Seed salons go with their services, amenities
+-- and hours (ON DELETE CASCADE); seed categories and amenities are kept
+-- while other salons use them.
+
+DELETE FROM salons WHERE slug IN (
+    'estilo-mar', 'barberia-don-pedro', 'spa-costa-atlantica', 'nail-studio-mdp',
+    'tinta-urbana-tattoo', 'clinica-dermoestetica-luz', 'corte-express', 'zen-masajes',
+    'la-portena-barbershop', 'belleza-total', 'nails-and-co', 'peluqueria-francesa',
+    'caballeros-vip', 'centro-estetico-brillo', 'eco-hair-studio'
+);
+
+DELETE FROM amenities a
+WHERE a.icon IN ('wifi', 'parking', 'accessible', 'calendar', 'credit-card', 'walk-in', 'room', 'coffee')
+  AND NOT EXISTS (SELECT 1 FROM salon_amenities sa WHERE sa.amenity_id = a.id);
+
+DELETE FROM categories c
+WHERE c.slug IN ('hair-salon', 'barbershop', 'nail-salon', 'spa', 'beauty-salon',
+                 'skincare-clinic', 'massage-therapy', 'tattoo-piercing')
+  AND NOT EXISTS (SELECT 1 FROM salons s WHERE s.category_id = c.id);
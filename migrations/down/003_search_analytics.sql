@@ -0,0 +1,3 @@
+-- Reverts 003_search_analytics.sql, deleting all recorded search events.
+
+DROP TABLE IF EXISTS search_events;
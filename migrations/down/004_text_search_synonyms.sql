@@ -0,0 +1,9 @@
+-- Reverts 004_text_search_synonyms.sql: back to the 'english' index from
+-- 001. The unaccent extension is kept, as other objects may use it.
+
+DROP INDEX IF EXISTS idx_salons_search;
+DROP TEXT SEARCH CONFIGURATION IF EXISTS salons;
+
+CREATE INDEX IF NOT EXISTS idx_salons_search ON salons USING GIN (
+    to_tsvector('english', coalesce(name, '') || ' ' || coalesce(description, ''))
+);
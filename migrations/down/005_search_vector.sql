@@ -0,0 +1,18 @@
+-- Reverts 005_search_vector.sql: drops search_vector with its triggers and
+-- restores the expression index from 004.
+
+DROP INDEX IF EXISTS idx_salons_name_trgm;
+DROP INDEX IF EXISTS idx_salons_search_vector;
+
+DROP TRIGGER IF EXISTS services_search_vector_update ON services;
+DROP TRIGGER IF EXISTS salons_search_vector_update ON salons;
+DROP FUNCTION IF EXISTS services_search_vector_trigger();
+DROP FUNCTION IF EXISTS salons_search_vector_trigger();
+DROP FUNCTION IF EXISTS salon_search_vector(INTEGER, TEXT, TEXT);
+DROP FUNCTION IF EXISTS f_unaccent(text);
+
+ALTER TABLE salons DROP COLUMN IF EXISTS search_vector;
+
+CREATE INDEX IF NOT EXISTS idx_salons_search ON salons USING GIN (
+    to_tsvector('salons', coalesce(name, '') || ' ' || coalesce(description, ''))
+);
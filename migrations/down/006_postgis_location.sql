@@ -0,0 +1,7 @@
+-- Reverts 006_postgis_location.sql. The postgis extension is kept, as
+-- other objects may use it.
+
+DROP INDEX IF EXISTS idx_salons_geo;
+ALTER TABLE salons DROP COLUMN IF EXISTS location;
+
+CREATE INDEX IF NOT EXISTS idx_salons_location ON salons(latitude, longitude);
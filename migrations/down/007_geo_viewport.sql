@@ -0,0 +1,3 @@
+-- Reverts 007_geo_viewport.sql.
+
+DROP INDEX IF EXISTS idx_salons_geometry;
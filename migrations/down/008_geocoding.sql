@@ -0,0 +1,11 @@
+-- Reverts 008_geocoding.sql, deleting the geocoding cache. Coordinates
+-- found by geocoders stay in latitude/longitude.
+
+DROP TABLE IF EXISTS geocode_cache;
+DROP INDEX IF EXISTS idx_salons_geocode_pending;
+
+DROP TRIGGER IF EXISTS salons_geocode_status ON salons;
+DROP FUNCTION IF EXISTS salons_geocode_status();
+
+ALTER TABLE salons DROP COLUMN IF EXISTS geocoded_at;
+ALTER TABLE salons DROP COLUMN IF EXISTS geocode_status;
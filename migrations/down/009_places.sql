@@ -0,0 +1,3 @@
+-- Reverts 009_places.sql, deleting all imported places.
+
+DROP TABLE IF EXISTS places;
@@ -0,0 +1,12 @@
+// Package migrations embeds the database schema migrations, so the API and
+// cmd/migrate can apply them without the files on disk (see
+// internal/migrate). Down migrations live in down/, under the name of the
+// migration they revert.
+package migrations
+
+import "embed"
+
+// FS holds the up migrations at its root and the down migrations in down/
+//
+//go:embed *.sql down/*.sql
+var FS embed.FS
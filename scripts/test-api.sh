@@ -3,6 +3,7 @@
 # Test script for the Beauty Salons Search API
 
 BASE_URL="http://localhost:8080/api/v1"
+ADMIN_API_KEY="${ADMIN_API_KEY:-dev-admin-key}"
 
 echo "========================================"
 echo "Beauty Salons Search API - Test Suite"
@@ -18,7 +19,7 @@ echo ""
 # Sync data
 echo "2. Sync Data to Elasticsearch"
 echo "------------------------------"
-curl -s -X POST "$BASE_URL/admin/sync" | jq .
+curl -s -X POST -H "X-API-Key: $ADMIN_API_KEY" "$BASE_URL/admin/sync" | jq .
 echo ""
 
 # Wait for indexing
@@ -73,7 +74,7 @@ echo ""
 # Cluster health
 echo "10. Elasticsearch Cluster Health"
 echo "---------------------------------"
-curl -s "$BASE_URL/admin/cluster/health" | jq '{cluster_name, status, number_of_nodes}'
+curl -s -H "X-API-Key: $ADMIN_API_KEY" "$BASE_URL/admin/cluster/health" | jq '{cluster_name, status, number_of_nodes}'
 echo ""
 
 echo "========================================"
@@ -0,0 +1,207 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"beauty-salons/internal/analytics"
+	"beauty-salons/internal/api/handlers"
+	"beauty-salons/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeEventStore records the batches it is asked to insert
+type fakeEventStore struct {
+	mu      sync.Mutex
+	batches [][]domain.SearchEvent
+}
+
+func (s *fakeEventStore) InsertSearchEvents(_ context.Context, events []domain.SearchEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batches = append(s.batches, append([]domain.SearchEvent(nil), events...))
+	return nil
+}
+
+func (s *fakeEventStore) total() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, b := range s.batches {
+		n += len(b)
+	}
+	return n
+}
+
+func TestRecorder_BatchesAndFlushesOnStop(t *testing.T) {
+	store := &fakeEventStore{}
+	rec := analytics.NewRecorder(store, analytics.Config{BufferSize: 10, BatchSize: 3, FlushInterval: time.Hour})
+
+	for i := 0; i < 7; i++ {
+		if !rec.Record(domain.SearchEvent{Type: domain.EventSearch, SearchID: "s"}) {
+			t.Fatalf("event %d should fit in the buffer", i+1)
+		}
+	}
+
+	stopping := make(chan struct{})
+	close(stopping)
+	rec.Run(context.Background(), stopping)
+
+	if got := store.total(); got != 7 {
+		t.Fatalf("wrote %d events, want 7", got)
+	}
+	for i, b := range store.batches {
+		if len(b) > 3 {
+			t.Errorf("batch %d has %d events, want at most 3", i, len(b))
+		}
+		for _, e := range b {
+			if e.CreatedAt.IsZero() {
+				t.Error("Record should stamp CreatedAt")
+			}
+		}
+	}
+}
+
+func TestRecorder_FlushInterval(t *testing.T) {
+	store := &fakeEventStore{}
+	rec := analytics.NewRecorder(store, analytics.Config{BufferSize: 10, BatchSize: 100, FlushInterval: 20 * time.Millisecond})
+
+	stopping := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		rec.Run(context.Background(), stopping)
+		close(done)
+	}()
+
+	rec.Record(domain.SearchEvent{Type: domain.EventSearch})
+
+	deadline := time.Now().Add(time.Second)
+	for store.total() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if store.total() != 1 {
+		t.Error("partial batch should be written after the flush interval")
+	}
+
+	close(stopping)
+	<-done
+}
+
+func TestRecorder_DropsWhenFull(t *testing.T) {
+	rec := analytics.NewRecorder(&fakeEventStore{}, analytics.Config{BufferSize: 2, BatchSize: 1, FlushInterval: time.Hour})
+
+	rec.Record(domain.SearchEvent{})
+	rec.Record(domain.SearchEvent{})
+	if rec.Record(domain.SearchEvent{}) {
+		t.Error("Record should drop events when the buffer is full")
+	}
+
+	var disabled *analytics.Recorder
+	if disabled.Record(domain.SearchEvent{}) {
+		t.Error("a nil recorder should drop events")
+	}
+}
+
+func TestParseWindow(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"24h", 24 * time.Hour, false},
+		{"7d", 7 * 24 * time.Hour, false},
+		{"90m", 90 * time.Minute, false},
+		{"365d", analytics.MaxWindow, false},
+		{"366d", 0, true},
+		{"0d", 0, true},
+		{"-1h", 0, true},
+		{"week", 0, true},
+		{"1.5d", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := analytics.ParseWindow(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseWindow(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseWindow(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeQuery(t *testing.T) {
+	if got := domain.NormalizeQuery("  Corte   de PELO "); got != "corte de pelo" {
+		t.Errorf("NormalizeQuery = %q, want %q", got, "corte de pelo")
+	}
+}
+
+func TestSearchParamsFilters(t *testing.T) {
+	category := int64(3)
+	rating := 4.5
+	params := domain.SalonSearchParams{
+		Query:      "uñas",
+		City:       "Mar del Plata",
+		CategoryID: &category,
+		MinRating:  &rating,
+		SortBy:     domain.SortByRelevance,
+	}
+
+	filters := params.Filters()
+	want := map[string]string{"city": "Mar del Plata", "category": "3", "min_rating": "4.5"}
+	if len(filters) != len(want) {
+		t.Fatalf("Filters() = %v, want %v", filters, want)
+	}
+	for k, v := range want {
+		if filters[k] != v {
+			t.Errorf("Filters()[%q] = %q, want %q", k, filters[k], v)
+		}
+	}
+}
+
+func TestRecordClick(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store := &fakeEventStore{}
+	rec := analytics.NewRecorder(store, analytics.Config{BufferSize: 10, BatchSize: 10, FlushInterval: time.Hour})
+
+	post := func(h *handlers.Handler, body string) int {
+		r := gin.New()
+		r.POST("/search/click", h.RecordClick)
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/search/click", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	h := handlers.NewHandler(nil, nil, handlers.WithAnalytics(rec))
+	if code := post(h, `{"search_id":"abc","salon_id":42,"position":3}`); code != http.StatusAccepted {
+		t.Errorf("valid click: status = %d, want 202", code)
+	}
+	if code := post(h, `{"search_id":"abc","salon_id":42,"position":0}`); code != http.StatusBadRequest {
+		t.Errorf("invalid position: status = %d, want 400", code)
+	}
+	if code := post(handlers.NewHandler(nil, nil), `{"search_id":"abc","salon_id":42,"position":3}`); code != http.StatusServiceUnavailable {
+		t.Errorf("analytics disabled: status = %d, want 503", code)
+	}
+
+	stopping := make(chan struct{})
+	close(stopping)
+	rec.Run(context.Background(), stopping)
+
+	if len(store.batches) != 1 || len(store.batches[0]) != 1 {
+		t.Fatalf("expected one recorded click, got %v", store.batches)
+	}
+	e := store.batches[0][0]
+	if e.Type != domain.EventClick || e.SearchID != "abc" || *e.SalonID != 42 || *e.Position != 3 {
+		t.Errorf("unexpected click event: %+v", e)
+	}
+}
@@ -0,0 +1,252 @@
+package unit
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"beauty-salons/internal/api/middleware"
+	"beauty-salons/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+const testJWTSecret = "0123456789abcdef0123456789abcdef"
+
+func signHS256(t *testing.T, secret string, claims map[string]interface{}) string {
+	t.Helper()
+	signing := jwtSigningInput(t, map[string]string{"alg": "HS256", "typ": "JWT"}, claims)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signing))
+	return signing + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	t.Helper()
+	signing := jwtSigningInput(t, map[string]string{"alg": "RS256", "typ": "JWT", "kid": kid}, claims)
+	digest := sha256.Sum256([]byte(signing))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signing + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func jwtSigningInput(t *testing.T, header map[string]string, claims map[string]interface{}) string {
+	t.Helper()
+	h, err := json.Marshal(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+}
+
+func bearerRequest(token string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req
+}
+
+func TestAPIKeyAuthenticator(t *testing.T) {
+	authn := auth.NewAPIKeyAuthenticator([]auth.APIKey{
+		{Key: "admin-key", Subject: "ops", Roles: []auth.Role{auth.RoleAdmin}},
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(auth.APIKeyHeader, "admin-key")
+	p, err := authn.Authenticate(req)
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if p.Subject != "ops" || !p.HasRole(auth.RoleAdmin) {
+		t.Errorf("Authenticate() = %+v, want admin ops", p)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "ApiKey admin-key")
+	if _, err := authn.Authenticate(req); err != nil {
+		t.Errorf("Authorization: ApiKey should be accepted, got %v", err)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(auth.APIKeyHeader, "wrong")
+	if _, err := authn.Authenticate(req); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Errorf("wrong key error = %v, want ErrInvalidCredentials", err)
+	}
+
+	if _, err := authn.Authenticate(httptest.NewRequest(http.MethodGet, "/", nil)); !errors.Is(err, auth.ErrNoCredentials) {
+		t.Errorf("no key error = %v, want ErrNoCredentials", err)
+	}
+}
+
+func TestJWTAuthenticator_HS256(t *testing.T) {
+	authn, err := auth.NewJWTAuthenticator(auth.JWTConfig{Secret: testJWTSecret, Issuer: "tests", Audience: "beauty-salons"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().Unix()
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{
+			name: "valid token",
+			token: signHS256(t, testJWTSecret, map[string]interface{}{
+				"sub": "owner-1", "iss": "tests", "aud": []string{"beauty-salons"}, "exp": now + 60,
+				"roles": []string{"salon_owner"}, "salon_ids": []int64{7},
+			}),
+		},
+		{
+			name:    "expired",
+			token:   signHS256(t, testJWTSecret, map[string]interface{}{"sub": "a", "iss": "tests", "aud": "beauty-salons", "exp": now - 3600}),
+			wantErr: true,
+		},
+		{
+			name:    "wrong secret",
+			token:   signHS256(t, "another-secret-another-secret-xx", map[string]interface{}{"sub": "a", "iss": "tests", "aud": "beauty-salons", "exp": now + 60}),
+			wantErr: true,
+		},
+		{
+			name:    "wrong audience",
+			token:   signHS256(t, testJWTSecret, map[string]interface{}{"sub": "a", "iss": "tests", "aud": "other", "exp": now + 60}),
+			wantErr: true,
+		},
+		{
+			name:    "missing expiry",
+			token:   signHS256(t, testJWTSecret, map[string]interface{}{"sub": "a", "iss": "tests", "aud": "beauty-salons"}),
+			wantErr: true,
+		},
+		{
+			name:    "malformed",
+			token:   "not-a-jwt",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := authn.Authenticate(bearerRequest(tt.token))
+			if tt.wantErr {
+				if !errors.Is(err, auth.ErrInvalidCredentials) {
+					t.Errorf("Authenticate() error = %v, want ErrInvalidCredentials", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Authenticate() error = %v", err)
+			}
+			if !p.CanManageSalon(7) || p.CanManageSalon(8) {
+				t.Errorf("salon_owner of 7 should manage only salon 7: %+v", p)
+			}
+		})
+	}
+}
+
+func TestJWTAuthenticator_RS256WithJWKS(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwks := map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "key-1",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}},
+	}
+	data, _ := json.Marshal(jwks)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	authn, err := auth.NewJWTAuthenticator(auth.JWTConfig{JWKSFile: path})
+	if err != nil {
+		t.Fatalf("NewJWTAuthenticator() error = %v", err)
+	}
+
+	claims := map[string]interface{}{"sub": "ops", "exp": time.Now().Add(time.Minute).Unix(), "roles": []string{"admin"}}
+
+	p, err := authn.Authenticate(bearerRequest(signRS256(t, key, "key-1", claims)))
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if !p.HasRole(auth.RoleAdmin) {
+		t.Errorf("Roles = %v, want admin", p.Roles)
+	}
+
+	if _, err := authn.Authenticate(bearerRequest(signRS256(t, key, "unknown", claims))); err == nil {
+		t.Error("token with an unknown kid should be rejected")
+	}
+
+	// HS256 must not be accepted when only RSA keys are configured
+	if _, err := authn.Authenticate(bearerRequest(signHS256(t, testJWTSecret, claims))); err == nil {
+		t.Error("HS256 token should be rejected without a shared secret")
+	}
+}
+
+func TestRequireRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	authn := auth.NewAPIKeyAuthenticator([]auth.APIKey{
+		{Key: "admin-key", Subject: "ops", Roles: []auth.Role{auth.RoleAdmin}},
+		{Key: "owner-key", Subject: "owner", Roles: []auth.Role{auth.RoleSalonOwner}},
+	})
+
+	r := gin.New()
+	r.Use(middleware.Authenticate(authn))
+	r.POST("/admin/sync", middleware.RequireRole(auth.RoleAdmin), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"subject": middleware.CurrentPrincipal(c).Subject})
+	})
+
+	tests := []struct {
+		name       string
+		key        string
+		wantStatus int
+	}{
+		{"anonymous", "", http.StatusUnauthorized},
+		{"invalid key", "nope", http.StatusUnauthorized},
+		{"salon owner", "owner-key", http.StatusForbidden},
+		{"admin", "admin-key", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/admin/sync", nil)
+			if tt.key != "" {
+				req.Header.Set(auth.APIKeyHeader, tt.key)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d (body %s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if w.Code == http.StatusUnauthorized && !strings.Contains(w.Header().Get("WWW-Authenticate"), "Bearer") {
+				t.Error("401 responses should include WWW-Authenticate")
+			}
+		})
+	}
+}
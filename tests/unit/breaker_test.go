@@ -0,0 +1,88 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"beauty-salons/internal/search"
+)
+
+func TestCircuitBreaker_OpensAfterThreshold(t *testing.T) {
+	b := search.NewCircuitBreaker(3, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		if !b.Allow() {
+			t.Fatalf("Allow() = false after %d failures, want true", i)
+		}
+		b.RecordFailure()
+	}
+	if b.State() != search.BreakerClosed {
+		t.Errorf("State() = %v, want %v", b.State(), search.BreakerClosed)
+	}
+
+	b.RecordFailure()
+	if b.State() != search.BreakerOpen {
+		t.Errorf("State() = %v, want %v", b.State(), search.BreakerOpen)
+	}
+	if b.Allow() {
+		t.Error("Allow() = true while open, want false")
+	}
+}
+
+func TestCircuitBreaker_SuccessResetsFailures(t *testing.T) {
+	b := search.NewCircuitBreaker(2, time.Minute)
+
+	b.RecordFailure()
+	b.RecordSuccess()
+	b.RecordFailure()
+
+	if b.State() != search.BreakerClosed {
+		t.Errorf("State() = %v, want %v", b.State(), search.BreakerClosed)
+	}
+}
+
+func TestCircuitBreaker_HalfOpenTrial(t *testing.T) {
+	b := search.NewCircuitBreaker(1, 10*time.Millisecond)
+	b.RecordFailure()
+
+	time.Sleep(20 * time.Millisecond)
+
+	if b.State() != search.BreakerHalfOpen {
+		t.Errorf("State() = %v, want %v", b.State(), search.BreakerHalfOpen)
+	}
+	if !b.Allow() {
+		t.Fatal("Allow() = false after cooldown, want true")
+	}
+	if b.Allow() {
+		t.Error("Allow() = true for a second concurrent trial, want false")
+	}
+
+	// A failed trial re-opens the breaker
+	b.RecordFailure()
+	if b.Allow() {
+		t.Error("Allow() = true after failed trial, want false")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if !b.Allow() {
+		t.Fatal("Allow() = false after second cooldown, want true")
+	}
+	b.RecordSuccess()
+	if b.State() != search.BreakerClosed {
+		t.Errorf("State() = %v, want %v", b.State(), search.BreakerClosed)
+	}
+}
+
+func TestCircuitBreaker_ReleaseFreesTrial(t *testing.T) {
+	b := search.NewCircuitBreaker(1, 10*time.Millisecond)
+	b.RecordFailure()
+	time.Sleep(20 * time.Millisecond)
+
+	if !b.Allow() {
+		t.Fatal("Allow() = false after cooldown, want true")
+	}
+	b.Release()
+	if !b.Allow() {
+		t.Error("Allow() = false after Release, want true")
+	}
+}
@@ -0,0 +1,153 @@
+package unit
+
+import (
+	"reflect"
+	"testing"
+
+	"beauty-salons/internal/domain"
+)
+
+func TestCompareRankings(t *testing.T) {
+	tests := []struct {
+		name        string
+		a           []int64
+		b           []int64
+		k           int
+		wantOverlap float64
+		wantTau     *float64
+		wantOnlyA   []int64
+		wantOnlyB   []int64
+	}{
+		{
+			name:        "identical rankings",
+			a:           []int64{1, 2, 3, 4},
+			b:           []int64{1, 2, 3, 4},
+			k:           4,
+			wantOverlap: 1,
+			wantTau:     floatPtr(1),
+			wantOnlyA:   []int64{},
+			wantOnlyB:   []int64{},
+		},
+		{
+			name:        "reversed rankings",
+			a:           []int64{1, 2, 3, 4},
+			b:           []int64{4, 3, 2, 1},
+			k:           4,
+			wantOverlap: 1,
+			wantTau:     floatPtr(-1),
+			wantOnlyA:   []int64{},
+			wantOnlyB:   []int64{},
+		},
+		{
+			name:        "partial overlap",
+			a:           []int64{1, 2, 3, 4},
+			b:           []int64{2, 1, 5, 6},
+			k:           4,
+			wantOverlap: 0.5,
+			wantTau:     floatPtr(-1),
+			wantOnlyA:   []int64{3, 4},
+			wantOnlyB:   []int64{5, 6},
+		},
+		{
+			name:        "k truncates lists",
+			a:           []int64{1, 2, 3, 4},
+			b:           []int64{1, 2, 5, 6},
+			k:           2,
+			wantOverlap: 1,
+			wantTau:     floatPtr(1),
+			wantOnlyA:   []int64{},
+			wantOnlyB:   []int64{},
+		},
+		{
+			name:        "one backend empty",
+			a:           []int64{1, 2},
+			b:           []int64{},
+			k:           10,
+			wantOverlap: 0,
+			wantTau:     nil,
+			wantOnlyA:   []int64{1, 2},
+			wantOnlyB:   []int64{},
+		},
+		{
+			name:        "both empty",
+			a:           []int64{},
+			b:           []int64{},
+			k:           10,
+			wantOverlap: 1,
+			wantTau:     nil,
+			wantOnlyA:   []int64{},
+			wantOnlyB:   []int64{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := domain.CompareRankings(tt.a, tt.b, tt.k)
+
+			if got.OverlapAtK != tt.wantOverlap {
+				t.Errorf("OverlapAtK = %v, want %v", got.OverlapAtK, tt.wantOverlap)
+			}
+			if (got.KendallTau == nil) != (tt.wantTau == nil) {
+				t.Fatalf("KendallTau = %v, want %v", got.KendallTau, tt.wantTau)
+			}
+			if got.KendallTau != nil && *got.KendallTau != *tt.wantTau {
+				t.Errorf("KendallTau = %v, want %v", *got.KendallTau, *tt.wantTau)
+			}
+			if !reflect.DeepEqual(got.OnlyInA, tt.wantOnlyA) {
+				t.Errorf("OnlyInA = %v, want %v", got.OnlyInA, tt.wantOnlyA)
+			}
+			if !reflect.DeepEqual(got.OnlyInB, tt.wantOnlyB) {
+				t.Errorf("OnlyInB = %v, want %v", got.OnlyInB, tt.wantOnlyB)
+			}
+		})
+	}
+}
+
+func TestNewSearchComparison(t *testing.T) {
+	es := domain.BackendResult{
+		Backend: "elasticsearch",
+		Results: []domain.SalonSearchResult{{Salon: domain.Salon{ID: 1}}, {Salon: domain.Salon{ID: 2}}},
+		Total:   2,
+	}
+	pg := domain.BackendResult{
+		Backend: "postgresql",
+		Results: []domain.SalonSearchResult{{Salon: domain.Salon{ID: 2}}, {Salon: domain.Salon{ID: 3}}},
+		Total:   2,
+	}
+
+	cmp := domain.NewSearchComparison(es, pg, domain.SalonSearchParams{Query: "spa"}, 2)
+
+	if cmp.Query != "spa" {
+		t.Errorf("Query = %v, want spa", cmp.Query)
+	}
+	if cmp.OverlapAtK != 0.5 {
+		t.Errorf("OverlapAtK = %v, want 0.5", cmp.OverlapAtK)
+	}
+	if !reflect.DeepEqual(cmp.OnlyInElasticsearch, []int64{1}) {
+		t.Errorf("OnlyInElasticsearch = %v, want [1]", cmp.OnlyInElasticsearch)
+	}
+	if !reflect.DeepEqual(cmp.OnlyInPostgres, []int64{3}) {
+		t.Errorf("OnlyInPostgres = %v, want [3]", cmp.OnlyInPostgres)
+	}
+}
+
+func TestNewSearchComparison_KBoundedByPage(t *testing.T) {
+	page := make([]domain.SalonSearchResult, 20)
+	for i := range page {
+		page[i].Salon.ID = int64(i + 1)
+	}
+	es := domain.BackendResult{Backend: "elasticsearch", Results: page}
+	pg := domain.BackendResult{Backend: "postgresql", Results: page[:10]}
+	params := domain.SalonSearchParams{Page: 1, PageSize: 20}
+
+	// Only 20 results were fetched, so overlap@50 would be overlap@20
+	cmp := domain.NewSearchComparison(es, pg, params, 50)
+	if cmp.K != 20 || cmp.OverlapAtK != 0.5 {
+		t.Errorf("k = %d, overlap = %v, want overlap@20 = 0.5", cmp.K, cmp.OverlapAtK)
+	}
+
+	cmp = domain.NewSearchComparison(es, pg, params, 5)
+	if cmp.K != 5 || cmp.OverlapAtK != 1 {
+		t.Errorf("k = %d, overlap = %v, want overlap@5 = 1", cmp.K, cmp.OverlapAtK)
+	}
+}
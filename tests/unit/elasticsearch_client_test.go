@@ -0,0 +1,147 @@
+package unit
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"beauty-salons/internal/domain"
+	"beauty-salons/internal/search"
+)
+
+// flakyCluster answers the first failures requests with status, then
+// succeeds, counting requests
+func flakyCluster(t *testing.T, failures int32, status int, cfg search.Config) (*search.ElasticsearchClient, *int32) {
+	t.Helper()
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		w.Header().Set("Content-Type", "application/json")
+		if atomic.AddInt32(&calls, 1) <= failures {
+			w.WriteHeader(status)
+			return
+		}
+		_, _ = w.Write([]byte(`{"count": 7}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg.Addresses = []string{srv.URL}
+	es, err := search.NewElasticsearchClient(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(es.Close)
+	return es, &calls
+}
+
+func TestElasticsearchClient_Retries(t *testing.T) {
+	retrying := search.Config{MaxRetries: 3, RetryBackoff: time.Millisecond, MaxRetryBackoff: 2 * time.Millisecond}
+
+	for _, status := range []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout} {
+		es, calls := flakyCluster(t, 2, status, retrying)
+		if n, err := es.CountDocuments(context.Background()); err != nil || n != 7 {
+			t.Errorf("status %d: CountDocuments() = %d, %v, want it retried", status, n, err)
+		}
+		if *calls != 3 {
+			t.Errorf("status %d: %d requests, want 3", status, *calls)
+		}
+	}
+
+	// Other errors are not retried, nor is anything with retries disabled
+	es, calls := flakyCluster(t, 1, http.StatusBadRequest, retrying)
+	if _, err := es.CountDocuments(context.Background()); err == nil || *calls != 1 {
+		t.Errorf("400: %d requests, err %v, want one failed request", *calls, err)
+	}
+	es, calls = flakyCluster(t, 1, http.StatusServiceUnavailable, search.Config{})
+	if _, err := es.CountDocuments(context.Background()); err == nil || *calls != 1 {
+		t.Errorf("MaxRetries 0: %d requests, err %v, want one failed request", *calls, err)
+	}
+}
+
+func TestElasticsearchClient_APIKeyAndCompression(t *testing.T) {
+	var auth, encoding, body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth, encoding = r.Header.Get("Authorization"), r.Header.Get("Content-Encoding")
+		if zr, err := gzip.NewReader(r.Body); err == nil {
+			raw, _ := io.ReadAll(zr)
+			body = string(raw)
+		}
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"hits":{"total":{"value":0},"hits":[]}}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	es, err := search.NewElasticsearchClient(search.Config{Addresses: []string{srv.URL}, APIKey: "aWQ6a2V5", Compress: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(es.Close)
+
+	if _, _, _, err := es.SearchPins(context.Background(), domain.SalonSearchParams{Page: 1, PageSize: 10}, 10); err != nil {
+		t.Fatalf("SearchPins() error = %v", err)
+	}
+	if auth != "APIKey aWQ6a2V5" {
+		t.Errorf("Authorization = %q, want the API key", auth)
+	}
+	if encoding != "gzip" || body == "" {
+		t.Errorf("Content-Encoding = %q, body %q, want a gzipped query", encoding, body)
+	}
+}
+
+func TestElasticsearchClient_RequestTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	es, err := search.NewElasticsearchClient(search.Config{Addresses: []string{srv.URL}, RequestTimeout: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(es.Close)
+
+	start := time.Now()
+	if err := es.Ping(context.Background()); err == nil {
+		t.Fatal("Ping() should time out")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Ping() took %v, want it cut off by the request timeout", elapsed)
+	}
+}
+
+func TestElasticsearchClient_RequestTimeoutStalledBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"hits": {"hits": [`))
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	es, err := search.NewElasticsearchClient(search.Config{Addresses: []string{srv.URL}, RequestTimeout: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(es.Close)
+
+	start := time.Now()
+	if _, _, _, err := es.Search(context.Background(), domain.SalonSearchParams{Page: 1, PageSize: 10}); err == nil {
+		t.Fatal("Search() should time out reading the body")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Search() took %v, want the stalled body cut off by the request timeout", elapsed)
+	}
+}
@@ -0,0 +1,232 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"beauty-salons/internal/api/handlers"
+	"beauty-salons/internal/domain"
+	"beauty-salons/internal/export"
+	"beauty-salons/internal/importer"
+
+	"github.com/gin-gonic/gin"
+)
+
+func exportSalon() domain.Salon {
+	desc := "Cortes y color"
+	rating := 4.5
+	priceMin, priceMax, minutes := 6000.0, 9000.0, 30
+	hours := []domain.OperatingHours{{DayOfWeek: 0, IsClosed: true}}
+	for day := 1; day <= 5; day++ {
+		hours = append(hours, domain.OperatingHours{DayOfWeek: day, OpenTime: "10:00:00", CloseTime: "20:00:00"})
+	}
+	hours = append(hours, domain.OperatingHours{DayOfWeek: 6, OpenTime: "10:00:00", CloseTime: "14:30:00"})
+
+	return domain.Salon{
+		ID:          7,
+		Name:        "Bulevar, Barbería",
+		Slug:        "bulevar-barberia-rosario",
+		Description: &desc,
+		Location: domain.Location{
+			Address:  "Bv. Oroño 1200",
+			City:     "Rosario",
+			GeoPoint: &domain.GeoPoint{Latitude: -32.948, Longitude: -60.656},
+		},
+		Category:    &domain.Category{ID: 2, Name: "Barbería", Slug: "barbershop"},
+		PriceRange:  domain.PriceModerate,
+		Rating:      &rating,
+		ReviewCount: 12,
+		IsActive:    true,
+		Services: []domain.Service{
+			{Name: "Corte", PriceMin: &priceMin, PriceMax: &priceMax, DurationMinutes: &minutes},
+			{Name: "Barba", PriceMax: &priceMin},
+		},
+		Amenities:      []domain.Amenity{{Name: "WiFi"}, {Name: "Acepta Tarjetas"}},
+		OperatingHours: hours,
+	}
+}
+
+func TestNewRecord_FormatsCSVCells(t *testing.T) {
+	record := importer.NewRecord(&domain.Salon{Name: "X", IsActive: true, OperatingHours: exportSalon().OperatingHours})
+	row := record.CSVRow()
+	cells := map[string]string{}
+	for i, col := range importer.CSVHeader() {
+		cells[col] = row[i]
+	}
+	if got := cells["hours"]; got != "0=closed|1-5=10:00-20:00|6=10:00-14:30" {
+		t.Errorf("hours = %q", got)
+	}
+	if cells["services"] != "" || cells["latitude"] != "" || cells["is_active"] != "true" {
+		t.Errorf("cells = %v", cells)
+	}
+}
+
+func TestExport_CSVRoundTrip(t *testing.T) {
+	salon := exportSalon()
+	var buf bytes.Buffer
+	w, err := export.NewWriter(&buf, export.FormatCSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write(&salon); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := importer.ParseCSV(&buf)
+	if err != nil || len(rows) != 1 || rows[0].Err != nil {
+		t.Fatalf("ParseCSV() = %+v, %v", rows, err)
+	}
+	got := rows[0].Salon
+	if got.Name != salon.Name || got.Slug != salon.Slug || *got.Description != *salon.Description ||
+		got.Category.Slug != "barbershop" || *got.Rating != 4.5 || got.ReviewCount != 12 {
+		t.Errorf("salon = %+v", got)
+	}
+	if got.Location.GeoPoint == nil || *got.Location.GeoPoint != *salon.Location.GeoPoint {
+		t.Errorf("GeoPoint = %v", got.Location.GeoPoint)
+	}
+	if len(got.Services) != 2 || *got.Services[0].PriceMax != 9000 || got.Services[1].PriceMin != nil || *got.Services[1].PriceMax != 6000 {
+		t.Errorf("Services = %+v", got.Services)
+	}
+	if len(got.Amenities) != 2 || len(got.OperatingHours) != 7 || got.OperatingHours[6].CloseTime != "14:30:00" {
+		t.Errorf("Amenities = %+v, OperatingHours = %+v", got.Amenities, got.OperatingHours)
+	}
+}
+
+func TestExport_NDJSON(t *testing.T) {
+	salons := []domain.Salon{exportSalon(), {ID: 8, Name: "Vacío", Slug: "vacio", IsActive: true}}
+	var buf bytes.Buffer
+	w, _ := export.NewWriter(&buf, export.FormatNDJSON)
+	for i := range salons {
+		if err := w.Write(&salons[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := importer.ParseNDJSON(&buf)
+	if err != nil || len(rows) != 2 {
+		t.Fatalf("ParseNDJSON() = %+v, %v", rows, err)
+	}
+	for _, row := range rows {
+		if row.Err != nil {
+			t.Errorf("line %d: %v", row.Line, row.Err)
+		}
+	}
+	// Salons without services export an empty list, which clears them on import
+	if empty := rows[1].Salon; empty.Services == nil || len(empty.Services) != 0 {
+		t.Errorf("Services = %#v", empty.Services)
+	}
+}
+
+func TestExport_GeoJSON(t *testing.T) {
+	decode := func(salons ...domain.Salon) map[string]interface{} {
+		var buf bytes.Buffer
+		w, _ := export.NewWriter(&buf, export.FormatGeoJSON)
+		for i := range salons {
+			if err := w.Write(&salons[i]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		var doc map[string]interface{}
+		if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+			t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+		}
+		return doc
+	}
+
+	empty := decode()
+	if empty["type"] != "FeatureCollection" || len(empty["features"].([]interface{})) != 0 {
+		t.Errorf("empty export = %v", empty)
+	}
+
+	doc := decode(exportSalon(), domain.Salon{ID: 8, Name: "Sin Coordenadas", IsActive: true})
+	features := doc["features"].([]interface{})
+	if len(features) != 2 {
+		t.Fatalf("features = %v", features)
+	}
+	first := features[0].(map[string]interface{})
+	coords := first["geometry"].(map[string]interface{})["coordinates"].([]interface{})
+	if coords[0] != -60.656 || coords[1] != -32.948 {
+		t.Errorf("coordinates = %v, want [lon lat]", coords)
+	}
+	props := first["properties"].(map[string]interface{})
+	if props["slug"] != "bulevar-barberia-rosario" || props["latitude"] != nil || props["category"] != "barbershop" {
+		t.Errorf("properties = %v", props)
+	}
+	if second := features[1].(map[string]interface{}); second["geometry"] != nil {
+		t.Errorf("salons without coordinates should have a null geometry, got %v", second["geometry"])
+	}
+}
+
+func TestExportSalons_RejectsBadRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/admin/export", handlers.NewHandler(nil, nil).ExportSalons)
+
+	for _, url := range []string{"/admin/export?format=xlsx", "/admin/export?bbox=1,2,3"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "error") {
+			t.Errorf("%s: status = %d, want 400: %s", url, w.Code, w.Body)
+		}
+	}
+}
+
+// slowExporter sends batches of one salon with a pause before each
+type slowExporter struct {
+	batches int
+	pause   time.Duration
+}
+
+func (s slowExporter) ExportSalons(ctx context.Context, _ domain.SalonSearchParams, fn func([]domain.Salon) error) error {
+	for i := 0; i < s.batches; i++ {
+		time.Sleep(s.pause)
+		salon := exportSalon()
+		salon.ID = int64(i + 1)
+		if err := fn([]domain.Salon{salon}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestExportSalons_OutlastsWriteTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	h := handlers.NewHandler(nil, nil, handlers.WithExporter(slowExporter{batches: 5, pause: 100 * time.Millisecond}))
+	router.GET("/admin/export", h.ExportSalons)
+
+	srv := httptest.NewUnstartedServer(router)
+	srv.Config.WriteTimeout = 200 * time.Millisecond
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	resp, err := http.Get(srv.URL + "/admin/export?format=ndjson")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("export cut off after %d bytes: %v", len(body), err)
+	}
+	rows, err := importer.ParseNDJSON(bytes.NewReader(body))
+	if resp.StatusCode != http.StatusOK || err != nil || len(rows) != 5 {
+		t.Errorf("status %d, %d salons (%v), want all 5 past the 200ms write timeout", resp.StatusCode, len(rows), err)
+	}
+}
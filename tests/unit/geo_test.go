@@ -0,0 +1,332 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"beauty-salons/internal/api/handlers"
+	"beauty-salons/internal/domain"
+	"beauty-salons/internal/search"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeSearchCluster answers every request with the given search response
+func fakeSearchCluster(t *testing.T, response string) *search.ElasticsearchClient {
+	t.Helper()
+	es, _ := recordingSearchCluster(t, response)
+	return es
+}
+
+// recordingSearchCluster is fakeSearchCluster that also keeps the body of
+// the last request
+func recordingSearchCluster(t *testing.T, response string) (*search.ElasticsearchClient, *[]byte) {
+	t.Helper()
+
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(response))
+	}))
+	t.Cleanup(srv.Close)
+
+	es, err := search.NewElasticsearchClient(search.Config{Addresses: []string{srv.URL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(es.Close)
+	return es, &body
+}
+
+func TestSearch_DistanceFromLastSortValue(t *testing.T) {
+	// Relevance sort: _score and rating come before the geo distance
+	es := fakeSearchCluster(t, `{
+		"hits": {
+			"total": {"value": 2},
+			"hits": [
+				{"_score": 15.5, "_source": {"id": 1, "name": "Near"}, "sort": [15.5, 4.5, 2.25]},
+				{"_score": 9.0, "_source": {"id": 2, "name": "No location"}, "sort": [9.0, 4.0, 1.7976931348623157e308]}
+			]
+		}
+	}`)
+
+	params := domain.SalonSearchParams{
+		Query:    "corte",
+		Location: &domain.GeoPoint{Latitude: -38.0, Longitude: -57.55},
+		Page:     1,
+		PageSize: 10,
+	}
+	results, total, _, err := es.Search(context.Background(), params)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if total != 2 || len(results) != 2 {
+		t.Fatalf("got %d results (total %d), want 2", len(results), total)
+	}
+
+	if results[0].Distance == nil || *results[0].Distance != 2.25 {
+		t.Errorf("Distance = %v, want 2.25 (the last sort value, not _score)", results[0].Distance)
+	}
+	if results[1].Distance != nil {
+		t.Errorf("salon without location should have no distance, got %v", *results[1].Distance)
+	}
+}
+
+func TestParseBoundingBox(t *testing.T) {
+	box, err := domain.ParseBoundingBox("-57.6, -38.1, -57.5, -37.9")
+	if err != nil {
+		t.Fatalf("ParseBoundingBox() error = %v", err)
+	}
+	want := domain.BoundingBox{MinLon: -57.6, MinLat: -38.1, MaxLon: -57.5, MaxLat: -37.9}
+	if *box != want {
+		t.Errorf("ParseBoundingBox() = %+v, want %+v", *box, want)
+	}
+	if box.String() != "-57.6,-38.1,-57.5,-37.9" {
+		t.Errorf("String() = %q", box.String())
+	}
+
+	invalid := map[string]string{
+		"too few values":      "-57.6,-38.1,-57.5",
+		"not a number":        "-57.6,south,-57.5,-37.9",
+		"out of range":        "-57.6,-91,-57.5,-37.9",
+		"lat reversed":        "-57.6,-37.9,-57.5,-38.1",
+		"across antimeridian": "179,-10,-179,10",
+	}
+	for name, s := range invalid {
+		if _, err := domain.ParseBoundingBox(s); err == nil {
+			t.Errorf("%s: ParseBoundingBox(%q) should fail", name, s)
+		}
+	}
+}
+
+func TestParsePolygon(t *testing.T) {
+	valid := `{"type":"Polygon","coordinates":[[[-57.6,-38.1],[-57.5,-38.1],[-57.5,-37.9],[-57.6,-38.1]]]}`
+	p, err := domain.ParsePolygon(valid)
+	if err != nil {
+		t.Fatalf("ParsePolygon() error = %v", err)
+	}
+	if len(p.Coordinates[0]) != 4 {
+		t.Errorf("outer ring has %d positions, want 4", len(p.Coordinates[0]))
+	}
+
+	invalid := map[string]string{
+		"not json":     `{"type":`,
+		"wrong type":   `{"type":"Point","coordinates":[-57.6,-38.1]}`,
+		"no rings":     `{"type":"Polygon","coordinates":[]}`,
+		"open ring":    `{"type":"Polygon","coordinates":[[[-57.6,-38.1],[-57.5,-38.1],[-57.5,-37.9],[-57.6,-37.9]]]}`,
+		"short ring":   `{"type":"Polygon","coordinates":[[[-57.6,-38.1],[-57.5,-38.1],[-57.6,-38.1]]]}`,
+		"out of range": `{"type":"Polygon","coordinates":[[[-57.6,-98.1],[-57.5,-38.1],[-57.5,-37.9],[-57.6,-98.1]]]}`,
+	}
+	for name, s := range invalid {
+		if _, err := domain.ParsePolygon(s); err == nil {
+			t.Errorf("%s: ParsePolygon() should fail", name)
+		}
+	}
+
+	ring := make([][]float64, domain.MaxPolygonVertices+1)
+	for i := range ring {
+		ring[i] = []float64{float64(i%90) / 100, 0}
+	}
+	ring[len(ring)-1] = ring[0]
+	if err := (domain.Polygon{Type: "Polygon", Coordinates: [][][]float64{ring}}).Validate(); err == nil {
+		t.Error("polygon over MaxPolygonVertices should fail")
+	}
+}
+
+func TestSearchPins_ViewportQuery(t *testing.T) {
+	es, body := recordingSearchCluster(t, `{
+		"hits": {
+			"total": {"value": 3},
+			"hits": [
+				{"_source": {"id": 1, "name": "Centro", "location": {"lat": -38.0, "lon": -57.55}, "rating": 4.5}},
+				{"_source": {"id": 2, "name": "Puerto", "location": {"lat": -38.05, "lon": -57.53}}}
+			]
+		}
+	}`)
+
+	polygon, err := domain.ParsePolygon(`{"type":"Polygon","coordinates":[[[-57.6,-38.1],[-57.5,-38.1],[-57.5,-37.9],[-57.6,-38.1]]]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := domain.SalonSearchParams{
+		BoundingBox: &domain.BoundingBox{MinLon: -57.6, MinLat: -38.1, MaxLon: -57.5, MaxLat: -37.9},
+		Polygon:     polygon,
+	}
+	pins, total, _, err := es.SearchPins(context.Background(), params, 2)
+	if err != nil {
+		t.Fatalf("SearchPins() error = %v", err)
+	}
+	if total != 3 || len(pins) != 2 {
+		t.Fatalf("got %d pins (total %d), want 2 of 3", len(pins), total)
+	}
+	if pins[0].ID != 1 || pins[0].Location.Latitude != -38.0 || pins[0].Rating == nil || *pins[0].Rating != 4.5 {
+		t.Errorf("pins[0] = %+v", pins[0])
+	}
+	if pins[1].Rating != nil {
+		t.Errorf("unrated salon should have no rating, got %v", *pins[1].Rating)
+	}
+
+	query := string(*body)
+	for _, want := range []string{
+		`"geo_bounding_box"`,
+		`"top_left":{"lat":-37.9,"lon":-57.6}`,
+		`"bottom_right":{"lat":-38.1,"lon":-57.5}`,
+		`"geo_shape"`,
+		`"relation":"intersects"`,
+		`"size":2`,
+	} {
+		if !strings.Contains(query, want) {
+			t.Errorf("query missing %s: %s", want, query)
+		}
+	}
+	if strings.Contains(query, `"highlight"`) {
+		t.Errorf("pin query should not request highlights: %s", query)
+	}
+}
+
+func TestSearchPins_RequiresViewport(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := handlers.NewHandler(nil, nil)
+
+	tests := map[string]string{
+		"missing":     "/api/v1/search/pins?q=corte",
+		"invalid box": "/api/v1/search/pins?bbox=1,2,3",
+		"bad polygon": `/api/v1/search/pins?polygon={"type":"Point"}`,
+	}
+	for name, target := range tests {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, target, nil)
+
+			h.SearchPins(c)
+
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want 400", w.Code)
+			}
+			var resp map[string]string
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp["error"] == "" {
+				t.Errorf("expected an error message, got %s", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestTileKeyAndBounds(t *testing.T) {
+	key := domain.TileKey(1, 0, 1)
+	zoom, x, y, err := domain.ParseTileKey(key)
+	if err != nil || zoom != 1 || x != 0 || y != 1 {
+		t.Fatalf("ParseTileKey(%q) = %d/%d/%d, %v", key, zoom, x, y, err)
+	}
+
+	// Bottom left quarter of the world at zoom 1
+	b := domain.TileBounds(1, 0, 1)
+	if b.MinLon != -180 || b.MaxLon != 0 || b.MaxLat != 0 {
+		t.Errorf("TileBounds(1, 0, 1) = %+v", b)
+	}
+	if b.MinLat > -85.05 || b.MinLat < -85.06 {
+		t.Errorf("MinLat = %v, want the Web Mercator limit", b.MinLat)
+	}
+
+	for _, key := range []string{"", "1/2", "a/0/0", "1/2/0", "30/0/0", "-1/0/0"} {
+		if _, _, _, err := domain.ParseTileKey(key); err == nil {
+			t.Errorf("ParseTileKey(%q) should fail", key)
+		}
+	}
+}
+
+func TestSearchClusters_GeotileAggregation(t *testing.T) {
+	es, body := recordingSearchCluster(t, `{
+		"hits": {"total": {"value": 5}, "hits": []},
+		"aggregations": {
+			"clusters": {
+				"buckets": [
+					{
+						"key": "12/1393/2524",
+						"doc_count": 4,
+						"centroid": {"location": {"lat": -38.0, "lon": -57.55}, "count": 4},
+						"top_salon": {"hits": {"hits": [
+							{"_source": {"id": 7, "name": "Top", "location": {"lat": -38.01, "lon": -57.54}, "rating": 4.9}}
+						]}}
+					},
+					{
+						"key": "12/1394/2524",
+						"doc_count": 1,
+						"centroid": {"location": {"lat": -38.0, "lon": -57.45}, "count": 1},
+						"top_salon": {"hits": {"hits": [
+							{"_source": {"id": 8, "name": "Other", "location": {"lat": -38.0, "lon": -57.45}}}
+						]}}
+					}
+				]
+			}
+		}
+	}`)
+
+	params := domain.SalonSearchParams{
+		City:        "Mar del Plata",
+		BoundingBox: &domain.BoundingBox{MinLon: -57.6, MinLat: -38.1, MaxLon: -57.4, MaxLat: -37.9},
+	}
+	clusters, total, _, err := es.SearchClusters(context.Background(), params, 12, 100)
+	if err != nil {
+		t.Fatalf("SearchClusters() error = %v", err)
+	}
+	if total != 5 || len(clusters) != 2 {
+		t.Fatalf("got %d clusters (total %d), want 2 (total 5)", len(clusters), total)
+	}
+
+	first := clusters[0]
+	if first.Key != "12/1393/2524" || first.Count != 4 || first.Centroid.Longitude != -57.55 {
+		t.Errorf("clusters[0] = %+v", first)
+	}
+	if first.TopSalon == nil || first.TopSalon.ID != 7 {
+		t.Errorf("TopSalon = %+v, want salon 7", first.TopSalon)
+	}
+	if first.Bounds != domain.TileBounds(12, 1393, 2524) {
+		t.Errorf("Bounds = %+v, want the tile bounds", first.Bounds)
+	}
+
+	query := string(*body)
+	for _, want := range []string{
+		`"geotile_grid":{`,
+		`"precision":12`,
+		`"size":0`,
+		`"geo_centroid"`,
+		`"top_hits"`,
+		`"city":"Mar del Plata"`,
+	} {
+		if !strings.Contains(query, want) {
+			t.Errorf("query missing %s: %s", want, query)
+		}
+	}
+}
+
+func TestSearchClusters_Validation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := handlers.NewHandler(nil, nil)
+
+	tests := map[string]string{
+		"missing zoom":  "/api/v1/search/clusters?bbox=-57.6,-38.1,-57.5,-37.9",
+		"zoom too deep": "/api/v1/search/clusters?zoom=30&bbox=-57.6,-38.1,-57.5,-37.9",
+		"no viewport":   "/api/v1/search/clusters?zoom=12",
+	}
+	for name, target := range tests {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, target, nil)
+
+			h.SearchClusters(c)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want 400", w.Code)
+			}
+		})
+	}
+}
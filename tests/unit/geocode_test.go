@@ -0,0 +1,323 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"beauty-salons/internal/config"
+	"beauty-salons/internal/domain"
+	"beauty-salons/internal/geocode"
+)
+
+const testGazetteer = `country,state,city,postal_code,latitude,longitude
+# comment lines are skipped
+Argentina,Buenos Aires,Mar del Plata,7600,-38.0055,-57.5426
+Argentina,Córdoba,Córdoba,5000,-31.4201,-64.1888
+Argentina,Buenos Aires,San Martín,,-34.5750,-58.5372
+Argentina,Mendoza,San Martín,,-33.0810,-68.4681
+`
+
+var marDelPlata = domain.Location{
+	Address:    "Av. Colón 2145",
+	City:       "Mar del Plata",
+	State:      "Buenos Aires",
+	PostalCode: "7600",
+	Country:    "Argentina",
+}
+
+func mustGazetteer(t *testing.T) *geocode.Gazetteer {
+	t.Helper()
+	g, err := geocode.ParseGazetteer(strings.NewReader(testGazetteer))
+	if err != nil {
+		t.Fatalf("ParseGazetteer() error = %v", err)
+	}
+	return g
+}
+
+func TestNominatim_StructuredSearch(t *testing.T) {
+	var got *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("city") == "Nowhere" {
+			_, _ = w.Write([]byte(`[]`))
+			return
+		}
+		_, _ = w.Write([]byte(`[{"lat": "-38.0023", "lon": "-57.5575", "display_name": "Avenida Colón 2145, Mar del Plata"}]`))
+	}))
+	defer srv.Close()
+
+	n, err := geocode.NewNominatim(geocode.NominatimConfig{BaseURL: srv.URL, UserAgent: "test-agent", MinInterval: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := n.Geocode(context.Background(), marDelPlata)
+	if err != nil {
+		t.Fatalf("Geocode() error = %v", err)
+	}
+	if result.Point != (domain.GeoPoint{Latitude: -38.0023, Longitude: -57.5575}) || result.Provider != "nominatim" {
+		t.Errorf("Geocode() = %+v", result)
+	}
+
+	q := got.URL.Query()
+	if got.URL.Path != "/search" || q.Get("format") != "jsonv2" || q.Get("street") != "Av. Colón 2145" || q.Get("postalcode") != "7600" {
+		t.Errorf("unexpected request %s", got.URL)
+	}
+	if got.Header.Get("User-Agent") != "test-agent" {
+		t.Errorf("User-Agent = %q, want test-agent", got.Header.Get("User-Agent"))
+	}
+
+	if _, err := n.Geocode(context.Background(), domain.Location{City: "Nowhere"}); !errors.Is(err, geocode.ErrNotFound) {
+		t.Errorf("unknown address: error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestNominatim_ServerError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	n, err := geocode.NewNominatim(geocode.NominatimConfig{BaseURL: srv.URL, MinInterval: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = n.Geocode(context.Background(), marDelPlata)
+	if err == nil || errors.Is(err, geocode.ErrNotFound) {
+		t.Errorf("error = %v, want a request error", err)
+	}
+}
+
+func TestGazetteer_Lookup(t *testing.T) {
+	g := mustGazetteer(t)
+	if g.Len() != 4 {
+		t.Errorf("Len() = %d, want 4", g.Len())
+	}
+
+	tests := []struct {
+		name string
+		loc  domain.Location
+		want *domain.GeoPoint
+	}{
+		{"postal code", domain.Location{PostalCode: "7600", Country: "Argentina"}, &domain.GeoPoint{Latitude: -38.0055, Longitude: -57.5426}},
+		{"city without accents", domain.Location{City: "cordoba", Country: "argentina"}, &domain.GeoPoint{Latitude: -31.4201, Longitude: -64.1888}},
+		{"city only", domain.Location{City: "Mar del Plata"}, &domain.GeoPoint{Latitude: -38.0055, Longitude: -57.5426}},
+		{"city and state", domain.Location{City: "San Martin", State: "Mendoza", Country: "Argentina"}, &domain.GeoPoint{Latitude: -33.0810, Longitude: -68.4681}},
+		{"ambiguous city", domain.Location{City: "San Martín", Country: "Argentina"}, nil},
+		{"unknown", domain.Location{City: "Rosario"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := g.Geocode(context.Background(), tt.loc)
+			if tt.want == nil {
+				if !errors.Is(err, geocode.ErrNotFound) {
+					t.Errorf("error = %v, want ErrNotFound", err)
+				}
+				return
+			}
+			if err != nil || result.Point != *tt.want {
+				t.Errorf("Geocode() = %+v, %v; want %v", result, err, *tt.want)
+			}
+		})
+	}
+}
+
+func TestGazetteer_InvalidFiles(t *testing.T) {
+	files := map[string]string{
+		"wrong header":    "city,lat,lon\nMar del Plata,-38,-57\n",
+		"bad coordinates": "country,state,city,postal_code,latitude,longitude\nArgentina,,Mar del Plata,,-138,-57\n",
+		"no place":        "country,state,city,postal_code,latitude,longitude\nArgentina,Buenos Aires,,,-38,-57\n",
+	}
+	for name, content := range files {
+		if _, err := geocode.ParseGazetteer(strings.NewReader(content)); err == nil {
+			t.Errorf("%s: ParseGazetteer() should fail", name)
+		}
+	}
+}
+
+// fakeGeocoder answers from a map of cities and counts calls
+type fakeGeocoder struct {
+	points map[string]domain.GeoPoint
+	err    error
+	calls  int
+}
+
+func (f *fakeGeocoder) Name() string { return "fake" }
+
+func (f *fakeGeocoder) Geocode(_ context.Context, loc domain.Location) (*geocode.Result, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	if p, ok := f.points[loc.City]; ok {
+		return &geocode.Result{Point: p, Provider: f.Name()}, nil
+	}
+	return nil, geocode.ErrNotFound
+}
+
+func TestChain_FallsThrough(t *testing.T) {
+	rosario := domain.GeoPoint{Latitude: -32.9442, Longitude: -60.6505}
+	remote := &fakeGeocoder{points: map[string]domain.GeoPoint{"Rosario": rosario}}
+	chain := geocode.Chain{mustGazetteer(t), remote}
+
+	if _, err := chain.Geocode(context.Background(), marDelPlata); err != nil || remote.calls != 0 {
+		t.Errorf("gazetteer hit should not reach the next geocoder (err %v, calls %d)", err, remote.calls)
+	}
+	if result, err := chain.Geocode(context.Background(), domain.Location{City: "Rosario"}); err != nil || result.Point != rosario {
+		t.Errorf("Geocode(Rosario) = %+v, %v", result, err)
+	}
+
+	failing := geocode.Chain{&fakeGeocoder{err: errors.New("boom")}, mustGazetteer(t)}
+	if _, err := failing.Geocode(context.Background(), domain.Location{City: "Nowhere"}); err == nil || errors.Is(err, geocode.ErrNotFound) {
+		t.Errorf("error = %v, want the geocoder error", err)
+	}
+}
+
+// memoryCache is an in-memory geocode.Cache
+type memoryCache map[string]*geocode.Result
+
+func (m memoryCache) GetGeocode(_ context.Context, key string, _ time.Duration) (*geocode.Result, bool, error) {
+	result, ok := m[key]
+	return result, ok, nil
+}
+
+func (m memoryCache) PutGeocode(_ context.Context, key string, result *geocode.Result) error {
+	m[key] = result
+	return nil
+}
+
+func TestCached_RemembersResults(t *testing.T) {
+	inner := &fakeGeocoder{points: map[string]domain.GeoPoint{"Mar del Plata": {Latitude: -38, Longitude: -57.5}}}
+	cache := memoryCache{}
+	g := geocode.NewCached(inner, cache, 0)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := g.Geocode(ctx, marDelPlata); err != nil {
+			t.Fatalf("Geocode() error = %v", err)
+		}
+		// Same address, different spelling
+		if _, err := g.Geocode(ctx, domain.Location{City: "Nowhere"}); !errors.Is(err, geocode.ErrNotFound) {
+			t.Fatalf("error = %v, want ErrNotFound", err)
+		}
+	}
+	if inner.calls != 2 {
+		t.Errorf("geocoder called %d times, want 2 (one per address)", inner.calls)
+	}
+
+	loud := marDelPlata
+	loud.City, loud.Address = "MAR DEL PLATA", "av.  colon 2145"
+	if _, err := g.Geocode(ctx, loud); err != nil || inner.calls != 2 {
+		t.Errorf("normalized address should hit the cache (err %v, calls %d)", err, inner.calls)
+	}
+
+	inner.err = errors.New("timeout")
+	if _, err := g.Geocode(ctx, domain.Location{City: "Rosario"}); err == nil {
+		t.Error("geocoder error should be returned")
+	}
+	if _, ok := cache[geocode.Key(domain.Location{City: "Rosario"})]; ok {
+		t.Error("geocoder errors should not be cached")
+	}
+}
+
+// fakeGeocodeStore is an in-memory geocode.Store
+type fakeGeocodeStore struct {
+	salons []domain.Salon
+	set    map[int64]domain.GeocodeStatus
+}
+
+func (s *fakeGeocodeStore) SalonsToGeocode(_ context.Context, afterID int64, limit int) ([]domain.Salon, error) {
+	var out []domain.Salon
+	for _, salon := range s.salons {
+		if salon.ID > afterID && len(out) < limit {
+			out = append(out, salon)
+		}
+	}
+	return out, nil
+}
+
+func (s *fakeGeocodeStore) SetSalonGeocode(_ context.Context, id int64, point *domain.GeoPoint, status domain.GeocodeStatus) error {
+	if (status == domain.GeocodeOK) != (point != nil) {
+		return errors.New("point must be set exactly for ok")
+	}
+	s.set[id] = status
+	return nil
+}
+
+func TestBackfill(t *testing.T) {
+	store := &fakeGeocodeStore{
+		salons: []domain.Salon{
+			{ID: 1, Location: marDelPlata},
+			{ID: 2, Location: domain.Location{City: "Atlantis"}},
+			{ID: 3, Location: domain.Location{Address: "No city"}},
+			{ID: 4, Location: domain.Location{City: "Córdoba", Country: "Argentina"}},
+		},
+		set: map[int64]domain.GeocodeStatus{},
+	}
+
+	stats, err := geocode.Backfill(context.Background(), store, mustGazetteer(t), 2)
+	if err != nil {
+		t.Fatalf("Backfill() error = %v", err)
+	}
+	if stats.Processed != 4 || stats.Located != 2 || stats.NotFound != 2 || stats.Failed != 0 {
+		t.Errorf("stats = %s", stats)
+	}
+	if len(stats.LocatedIDs) != 2 || stats.LocatedIDs[0] != 1 || stats.LocatedIDs[1] != 4 {
+		t.Errorf("LocatedIDs = %v, want [1 4]", stats.LocatedIDs)
+	}
+	want := map[int64]domain.GeocodeStatus{1: domain.GeocodeOK, 2: domain.GeocodeNotFound, 3: domain.GeocodeNotFound, 4: domain.GeocodeOK}
+	for id, status := range want {
+		if store.set[id] != status {
+			t.Errorf("salon %d status = %q, want %q", id, store.set[id], status)
+		}
+	}
+}
+
+func TestLocate_KeepsManualCoordinates(t *testing.T) {
+	g := &fakeGeocoder{}
+	salon := &domain.Salon{Location: marDelPlata}
+	salon.Location.GeoPoint = &domain.GeoPoint{Latitude: 1, Longitude: 2}
+
+	status, err := geocode.Locate(context.Background(), g, salon)
+	if err != nil || status != domain.GeocodeManual || g.calls != 0 {
+		t.Errorf("Locate() = %q, %v (calls %d); want manual without lookups", status, err, g.calls)
+	}
+
+	g.err = errors.New("unavailable")
+	salon = &domain.Salon{Location: marDelPlata}
+	status, err = geocode.Locate(context.Background(), g, salon)
+	if err == nil || status != domain.GeocodeFailed || salon.Location.GeocodeStatus != domain.GeocodeFailed {
+		t.Errorf("Locate() = %q, %v; want failed with the error", status, err)
+	}
+}
+
+func TestConfig_GeocodingValidation(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+
+	t.Setenv("GEOCODER_PROVIDERS", "gazetteer,google")
+	_, err := config.Load()
+	if err == nil {
+		t.Fatal("Load() should fail")
+	}
+	for _, want := range []string{"GEOCODER_GAZETTEER_FILE", `"google"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error should mention %s: %v", want, err)
+		}
+	}
+
+	t.Setenv("GEOCODER_PROVIDERS", "nominatim")
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	g, err := geocode.New(cfg.Geocoding.GeocoderConfig(), nil)
+	if err != nil || g == nil || g.Name() != "nominatim" {
+		t.Errorf("New() = %v, %v; want a nominatim geocoder", g, err)
+	}
+}
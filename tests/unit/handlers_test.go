@@ -1,13 +1,19 @@
 package unit
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"beauty-salons/internal/api/handlers"
 	"beauty-salons/internal/domain"
+	"beauty-salons/internal/search"
 
 	"github.com/gin-gonic/gin"
 )
@@ -146,6 +152,21 @@ func TestParseSearchParams(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:        "near place",
+			queryString: "near=+G%C3%BCemes,+Mar+del+Plata+&radius=2",
+			check: func(t *testing.T, p domain.SalonSearchParams) {
+				if p.Near != "Güemes, Mar del Plata" {
+					t.Errorf("Near = %q, want Güemes, Mar del Plata", p.Near)
+				}
+				if p.Location != nil {
+					t.Error("Location should only be set once the place is resolved")
+				}
+				if p.RadiusKm == nil || *p.RadiusKm != 2 {
+					t.Errorf("RadiusKm = %v, want 2", p.RadiusKm)
+				}
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -273,3 +294,188 @@ func TestHealthEndpoint(t *testing.T) {
 		t.Errorf("status = %v, want ok", response["status"])
 	}
 }
+
+func TestLivezEndpoint(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	h := handlers.NewHandler(nil, nil)
+	router := gin.New()
+	router.GET("/livez", h.Livez)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/livez", nil)
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Status code = %v, want %v", w.Code, http.StatusOK)
+	}
+
+	var response map[string]string
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	if response["status"] != "alive" {
+		t.Errorf("status = %v, want alive", response["status"])
+	}
+}
+
+// fakeDatabase is a DatabaseChecker failing its pings with err
+type fakeDatabase struct{ err error }
+
+func (f fakeDatabase) Ping(context.Context) error { return f.err }
+func (fakeDatabase) ReplicaDB() *sql.DB           { return nil }
+func (fakeDatabase) ReplicaHealthy() bool         { return false }
+
+// healthCluster answers cluster health with status and counts documents
+// in the salons index
+func healthCluster(t *testing.T, status string, documents int) *search.ElasticsearchClient {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/_cluster/health":
+			_ = json.NewEncoder(w).Encode(map[string]string{"status": status})
+		case strings.HasSuffix(r.URL.Path, "/_count"):
+			_ = json.NewEncoder(w).Encode(map[string]int{"count": documents})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	es, err := search.NewElasticsearchClient(search.Config{Addresses: []string{srv.URL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(es.Close)
+	return es
+}
+
+// unreachableCluster is a client for a cluster that refuses connections
+func unreachableCluster(t *testing.T) *search.ElasticsearchClient {
+	t.Helper()
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	es, err := search.NewElasticsearchClient(search.Config{Addresses: []string{srv.URL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(es.Close)
+	return es
+}
+
+func TestReadyzEndpoint(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	openBreaker := search.NewCircuitBreaker(1, time.Minute)
+	openBreaker.RecordFailure()
+
+	tests := []struct {
+		name       string
+		database   error
+		es         func(t *testing.T) *search.ElasticsearchClient
+		breaker    *search.CircuitBreaker
+		wantCode   int
+		wantStatus string
+		wantPG     string
+		wantES     string
+	}{
+		{
+			name:       "all up",
+			es:         func(t *testing.T) *search.ElasticsearchClient { return healthCluster(t, "green", 12) },
+			wantCode:   http.StatusOK,
+			wantStatus: handlers.StatusReady,
+			wantPG:     handlers.StatusUp,
+			wantES:     handlers.StatusUp,
+		},
+		{
+			name:       "postgres down",
+			database:   errors.New("connection refused"),
+			es:         func(t *testing.T) *search.ElasticsearchClient { return healthCluster(t, "green", 12) },
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: handlers.StatusNotReady,
+			wantPG:     handlers.StatusDown,
+			wantES:     handlers.StatusUp,
+		},
+		{
+			name:       "elasticsearch unreachable",
+			es:         unreachableCluster,
+			wantCode:   http.StatusOK,
+			wantStatus: handlers.StatusDegraded,
+			wantPG:     handlers.StatusUp,
+			wantES:     handlers.StatusDown,
+		},
+		{
+			name:       "cluster red",
+			es:         func(t *testing.T) *search.ElasticsearchClient { return healthCluster(t, "red", 12) },
+			wantCode:   http.StatusOK,
+			wantStatus: handlers.StatusDegraded,
+			wantPG:     handlers.StatusUp,
+			wantES:     handlers.StatusDown,
+		},
+		{
+			name:       "empty index",
+			es:         func(t *testing.T) *search.ElasticsearchClient { return healthCluster(t, "green", 0) },
+			wantCode:   http.StatusOK,
+			wantStatus: handlers.StatusDegraded,
+			wantPG:     handlers.StatusUp,
+			wantES:     handlers.StatusDown,
+		},
+		{
+			name:       "circuit breaker open",
+			es:         func(t *testing.T) *search.ElasticsearchClient { return healthCluster(t, "green", 12) },
+			breaker:    openBreaker,
+			wantCode:   http.StatusOK,
+			wantStatus: handlers.StatusDegraded,
+			wantPG:     handlers.StatusUp,
+			wantES:     handlers.StatusDegraded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := []handlers.Option{handlers.WithDatabaseChecker(fakeDatabase{tt.database})}
+			if tt.breaker != nil {
+				opts = append(opts, handlers.WithCircuitBreaker(tt.breaker))
+			}
+			router := gin.New()
+			router.GET("/readyz", handlers.NewHandler(nil, tt.es(t), opts...).Readyz)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/readyz", nil))
+			if w.Code != tt.wantCode {
+				t.Errorf("Status code = %v, want %v", w.Code, tt.wantCode)
+			}
+
+			var response handlers.ReadinessResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			if response.Status != tt.wantStatus {
+				t.Errorf("status = %v, want %v", response.Status, tt.wantStatus)
+			}
+
+			pg, es := response.Dependencies["postgres"], response.Dependencies["elasticsearch"]
+			if len(response.Dependencies) != 2 || !pg.Critical || es.Critical {
+				t.Errorf("dependencies = %+v, want critical postgres and non-critical elasticsearch", response.Dependencies)
+			}
+			if pg.Status != tt.wantPG || es.Status != tt.wantES {
+				t.Errorf("postgres %v, elasticsearch %v, want %v and %v", pg.Status, es.Status, tt.wantPG, tt.wantES)
+			}
+			for name, dep := range response.Dependencies {
+				if (dep.Status == handlers.StatusUp) != (dep.Error == "") {
+					t.Errorf("%s: status %v with error %q", name, dep.Status, dep.Error)
+				}
+				if dep.Status == handlers.StatusDown && (dep.LastError == "" || dep.LastErrorAt == nil) {
+					t.Errorf("%s: down without the last error", name)
+				}
+			}
+			if es.Details["breaker"] == nil {
+				t.Errorf("elasticsearch details = %v, want the breaker state", es.Details)
+			}
+		})
+	}
+}
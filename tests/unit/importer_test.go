@@ -0,0 +1,256 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"beauty-salons/internal/api/handlers"
+	"beauty-salons/internal/domain"
+	"beauty-salons/internal/importer"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestImporter_ParseCSV(t *testing.T) {
+	input := `# comment
+name,city,category,latitude,longitude,services,amenities,hours
+Bulevar Barbería,Rosario,barbershop,-32.948,-60.656,Corte:6000-9000:30|Afeitado::20,wifi|Acepta Tarjetas,1-5=10:00-20:00|0=closed
+Sin Datos,Rosario,,,,,,
+Malo,Rosario,,abc,,Corte:caro,,8=10:00-12:00
+`
+	rows, err := importer.ParseCSV(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseCSV() error = %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("got %d rows, want 3", len(rows))
+	}
+
+	first := rows[0]
+	if first.Err != nil || first.Line != 3 {
+		t.Fatalf("row 1: line %d, error %v", first.Line, first.Err)
+	}
+	s := first.Salon
+	if s.Slug != "bulevar-barberia-rosario" || s.Category == nil || s.Category.Slug != "barbershop" || !s.IsActive {
+		t.Errorf("salon = %+v", s)
+	}
+	if s.Location.GeoPoint == nil || s.Location.GeoPoint.Latitude != -32.948 {
+		t.Errorf("GeoPoint = %v", s.Location.GeoPoint)
+	}
+	if len(s.Services) != 2 || *s.Services[0].PriceMin != 6000 || *s.Services[0].PriceMax != 9000 ||
+		*s.Services[0].DurationMinutes != 30 || s.Services[1].PriceMax != nil || *s.Services[1].DurationMinutes != 20 {
+		t.Errorf("Services = %+v", s.Services)
+	}
+	if len(s.Amenities) != 2 || s.Amenities[1].Name != "Acepta Tarjetas" {
+		t.Errorf("Amenities = %+v", s.Amenities)
+	}
+	if len(s.OperatingHours) != 6 || s.OperatingHours[0].OpenTime != "10:00:00" || !s.OperatingHours[5].IsClosed {
+		t.Errorf("OperatingHours = %+v", s.OperatingHours)
+	}
+
+	// Empty cells clear lists rather than keeping them
+	if empty := rows[1].Salon; rows[1].Err != nil || empty.Services == nil || len(empty.Services) != 0 || empty.Category != nil {
+		t.Errorf("row 2 = %+v, %v", empty, rows[1].Err)
+	}
+
+	bad := rows[2].Err
+	if bad == nil {
+		t.Fatal("row 3 should fail")
+	}
+	for _, want := range []string{"latitude", "invalid price"} {
+		if !strings.Contains(bad.Error(), want) {
+			t.Errorf("row 3 error should mention %s: %v", want, bad)
+		}
+	}
+	if rows[2].Salon.Slug != "malo-rosario" {
+		t.Errorf("failed rows should keep their slug, got %q", rows[2].Salon.Slug)
+	}
+
+	if _, err := importer.ParseCSV(strings.NewReader("name,colour\nX,red\n")); err == nil {
+		t.Error("unknown columns should be rejected")
+	}
+	if _, err := importer.ParseCSV(strings.NewReader("city\nRosario\n")); err == nil {
+		t.Error("the name column should be required")
+	}
+	if rows, err := importer.Load("../../salons.example.csv", ""); err != nil || len(rows) == 0 || rows[0].Err != nil {
+		t.Errorf("salons.example.csv: %v", err)
+	}
+}
+
+func TestImporter_ParseNDJSON(t *testing.T) {
+	input := `{"name": "Uñas del Parque", "city": "Rosario", "services": [{"name": "Manicura", "price_min": 7000}], "hours": [{"day": 2, "open": "9:30", "close": "19:00"}]}
+
+{"name": "Sin Horario", "slug": "sin-horario", "hours": [{"day": 1, "open": "19:00", "close": "09:00"}]}
+{"name": "Typo", "adress": "Calle 1"}
+{"name": "Solo Lat", "latitude": -32.9}
+`
+	rows, err := importer.ParseNDJSON(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseNDJSON() error = %v", err)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("got %d rows, want 4 (blank lines skipped)", len(rows))
+	}
+
+	if rows[0].Err != nil || rows[0].Salon.Slug != "unas-del-parque-rosario" || rows[0].Salon.OperatingHours[0].OpenTime != "09:30:00" {
+		t.Errorf("row 1 = %+v, %v", rows[0].Salon, rows[0].Err)
+	}
+	if rows[0].Salon.Amenities != nil {
+		t.Error("missing amenities should stay nil, keeping the salon's current ones")
+	}
+	for i, want := range map[int]string{1: "closes before it opens", 2: "unknown field", 3: "together"} {
+		if rows[i].Err == nil || !strings.Contains(rows[i].Err.Error(), want) {
+			t.Errorf("row %d (line %d) error = %v, want %q", i+1, rows[i].Line, rows[i].Err, want)
+		}
+	}
+	if rows[1].Line != 3 {
+		t.Errorf("line = %d, want 3", rows[1].Line)
+	}
+}
+
+func TestImporter_ParseFormat(t *testing.T) {
+	for in, want := range map[string]importer.Format{
+		"csv": importer.FormatCSV, ".CSV": importer.FormatCSV, "text/csv; charset=utf-8": importer.FormatCSV,
+		"ndjson": importer.FormatNDJSON, ".jsonl": importer.FormatNDJSON, "application/x-ndjson": importer.FormatNDJSON,
+	} {
+		if got, err := importer.ParseFormat(in); err != nil || got != want {
+			t.Errorf("ParseFormat(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := importer.ParseFormat("xlsx"); err == nil {
+		t.Error("ParseFormat(xlsx) should fail")
+	}
+}
+
+// fakeImportStore records batches; salons named "reject" fail
+type fakeImportStore struct {
+	batches [][]string
+	dryRuns []bool
+	nextID  int64
+	err     error
+}
+
+func (s *fakeImportStore) UpsertSalons(_ context.Context, salons []domain.Salon, dryRun bool) ([]importer.UpsertResult, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	var slugs []string
+	results := make([]importer.UpsertResult, len(salons))
+	for i, salon := range salons {
+		slugs = append(slugs, salon.Slug)
+		if salon.Name == "reject" {
+			results[i].Err = errors.New("unknown category")
+			continue
+		}
+		s.nextID++
+		results[i] = importer.UpsertResult{ID: s.nextID, Created: s.nextID%2 == 1}
+	}
+	s.batches = append(s.batches, slugs)
+	s.dryRuns = append(s.dryRuns, dryRun)
+	return results, nil
+}
+
+func (s *fakeImportStore) GetSalonByID(_ context.Context, id int64) (*domain.Salon, error) {
+	return &domain.Salon{ID: id}, nil
+}
+
+type fakeIndexer struct{ indexed []int64 }
+
+func (f *fakeIndexer) BulkIndexSalons(_ context.Context, salons []domain.Salon) error {
+	for _, s := range salons {
+		f.indexed = append(f.indexed, s.ID)
+	}
+	return nil
+}
+
+func importRows(names ...string) []importer.Row {
+	rows := make([]importer.Row, len(names))
+	for i, name := range names {
+		rows[i] = importer.Row{Line: i + 2, Salon: domain.Salon{Name: name, Slug: importer.Slug(name, "")}}
+	}
+	return rows
+}
+
+func TestImporter_Import(t *testing.T) {
+	rows := importRows("a", "b", "reject", "c", "", "a", "d")
+	rows = append(rows, importer.Row{Line: 20, Err: errors.New("invalid JSON")})
+	store := &fakeImportStore{}
+	index := &fakeIndexer{}
+
+	report, err := importer.Import(context.Background(), store, index, rows, importer.Options{BatchSize: 2})
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+
+	wantBatches := "[[a b] [reject c] [d]]"
+	if got := fmt.Sprint(store.batches); got != wantBatches {
+		t.Errorf("batches = %s, want %s", got, wantBatches)
+	}
+	if report.Rows != 8 || report.Created != 2 || report.Updated != 2 || report.Failed != 4 || report.Indexed != 4 {
+		t.Errorf("report = %s", report)
+	}
+	if len(index.indexed) != 4 || len(report.IDs) != 4 {
+		t.Errorf("indexed %v, IDs %v", index.indexed, report.IDs)
+	}
+
+	wantErrors := map[int]string{4: "unknown category", 6: "name is required", 7: "duplicate slug, first used on line 2", 20: "invalid JSON"}
+	if len(report.Errors) != len(wantErrors) {
+		t.Fatalf("errors = %+v", report.Errors)
+	}
+	for _, e := range report.Errors {
+		if !strings.Contains(e.Error, wantErrors[e.Line]) {
+			t.Errorf("line %d error = %q, want %q", e.Line, e.Error, wantErrors[e.Line])
+		}
+	}
+}
+
+func TestImporter_DryRunAndStoreErrors(t *testing.T) {
+	store := &fakeImportStore{}
+	index := &fakeIndexer{}
+	report, err := importer.Import(context.Background(), store, index, importRows("a", "b"), importer.Options{DryRun: true})
+	if err != nil || !report.DryRun || report.Created+report.Updated != 2 {
+		t.Fatalf("Import() = %s, %v", report, err)
+	}
+	if len(store.dryRuns) != 1 || !store.dryRuns[0] || len(index.indexed) != 0 {
+		t.Errorf("dry runs should reach the store as such and skip indexing (dryRuns %v, indexed %v)", store.dryRuns, index.indexed)
+	}
+
+	store = &fakeImportStore{err: errors.New("connection refused")}
+	if _, err := importer.Import(context.Background(), store, nil, importRows("a"), importer.Options{}); err == nil {
+		t.Error("store errors should stop the import")
+	}
+}
+
+func TestImportSalons_RejectsBadRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/admin/import", handlers.NewHandler(nil, nil).ImportSalons)
+
+	tests := []struct {
+		name        string
+		url         string
+		contentType string
+		body        string
+	}{
+		{"unknown format", "/admin/import", "application/octet-stream", "name\nX\n"},
+		{"bad header", "/admin/import?format=csv", "", "colour\nred\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.url, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want 400: %s", w.Code, w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"beauty-salons/internal/api/middleware"
+	"beauty-salons/internal/logging"
+	"beauty-salons/internal/search"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var seen string
+	r := gin.New()
+	r.Use(middleware.RequestID())
+	r.GET("/", func(c *gin.Context) {
+		seen = logging.RequestID(c.Request.Context())
+	})
+
+	tests := []struct {
+		name     string
+		incoming string
+		wantSame bool
+	}{
+		{"accepts caller ID", "abc-123", true},
+		{"generates when missing", "", false},
+		{"replaces invalid ID", "has spaces in it", false},
+		{"replaces oversized ID", strings.Repeat("x", 200), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.incoming != "" {
+				req.Header.Set(middleware.RequestIDHeader, tt.incoming)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			got := w.Header().Get(middleware.RequestIDHeader)
+			if got == "" || got != seen {
+				t.Fatalf("response ID %q, context ID %q: want equal and non-empty", got, seen)
+			}
+			if (got == tt.incoming) != tt.wantSame {
+				t.Errorf("ID = %q, incoming %q, wantSame %v", got, tt.incoming, tt.wantSame)
+			}
+		})
+	}
+}
+
+func TestLoggerMiddleware_IncludesRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, logging.FormatJSON, "info")
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev := slog.Default()
+	slog.SetDefault(logger)
+	defer slog.SetDefault(prev)
+
+	r := gin.New()
+	r.Use(middleware.RequestID(), middleware.Logger())
+	r.GET("/api/v1/salons/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/salons/42", nil)
+	req.Header.Set(middleware.RequestIDHeader, "req-42")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("log line is not JSON: %v\n%s", err, buf.String())
+	}
+	if entry["request_id"] != "req-42" {
+		t.Errorf("request_id = %v, want req-42", entry["request_id"])
+	}
+	if entry["route"] != "/api/v1/salons/:id" || entry["status"] != float64(200) {
+		t.Errorf("unexpected access log entry: %v", entry)
+	}
+}
+
+func TestLoggingNew_RejectsUnknownFormat(t *testing.T) {
+	if _, err := logging.New(&bytes.Buffer{}, "xml", "info"); err == nil {
+		t.Error("New() should reject unknown formats")
+	}
+	if _, err := logging.New(&bytes.Buffer{}, logging.FormatText, "loud"); err == nil {
+		t.Error("New() should reject unknown levels")
+	}
+}
+
+func TestElasticsearchClient_SendsOpaqueID(t *testing.T) {
+	var opaqueID string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		opaqueID = r.Header.Get("X-Opaque-Id")
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"version":{"number":"8.11.1"}}`))
+	}))
+	defer srv.Close()
+
+	es, err := search.NewElasticsearchClient(search.Config{Addresses: []string{srv.URL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer es.Close()
+
+	ctx := logging.WithRequestID(context.Background(), "req-7")
+	if err := es.Ping(ctx); err != nil {
+		t.Fatalf("Ping() error = %v", err)
+	}
+	if opaqueID != "req-7" {
+		t.Errorf("X-Opaque-Id = %q, want req-7", opaqueID)
+	}
+}
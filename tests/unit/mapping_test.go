@@ -0,0 +1,270 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"beauty-salons/internal/domain"
+	"beauty-salons/internal/search"
+	"beauty-salons/internal/synonyms"
+)
+
+// fakeMappingCluster serves index creation and GET _mapping, remembering the
+// mappings of the last created index
+type fakeMappingCluster struct {
+	mappings json.RawMessage // Served by GET _mapping; nil answers 404
+}
+
+func (f *fakeMappingCluster) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("X-Elastic-Product", "Elasticsearch")
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.Method == http.MethodHead:
+		w.WriteHeader(http.StatusNotFound)
+	case r.Method == http.MethodPut:
+		var body struct {
+			Mappings json.RawMessage `json:"mappings"`
+		}
+		raw, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(raw, &body)
+		f.mappings = body.Mappings
+		_, _ = w.Write([]byte(`{"acknowledged":true}`))
+	case strings.HasSuffix(r.URL.Path, "/_mapping"):
+		if f.mappings == nil {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":{"type":"index_not_found_exception"},"status":404}`))
+			return
+		}
+		// Aliases resolve to the concrete index name
+		_, _ = w.Write([]byte(`{"salons_v2":{"mappings":` + string(f.mappings) + `}}`))
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func newMappingClient(t *testing.T, cluster *fakeMappingCluster, cfg search.Config) *search.ElasticsearchClient {
+	t.Helper()
+	srv := httptest.NewServer(cluster)
+	t.Cleanup(srv.Close)
+	cfg.Addresses = []string{srv.URL}
+	es, err := search.NewElasticsearchClient(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(es.Close)
+	return es
+}
+
+func TestCreateIndex_VersionedMapping(t *testing.T) {
+	cluster := &fakeMappingCluster{}
+	es := newMappingClient(t, cluster, search.Config{Synonyms: synonyms.Default()})
+	if err := es.CreateIndex(context.Background()); err != nil {
+		t.Fatalf("CreateIndex() error = %v", err)
+	}
+
+	var mappings struct {
+		Dynamic    string                            `json:"dynamic"`
+		Meta       map[string]interface{}            `json:"_meta"`
+		Properties map[string]map[string]interface{} `json:"properties"`
+	}
+	if err := json.Unmarshal(cluster.mappings, &mappings); err != nil {
+		t.Fatal(err)
+	}
+	if mappings.Dynamic != "strict" {
+		t.Errorf("dynamic = %q, want strict so unmapped fields are caught", mappings.Dynamic)
+	}
+	for field, want := range map[string]string{"created_at": "date", "category_id": "long", "postal_code": "keyword", "location": "geo_point"} {
+		if got := mappings.Properties[field]["type"]; got != want {
+			t.Errorf("%s type = %v, want %s", field, got, want)
+		}
+	}
+	if hash, _ := mappings.Meta["mapping_hash"].(string); mappings.Meta["mapping_version"] == nil || len(hash) != 64 {
+		t.Errorf("_meta = %v", mappings.Meta)
+	}
+}
+
+func TestCheckMapping(t *testing.T) {
+	ctx := context.Background()
+	cluster := &fakeMappingCluster{}
+	es := newMappingClient(t, cluster, search.Config{Synonyms: synonyms.Default()})
+
+	status, err := es.CheckMapping(ctx)
+	if err != nil || status.Exists || status.InSync() {
+		t.Fatalf("missing index: %+v, %v", status, err)
+	}
+
+	if err := es.CreateIndex(ctx); err != nil {
+		t.Fatal(err)
+	}
+	status, err = es.CheckMapping(ctx)
+	if err != nil || !status.InSync() || status.Index != "salons_v2" {
+		t.Fatalf("fresh index: %+v, %v", status, err)
+	}
+
+	// Inline synonyms are part of the analysis settings, so changing them
+	// is drift; the same rules from a file are not
+	changed, _ := synonyms.Parse(strings.NewReader("uñas, manicura\n"))
+	other := newMappingClient(t, cluster, search.Config{Synonyms: changed})
+	if status, err := other.CheckMapping(ctx); err != nil || status.InSync() || !strings.Contains(status.String(), "expected v") {
+		t.Errorf("changed synonyms: %v, %v", status, err)
+	}
+
+	withPath := search.Config{SynonymsPath: "analysis/salon_synonyms.txt"}
+	a := newMappingClient(t, &fakeMappingCluster{}, withPath)
+	withPath.Synonyms = changed
+	b := newMappingClient(t, &fakeMappingCluster{}, withPath)
+	sa, _ := a.CheckMapping(ctx)
+	sb, _ := b.CheckMapping(ctx)
+	if sa.Expected.Hash != sb.Expected.Hash {
+		t.Error("rules read from a file should not change the mapping hash")
+	}
+
+	// Indexes created before versioned mappings have no _meta
+	cluster.mappings = json.RawMessage(`{"properties":{"name":{"type":"text"}}}`)
+	status, err = es.CheckMapping(ctx)
+	if err != nil || !status.Exists || status.Live != nil || status.InSync() {
+		t.Errorf("legacy index: %+v, %v", status, err)
+	}
+}
+
+// fakeAliasCluster records the requests of an index rebuild. aliased lists
+// the indexes behind the alias; legacy makes the alias name a concrete index
+// and rejected makes bulk requests reject every document.
+type fakeAliasCluster struct {
+	aliased  []string
+	legacy   bool
+	rejected bool
+	requests []string // "METHOD /path"
+	bulk     string
+	actions  string
+}
+
+func (f *fakeAliasCluster) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("X-Elastic-Product", "Elasticsearch")
+	w.Header().Set("Content-Type", "application/json")
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+	body, _ := io.ReadAll(r.Body)
+	switch {
+	case r.URL.Path == "/_alias/salons":
+		if len(f.aliased) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"alias [salons] missing","status":404}`))
+			return
+		}
+		out := map[string]interface{}{}
+		for _, name := range f.aliased {
+			out[name] = map[string]interface{}{"aliases": map[string]interface{}{"salons": map[string]interface{}{}}}
+		}
+		_ = json.NewEncoder(w).Encode(out)
+	case r.Method == http.MethodHead:
+		if f.legacy && r.URL.Path == "/salons" {
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	case r.URL.Path == "/_bulk":
+		f.bulk += string(body)
+		if f.rejected {
+			_, _ = w.Write([]byte(`{"errors":true,"items":[{"index":{"_id":"1","status":400,"error":{"type":"strict_dynamic_mapping_exception","reason":"mapping set to strict, dynamic introduction of [rating] within [_doc] is not allowed"}}}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"errors":false,"items":[]}`))
+	case r.URL.Path == "/_aliases":
+		f.actions = string(body)
+		_, _ = w.Write([]byte(`{"acknowledged":true}`))
+	default: // PUT and DELETE index
+		_, _ = w.Write([]byte(`{"acknowledged":true}`))
+	}
+}
+
+func TestRebuild_SwapsAlias(t *testing.T) {
+	ctx := context.Background()
+	load := func(bulk func([]domain.Salon) error) error {
+		return bulk([]domain.Salon{{ID: 1, Name: "Uno", IsActive: true}})
+	}
+
+	tests := []struct {
+		name     string
+		cluster  *fakeAliasCluster
+		replaced string
+	}{
+		{"alias over an older index", &fakeAliasCluster{aliased: []string{"salons_v2_20240101000000"}}, `"remove_index":{"index":"salons_v2_20240101000000"}`},
+		{"index named like the alias", &fakeAliasCluster{legacy: true}, `"remove_index":{"index":"salons"}`},
+		{"first index", &fakeAliasCluster{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			es := newAliasClient(t, tt.cluster)
+
+			index, err := es.Rebuild(ctx, load)
+			if err != nil {
+				t.Fatalf("Rebuild() error = %v", err)
+			}
+			if !strings.HasPrefix(index, "salons_v") || index == "salons" {
+				t.Errorf("index = %q, want a new versioned index", index)
+			}
+			if !strings.Contains(tt.cluster.bulk, `"_index":"`+index+`"`) {
+				t.Errorf("bulk request %q, want documents loaded into %s", tt.cluster.bulk, index)
+			}
+			if !strings.Contains(tt.cluster.actions, `"add":{"alias":"salons","index":"`+index+`"`) ||
+				!strings.Contains(tt.cluster.actions, tt.replaced) || (tt.replaced == "" && strings.Contains(tt.cluster.actions, "remove_index")) {
+				t.Errorf("alias actions = %s, want the alias moved and %s", tt.cluster.actions, tt.replaced)
+			}
+		})
+	}
+}
+
+func TestRebuild_FailedLoadKeepsAlias(t *testing.T) {
+	cluster := &fakeAliasCluster{aliased: []string{"salons_v2_20240101000000"}}
+	es := newAliasClient(t, cluster)
+
+	_, err := es.Rebuild(context.Background(), func(func([]domain.Salon) error) error {
+		return errors.New("cursor closed")
+	})
+	if err == nil {
+		t.Fatal("Rebuild() should fail with the load")
+	}
+	if cluster.actions != "" {
+		t.Errorf("alias actions = %s, want the alias left alone", cluster.actions)
+	}
+	last := cluster.requests[len(cluster.requests)-1]
+	if !strings.HasPrefix(last, "DELETE /salons_v") {
+		t.Errorf("last request = %q, want the partial index deleted", last)
+	}
+}
+
+func TestRebuild_RejectedDocumentsKeepAlias(t *testing.T) {
+	cluster := &fakeAliasCluster{aliased: []string{"salons_v2_20240101000000"}, rejected: true}
+	es := newAliasClient(t, cluster)
+
+	_, err := es.Rebuild(context.Background(), func(bulk func([]domain.Salon) error) error {
+		return bulk([]domain.Salon{{ID: 1, Name: "Uno", IsActive: true}})
+	})
+	if err == nil || !strings.Contains(err.Error(), "strict_dynamic_mapping_exception") {
+		t.Fatalf("Rebuild() error = %v, want the rejected documents reported", err)
+	}
+	if cluster.actions != "" {
+		t.Errorf("alias actions = %s, want the alias left on the complete index", cluster.actions)
+	}
+	last := cluster.requests[len(cluster.requests)-1]
+	if !strings.HasPrefix(last, "DELETE /salons_v") || strings.Contains(last, "salons_v2_20240101000000") {
+		t.Errorf("last request = %q, want the incomplete index deleted", last)
+	}
+}
+
+func newAliasClient(t *testing.T, cluster *fakeAliasCluster) *search.ElasticsearchClient {
+	t.Helper()
+	srv := httptest.NewServer(cluster)
+	t.Cleanup(srv.Close)
+	es, err := search.NewElasticsearchClient(search.Config{Addresses: []string{srv.URL}, Synonyms: synonyms.Default()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(es.Close)
+	return es
+}
@@ -0,0 +1,63 @@
+package unit
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"beauty-salons/internal/api/middleware"
+	"beauty-salons/internal/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+func scrapeMetrics(t *testing.T) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("/metrics status = %d", w.Code)
+	}
+	body, _ := io.ReadAll(w.Body)
+	return string(body)
+}
+
+func TestMetricsMiddleware_UsesRouteTemplate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(middleware.Metrics())
+	r.GET("/api/v1/salons/:id", func(c *gin.Context) {
+		c.Status(http.StatusNotFound)
+	})
+
+	for _, id := range []string{"1", "2", "3"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/salons/"+id, nil))
+	}
+
+	out := scrapeMetrics(t)
+	want := `beauty_salons_http_requests_total{method="GET",route="/api/v1/salons/:id",status="404"} 3`
+	if !strings.Contains(out, want) {
+		t.Errorf("metrics output missing %q", want)
+	}
+	if strings.Contains(out, `route="/api/v1/salons/1"`) {
+		t.Error("raw paths must not be used as route labels")
+	}
+}
+
+func TestObserveSearch_CountsZeroResults(t *testing.T) {
+	metrics.ObserveSearch("test_backend", 0)
+	metrics.ObserveSearch("test_backend", 12)
+
+	out := scrapeMetrics(t)
+	for _, want := range []string{
+		`beauty_salons_search_requests_total{backend="test_backend"} 2`,
+		`beauty_salons_search_zero_results_total{backend="test_backend"} 1`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("metrics output missing %q", want)
+		}
+	}
+}
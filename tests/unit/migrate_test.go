@@ -0,0 +1,92 @@
+package unit
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"beauty-salons/internal/migrate"
+	"beauty-salons/migrations"
+)
+
+func TestMigrate_Load(t *testing.T) {
+	fsys := fstest.MapFS{
+		"010_places.sql":          {Data: []byte("CREATE TABLE places ();")},
+		"002_seed.sql":            {Data: []byte("INSERT INTO x VALUES (1);")},
+		"down/002_seed.sql":       {Data: []byte("DELETE FROM x;")},
+		"README.md":               {Data: []byte("not a migration")},
+		"down/010_places.sql.bak": {Data: []byte("ignored")},
+	}
+	loaded, err := migrate.Load(fsys)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(loaded) != 2 || loaded[0].Version != 2 || loaded[1].Version != 10 || loaded[1].Name != "places" {
+		t.Fatalf("migrations = %+v", loaded)
+	}
+	if loaded[0].Down != "DELETE FROM x;" || loaded[1].Down != "" {
+		t.Errorf("down migrations = %q, %q", loaded[0].Down, loaded[1].Down)
+	}
+	if len(loaded[0].Checksum) != 64 || loaded[0].Checksum == loaded[1].Checksum {
+		t.Errorf("checksums = %q, %q", loaded[0].Checksum, loaded[1].Checksum)
+	}
+
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+		want string
+	}{
+		{"bad name", fstest.MapFS{"places.sql": {}}, "001_description.sql"},
+		{"duplicate version", fstest.MapFS{"001_a.sql": {}, "1_b.sql": {}}, "same version"},
+		{"orphan down", fstest.MapFS{"001_a.sql": {}, "down/002_b.sql": {}}, "no up migration"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := migrate.Load(tt.fsys); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMigrate_EmbeddedMigrations(t *testing.T) {
+	loaded, err := migrate.Load(migrations.FS)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(loaded) == 0 {
+		t.Fatal("no migrations embedded")
+	}
+	for i, m := range loaded {
+		if m.Version != int64(i+1) {
+			t.Errorf("migration %03d_%s: versions should have no gaps, want %03d", m.Version, m.Name, i+1)
+		}
+		if strings.TrimSpace(m.Down) == "" {
+			t.Errorf("migration %03d_%s has no down migration", m.Version, m.Name)
+		}
+		// Re-running a migration on a baselined database must not fail on
+		// indexes that already exist
+		for _, line := range strings.Split(m.Up, "\n") {
+			if strings.HasPrefix(line, "CREATE INDEX ") && !strings.HasPrefix(line, "CREATE INDEX IF NOT EXISTS ") {
+				t.Errorf("migration %03d_%s: %q should use IF NOT EXISTS", m.Version, m.Name, line)
+			}
+		}
+	}
+}
+
+func TestMigrate_StatusState(t *testing.T) {
+	tests := []struct {
+		status migrate.Status
+		want   string
+	}{
+		{migrate.Status{}, "pending"},
+		{migrate.Status{Applied: true}, "applied"},
+		{migrate.Status{Applied: true, Modified: true}, "modified"},
+		{migrate.Status{Applied: true, Missing: true}, "missing"},
+	}
+	for _, tt := range tests {
+		if got := tt.status.State(); got != tt.want {
+			t.Errorf("State(%+v) = %q, want %q", tt.status, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package unit
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"beauty-salons/internal/domain"
+	"beauty-salons/internal/places"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		in   string
+		want places.Query
+	}{
+		{"Plaza Colón", places.Query{Name: "plaza colon"}},
+		{"cerca de Plaza Colón", places.Query{Name: "plaza colon"}},
+		{"cerca de la Torre", places.Query{Name: "torre"}},
+		{"near Recoleta", places.Query{Name: "recoleta"}},
+		{"Güemes, Mar del Plata", places.Query{Name: "guemes", Qualifiers: []string{"mar del plata"}}},
+		{" Palermo , Buenos Aires, Argentina ", places.Query{Name: "palermo", Qualifiers: []string{"buenos aires", "argentina"}}},
+		// A prefix alone is the name
+		{"Cerca", places.Query{Name: "cerca"}},
+		{"En", places.Query{Name: "en"}},
+		{", Mar del Plata", places.Query{Qualifiers: []string{"mar del plata"}}},
+	}
+	for _, tt := range tests {
+		if got := places.ParseQuery(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseQuery(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParsePlacesCSV(t *testing.T) {
+	input := `# comment
+name,kind,city,state,country,latitude,longitude,radius_km
+Plaza Colón,landmark,Mar del Plata,Buenos Aires,Argentina,-38.003,-57.5412,
+Güemes,,Mar del Plata,Buenos Aires,Argentina,-38.012,-57.545,1
+Mar del Plata,CITY,,Buenos Aires,Argentina,-38.0055,-57.5426,
+`
+	got, err := places.ParseCSV(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseCSV() error = %v", err)
+	}
+	want := []domain.Place{
+		{Name: "Plaza Colón", Kind: domain.PlaceLandmark, City: "Mar del Plata", State: "Buenos Aires", Country: "Argentina",
+			Centroid: domain.GeoPoint{Latitude: -38.003, Longitude: -57.5412}, RadiusKm: places.DefaultLandmarkRadiusKm},
+		{Name: "Güemes", Kind: domain.PlaceNeighborhood, City: "Mar del Plata", State: "Buenos Aires", Country: "Argentina",
+			Centroid: domain.GeoPoint{Latitude: -38.012, Longitude: -57.545}, RadiusKm: 1},
+		{Name: "Mar del Plata", Kind: domain.PlaceCity, State: "Buenos Aires", Country: "Argentina",
+			Centroid: domain.GeoPoint{Latitude: -38.0055, Longitude: -57.5426}, RadiusKm: places.DefaultCityRadiusKm},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseCSV() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	if example, err := places.Load("../../places.example.csv"); err != nil || len(example) == 0 {
+		t.Errorf("places.example.csv: %d places, error %v", len(example), err)
+	}
+
+	header := "name,kind,city,state,country,latitude,longitude,radius_km\n"
+	invalid := map[string]string{
+		"wrong header":  "name,latitude,longitude\nCentro,-38,-57\n",
+		"unknown kind":  header + "Centro,district,,,,-38,-57,\n",
+		"no name":       header + ",landmark,,,,-38,-57,\n",
+		"bad latitude":  header + "Centro,,,,,-98,-57,\n",
+		"bad radius":    header + "Centro,,,,,-38,-57,wide\n",
+		"negative size": header + "Centro,,,,,-38,-57,-1\n",
+	}
+	for name, content := range invalid {
+		if _, err := places.ParseCSV(strings.NewReader(content)); err == nil {
+			t.Errorf("%s: ParseCSV() should fail", name)
+		}
+	}
+}
+
+func TestParsePlacesGeoJSON(t *testing.T) {
+	input := `{"type": "FeatureCollection", "features": [
+		{"type": "Feature", "properties": {"name": "Torreón del Monje", "kind": "landmark", "city": "Mar del Plata"},
+		 "geometry": {"type": "Point", "coordinates": [-57.5342, -38.0108]}},
+		{"type": "Feature", "properties": {"name": "La Perla", "city": "Mar del Plata"},
+		 "geometry": {"type": "Polygon", "coordinates": [[[-57.56, -38.0], [-57.54, -38.0], [-57.54, -37.98], [-57.56, -37.98], [-57.56, -38.0]]]}},
+		{"type": "Feature", "properties": {"name": "Islas", "radius_km": 3},
+		 "geometry": {"type": "MultiPolygon", "coordinates": [[[[-58, -34], [-57.9, -34], [-57.9, -33.9], [-58, -34]]], [[[-58.2, -34.2], [-58.1, -34.2], [-58.1, -34.1], [-58.2, -34.2]]]]}}
+	]}`
+	got, err := places.ParseGeoJSON(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseGeoJSON() error = %v", err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("got %d places, want 3", len(got))
+	}
+
+	if got[0].Centroid != (domain.GeoPoint{Latitude: -38.0108, Longitude: -57.5342}) || got[0].RadiusKm != places.DefaultLandmarkRadiusKm {
+		t.Errorf("point feature = %+v", got[0])
+	}
+
+	perla := got[1]
+	if perla.Kind != domain.PlaceNeighborhood || math.Abs(perla.Centroid.Latitude+37.99) > 1e-9 || math.Abs(perla.Centroid.Longitude+57.55) > 1e-9 {
+		t.Errorf("polygon feature = %+v, want neighborhood centered on -37.99,-57.55", perla)
+	}
+	// Northern corners are the farthest: meridians converge southwards
+	corner := domain.GeoPoint{Latitude: -37.98, Longitude: -57.56}
+	if math.Abs(perla.RadiusKm-perla.Centroid.DistanceTo(corner)) > 1e-9 {
+		t.Errorf("polygon radius = %v, want the distance to a northern corner", perla.RadiusKm)
+	}
+
+	if got[2].RadiusKm != 3 || math.Abs(got[2].Centroid.Latitude+34.05) > 1e-9 {
+		t.Errorf("multipolygon feature = %+v", got[2])
+	}
+
+	invalid := map[string]string{
+		"not a collection": `{"type": "Feature"}`,
+		"line string":      `{"type": "FeatureCollection", "features": [{"properties": {"name": "Costa"}, "geometry": {"type": "LineString", "coordinates": [[-57, -38], [-57.1, -38.1]]}}]}`,
+		"no name":          `{"type": "FeatureCollection", "features": [{"properties": {}, "geometry": {"type": "Point", "coordinates": [-57, -38]}}]}`,
+	}
+	for name, content := range invalid {
+		if _, err := places.ParseGeoJSON(strings.NewReader(content)); err == nil {
+			t.Errorf("%s: ParseGeoJSON() should fail", name)
+		}
+	}
+}
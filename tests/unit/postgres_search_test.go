@@ -0,0 +1,130 @@
+package unit
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"beauty-salons/internal/domain"
+	"beauty-salons/internal/migrate"
+	"beauty-salons/internal/repository"
+	"beauty-salons/internal/synonyms"
+	"beauty-salons/migrations"
+)
+
+// migratedRepository connects to TEST_DATABASE_URL, a disposable database
+// (e.g. the docker-compose one), and applies the migrations and seed data
+func migratedRepository(t *testing.T) *repository.PostgresRepository {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	repo, err := repository.NewPostgresRepository(url, repository.Config{Synonyms: synonyms.Default()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { repo.Close() })
+
+	migrator, err := migrate.New(repo.DB(), migrations.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("migrations failed: %v", err)
+	}
+	return repo
+}
+
+// searchSlugs returns the slugs of the first page of results for query
+func searchSlugs(t *testing.T, repo *repository.PostgresRepository, query string) ([]string, []domain.SalonSearchResult) {
+	t.Helper()
+	results, total, err := repo.SearchSalons(context.Background(), domain.SalonSearchParams{Query: query, Page: 1, PageSize: 10})
+	if err != nil {
+		t.Fatalf("SearchSalons(%q) error = %v", query, err)
+	}
+	if total < len(results) {
+		t.Errorf("SearchSalons(%q) total = %d, less than the %d results", query, total, len(results))
+	}
+	slugs := make([]string, len(results))
+	for i, result := range results {
+		slugs[i] = result.Salon.Slug
+	}
+	return slugs, results
+}
+
+func TestPostgresSearch_RankingAndHighlights(t *testing.T) {
+	repo := migratedRepository(t)
+
+	slugs, results := searchSlugs(t, repo, "barberia")
+	if len(results) == 0 || slugs[0] != "barberia-don-pedro" {
+		t.Fatalf("results = %v, want the salon named Barbería first", slugs)
+	}
+	for i, result := range results {
+		if result.Score <= 0 {
+			t.Errorf("%s: score %v, want text relevance", result.Salon.Slug, result.Score)
+		}
+		if i > 0 && result.Score > results[i-1].Score {
+			t.Errorf("results not ordered by score: %v after %v", result.Score, results[i-1].Score)
+		}
+	}
+
+	// The query is unaccented; the headline keeps the stored text, and
+	// fields without a match are left out as in Elasticsearch
+	top := results[0]
+	if name := top.Highlights["name"]; !strings.Contains(name, "<em>Barbería</em>") {
+		t.Errorf("name highlight = %q", name)
+	}
+	for field, fragment := range top.Highlights {
+		if !strings.Contains(fragment, "<em>") {
+			t.Errorf("%s highlight %q has no match", field, fragment)
+		}
+	}
+}
+
+func TestPostgresSearch_TrigramFallback(t *testing.T) {
+	repo := migratedRepository(t)
+
+	// A misspelled name matches no lexeme, only the name's trigrams
+	slugs, results := searchSlugs(t, repo, "Estilo Marr")
+	for i, slug := range slugs {
+		if slug == "estilo-mar" {
+			if results[i].Score <= 0 {
+				t.Errorf("score = %v, want the name similarity counted", results[i].Score)
+			}
+			return
+		}
+	}
+	t.Errorf("misspelled search found %v, not estilo-mar", slugs)
+}
+
+func TestPostgresSearch_Synonyms(t *testing.T) {
+	repo := migratedRepository(t)
+
+	// "manicure" appears in no salon; its synonym "manicura" does
+	slugs, _ := searchSlugs(t, repo, "manicure")
+	found := false
+	for _, slug := range slugs {
+		found = found || slug == "nail-studio-mdp"
+	}
+	if !found {
+		t.Errorf("search for a synonym found %v, not nail-studio-mdp", slugs)
+	}
+}
+
+func TestPostgresSearch_WithoutQuery(t *testing.T) {
+	repo := migratedRepository(t)
+
+	// Without a query only popularity ranks and nothing is highlighted
+	_, results := searchSlugs(t, repo, "")
+	if len(results) == 0 {
+		t.Fatal("no salons without a query")
+	}
+	for _, result := range results {
+		if result.Highlights != nil {
+			t.Errorf("%s: highlights %v without a query", result.Salon.Slug, result.Highlights)
+		}
+	}
+}
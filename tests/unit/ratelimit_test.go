@@ -0,0 +1,147 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"beauty-salons/internal/api/middleware"
+	"beauty-salons/internal/auth"
+	"beauty-salons/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMemoryStore_TokenBucket(t *testing.T) {
+	store := ratelimit.NewMemoryStore()
+	limit := ratelimit.Limit{PerMinute: 600, Burst: 3} // 10 tokens per second
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		res, _ := store.Take(ctx, "client", limit)
+		if !res.Allowed {
+			t.Fatalf("request %d should be allowed within the burst", i+1)
+		}
+		if res.Remaining != 2-i {
+			t.Errorf("request %d: Remaining = %d, want %d", i+1, res.Remaining, 2-i)
+		}
+	}
+
+	res, _ := store.Take(ctx, "client", limit)
+	if res.Allowed {
+		t.Fatal("request beyond the burst should be rejected")
+	}
+	if res.RetryAfter <= 0 || res.RetryAfter > 100*time.Millisecond {
+		t.Errorf("RetryAfter = %v, want at most one token interval (100ms)", res.RetryAfter)
+	}
+
+	// Other clients have their own bucket
+	if res, _ := store.Take(ctx, "other", limit); !res.Allowed {
+		t.Error("a different key should not share the bucket")
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	if res, _ := store.Take(ctx, "client", limit); !res.Allowed {
+		t.Error("bucket should refill over time")
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	authn := auth.NewAPIKeyAuthenticator([]auth.APIKey{
+		{Key: "partner-key", Subject: "partner", Roles: []auth.Role{auth.RolePublic}},
+	})
+	limit := ratelimit.Limit{PerMinute: 1, Burst: 2}
+
+	r := gin.New()
+	r.Use(middleware.Authenticate(authn))
+	r.GET("/search", middleware.RateLimit(ratelimit.NewMemoryStore(), "search", limit), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	do := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/search", nil)
+		req.RemoteAddr = "203.0.113.7:1234"
+		if key != "" {
+			req.Header.Set(auth.APIKeyHeader, key)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	for i := 0; i < 2; i++ {
+		if w := do(""); w.Code != http.StatusOK {
+			t.Fatalf("request %d: status = %d, want 200", i+1, w.Code)
+		}
+	}
+
+	w := do("")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("429 response should include Retry-After")
+	}
+	if got := w.Header().Get("RateLimit-Limit"); got != "2" {
+		t.Errorf("RateLimit-Limit = %q, want 2", got)
+	}
+	if got := w.Header().Get("RateLimit-Remaining"); got != "0" {
+		t.Errorf("RateLimit-Remaining = %q, want 0", got)
+	}
+
+	// An API key gets its own budget even from the same IP
+	if w := do("partner-key"); w.Code != http.StatusOK {
+		t.Errorf("authenticated client: status = %d, want 200", w.Code)
+	}
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("store unavailable")
+}
+
+func TestRateLimitMiddleware_FailsOpen(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.GET("/search", middleware.RateLimit(failingStore{}, "search", ratelimit.Limit{PerMinute: 1, Burst: 1}), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/search", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200 when the store is unavailable", w.Code)
+	}
+}
+
+func TestRateLimit_IgnoresForwardedForWithoutTrustedProxies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	// As cmd/api does with an empty HTTP_TRUSTED_PROXIES
+	if err := r.SetTrustedProxies(nil); err != nil {
+		t.Fatal(err)
+	}
+	r.GET("/search", middleware.RateLimit(ratelimit.NewMemoryStore(), "search", ratelimit.Limit{PerMinute: 1, Burst: 1}), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	codes := make([]int, 2)
+	for i, forwarded := range []string{"198.51.100.1", "198.51.100.2"} {
+		req := httptest.NewRequest(http.MethodGet, "/search", nil)
+		req.RemoteAddr = "203.0.113.7:1234"
+		req.Header.Set("X-Forwarded-For", forwarded)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		codes[i] = w.Code
+	}
+	if codes[0] != http.StatusOK || codes[1] != http.StatusTooManyRequests {
+		t.Errorf("statuses = %v, want a spoofed X-Forwarded-For to share the connection's bucket", codes)
+	}
+}
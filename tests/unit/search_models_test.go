@@ -0,0 +1,206 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"beauty-salons/internal/api/handlers"
+	"beauty-salons/internal/domain"
+	"beauty-salons/internal/search"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fullSalon has every Salon field set
+func fullSalon() domain.Salon {
+	created := time.Date(2024, 3, 1, 10, 30, 0, 0, time.UTC)
+	updated := time.Date(2024, 6, 15, 8, 0, 0, 123000000, time.UTC)
+	description, serviceDescription := "Cortes y color", "Lavado incluido"
+	rating, priceMin, priceMax, duration := 4.6, 5000.0, 9000.0, 45
+	categoryID := int64(3)
+	return domain.Salon{
+		ID:          42,
+		Name:        "Estudio Norte",
+		Slug:        "estudio-norte",
+		Description: &description,
+		Location: domain.Location{
+			Address:       "Av. Colón 1234",
+			City:          "Mar del Plata",
+			State:         "Buenos Aires",
+			PostalCode:    "B7600",
+			Country:       "AR",
+			GeoPoint:      &domain.GeoPoint{Latitude: -38.0, Longitude: -57.55},
+			GeocodeStatus: domain.GeocodeOK,
+		},
+		Contact:     domain.Contact{Phone: "+54 223 555-0000", Email: "hola@norte.ar", Website: "https://norte.ar"},
+		CategoryID:  &categoryID,
+		PriceRange:  domain.PriceUpscale,
+		Rating:      &rating,
+		ReviewCount: 87,
+		IsActive:    true,
+		IsVerified:  true,
+		CreatedAt:   created,
+		UpdatedAt:   updated,
+		Category:    &domain.Category{ID: categoryID, Name: "Peluquería", Slug: "peluqueria", CreatedAt: created},
+		Services: []domain.Service{{
+			ID: 7, SalonID: 42, Name: "Corte", Description: &serviceDescription,
+			PriceMin: &priceMin, PriceMax: &priceMax, DurationMinutes: &duration, CreatedAt: created,
+		}},
+		Amenities: []domain.Amenity{{ID: 1, Name: "WiFi", Icon: "wifi"}},
+		OperatingHours: []domain.OperatingHours{
+			{ID: 11, SalonID: 42, DayOfWeek: 1, OpenTime: "09:00:00", CloseTime: "19:00:00"},
+			{ID: 12, SalonID: 42, DayOfWeek: 0, IsClosed: true},
+		},
+	}
+}
+
+func TestSalonDocument_RoundTrip(t *testing.T) {
+	// Index the salon, then serve the indexed document back as a hit
+	var indexed []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodPut {
+			indexed, _ = io.ReadAll(r.Body)
+			_, _ = w.Write([]byte(`{"result":"created"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"hits":{"total":{"value":1},"hits":[{"_score":1.0,"_source":` + string(indexed) + `}]}}`))
+	}))
+	t.Cleanup(srv.Close)
+	es, err := search.NewElasticsearchClient(search.Config{Addresses: []string{srv.URL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(es.Close)
+
+	want := fullSalon()
+	if err := es.IndexSalon(context.Background(), &want); err != nil {
+		t.Fatalf("IndexSalon() error = %v", err)
+	}
+	results, _, _, err := es.Search(context.Background(), domain.SalonSearchParams{Page: 1, PageSize: 10})
+	if err != nil || len(results) != 1 {
+		t.Fatalf("Search() = %d results, %v", len(results), err)
+	}
+	if got := results[0].Salon; !reflect.DeepEqual(got, want) {
+		gotJSON, _ := json.Marshal(got)
+		wantJSON, _ := json.Marshal(want)
+		t.Errorf("round trip lost data:\n got %s\nwant %s", gotJSON, wantJSON)
+	}
+}
+
+func TestSalonDocument_LegacyFields(t *testing.T) {
+	// Documents indexed before mapping v3 have amenity names and category_name
+	es := fakeSearchCluster(t, `{"hits":{"total":{"value":1},"hits":[{"_source":
+		{"id":1,"name":"Viejo","category_id":2,"category_name":"Barbería","amenities":["WiFi","Parking"]}}]}}`)
+
+	results, _, _, err := es.Search(context.Background(), domain.SalonSearchParams{Page: 1, PageSize: 10})
+	if err != nil || len(results) != 1 {
+		t.Fatalf("Search() = %d results, %v", len(results), err)
+	}
+	salon := results[0].Salon
+	if salon.Category == nil || salon.Category.Name != "Barbería" || salon.Category.ID != 2 {
+		t.Errorf("Category = %+v", salon.Category)
+	}
+	if len(salon.Amenities) != 2 || salon.Amenities[1].Name != "Parking" {
+		t.Errorf("Amenities = %+v", salon.Amenities)
+	}
+}
+
+func TestSearch_PartialShardFailures(t *testing.T) {
+	// No hits.total (track_total_hits=false), null scores and keyword sort
+	// values must not break decoding
+	es := fakeSearchCluster(t, `{
+		"timed_out": true,
+		"_shards": {"total": 3, "successful": 1, "skipped": 0, "failed": 2, "failures": [
+			{"shard": 0, "index": "salons_v3", "node": "n1", "reason": {"type": "query_shard_exception", "reason": "failed to create query"}},
+			{"shard": 2, "index": "salons_v3", "node": "n2", "reason": {"type": "query_shard_exception", "reason": "failed to create query"}}
+		]},
+		"hits": {"hits": [
+			{"_score": null, "_source": {"id": 1, "name": "A"}, "sort": ["a", null]},
+			{"_score": null, "_source": {"id": 2, "name": "B"}, "sort": ["b", 3]}
+		]}
+	}`)
+
+	results, total, warnings, err := es.Search(context.Background(), domain.SalonSearchParams{Page: 2, PageSize: 10})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(results) != 2 || total != 12 {
+		t.Errorf("got %d results (total %d), want 2 (total 12 counted from the page)", len(results), total)
+	}
+	joined := strings.Join(warnings, "\n")
+	if len(warnings) != 3 || !strings.Contains(joined, "2 of 3 shards failed") ||
+		!strings.Contains(joined, "query_shard_exception") || !strings.Contains(joined, "timed out") {
+		t.Errorf("warnings = %q, want the failed shards once per reason and the timeout", warnings)
+	}
+}
+
+func TestSearch_AllShardsFailed(t *testing.T) {
+	es := fakeSearchCluster(t, `{
+		"_shards": {"total": 1, "successful": 0, "failed": 1, "failures": [
+			{"shard": 0, "index": "salons", "reason": {"type": "illegal_argument_exception", "reason": "no mapping for [rating]"}}
+		]},
+		"hits": {"total": {"value": 0}, "hits": []}
+	}`)
+
+	_, _, _, err := es.Search(context.Background(), domain.SalonSearchParams{Page: 1, PageSize: 10})
+	if err == nil || !strings.Contains(err.Error(), "no mapping for [rating]") {
+		t.Errorf("Search() error = %v, want the shard failure reason", err)
+	}
+}
+
+// exportOnce is an exporter with a single batch of salons
+type exportOnce []domain.Salon
+
+func (e exportOnce) ExportSalons(_ context.Context, _ domain.SalonSearchParams, fn func([]domain.Salon) error) error {
+	return fn(e)
+}
+
+func TestSyncToElasticsearch_IndexesEveryField(t *testing.T) {
+	cluster := &fakeAliasCluster{}
+	es := newAliasClient(t, cluster)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/admin/sync", handlers.NewHandler(nil, es, handlers.WithExporter(exportOnce{fullSalon()})).SyncToElasticsearch)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/sync", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"count":1`) {
+		t.Fatalf("sync = %d %s", w.Code, w.Body)
+	}
+
+	// Fields the sync used to leave out
+	for _, want := range []string{`"geocode_status":"ok"`, `"slug":"peluqueria"`, `"duration_minutes":45`, `"open_time":"09:00:00"`} {
+		if !strings.Contains(cluster.bulk, want) {
+			t.Errorf("indexed document lacks %s: %s", want, cluster.bulk)
+		}
+	}
+	if !strings.Contains(cluster.actions, `"alias":"salons"`) {
+		t.Errorf("alias actions = %q, want the alias moved to the synced index", cluster.actions)
+	}
+}
+
+func TestSyncToElasticsearch_RejectedDocumentsFail(t *testing.T) {
+	cluster := &fakeAliasCluster{aliased: []string{"salons_v3_20240101000000"}, rejected: true}
+	es := newAliasClient(t, cluster)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/admin/sync", handlers.NewHandler(nil, es, handlers.WithExporter(exportOnce{fullSalon()})).SyncToElasticsearch)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/sync", nil))
+	if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), "rejected 1 of 1 documents") {
+		t.Errorf("sync = %d %s, want it failed with the rejected documents", w.Code, w.Body)
+	}
+	if cluster.actions != "" {
+		t.Errorf("alias actions = %q, want the alias left on the complete index", cluster.actions)
+	}
+}
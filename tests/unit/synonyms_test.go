@@ -0,0 +1,156 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"beauty-salons/internal/search"
+	"beauty-salons/internal/synonyms"
+)
+
+func TestSynonyms_DefaultRulesParse(t *testing.T) {
+	set := synonyms.Default()
+	if set.Len() == 0 {
+		t.Fatal("built-in synonyms should not be empty")
+	}
+	if len(set.Rules()) != set.Len() {
+		t.Errorf("Rules() has %d lines for %d groups", len(set.Rules()), set.Len())
+	}
+}
+
+func TestSynonyms_Parse(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		groups  int
+		wantErr bool
+	}{
+		{"groups and comments", "# comment\n\nbarbería, barber\nuñas, nails\n", 2, false},
+		{"explicit mapping", "barber => barbería\n", 0, true},
+		{"single term", "barbería\n", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set, err := synonyms.Parse(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && set.Len() != tt.groups {
+				t.Errorf("Len() = %d, want %d", set.Len(), tt.groups)
+			}
+		})
+	}
+}
+
+func TestSynonyms_Expand(t *testing.T) {
+	set, err := synonyms.Parse(strings.NewReader("barbería, barber, barber shop\nuñas acrílicas, acrilico\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"Barber", []string{"barberia", "barber shop"}},
+		{"barbería centro", []string{"barber centro", "barber shop centro"}},
+		{"barber shop", []string{"barberia", "barber"}},
+		{"Uñas Acrílicas", []string{"acrilico"}},
+		{"masaje", nil},
+	}
+
+	for _, tt := range tests {
+		got := set.Expand(tt.query)
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("Expand(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+
+	var none *synonyms.Set
+	if got := none.Expand("barber"); got != nil {
+		t.Errorf("nil set Expand() = %q, want nil", got)
+	}
+}
+
+func TestSynonyms_Tokens(t *testing.T) {
+	got := synonyms.Tokens("Peluquería  Uñas-Acrílicas")
+	want := []string{"peluqueria", "unas", "acrilicas"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("Tokens() = %q, want %q", got, want)
+	}
+}
+
+// createIndexSettings runs CreateIndex against a fake cluster and returns
+// the salon_synonyms filter it sent
+func createIndexSettings(t *testing.T, cfg search.Config) map[string]interface{} {
+	t.Helper()
+
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		body, _ = io.ReadAll(r.Body)
+		_, _ = w.Write([]byte(`{"acknowledged":true}`))
+	}))
+	defer srv.Close()
+
+	cfg.Addresses = []string{srv.URL}
+	es, err := search.NewElasticsearchClient(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer es.Close()
+
+	if err := es.CreateIndex(context.Background()); err != nil {
+		t.Fatalf("CreateIndex() error = %v", err)
+	}
+
+	var mapping struct {
+		Settings struct {
+			Analysis struct {
+				Filter map[string]map[string]interface{} `json:"filter"`
+			} `json:"analysis"`
+		} `json:"settings"`
+	}
+	if err := json.Unmarshal(body, &mapping); err != nil {
+		t.Fatalf("invalid create index body: %v", err)
+	}
+	filter := mapping.Settings.Analysis.Filter["salon_synonyms"]
+	if filter["type"] != "synonym_graph" {
+		t.Fatalf("salon_synonyms filter = %v, want a synonym_graph filter", filter)
+	}
+	return filter
+}
+
+func TestCreateIndex_SynonymsFile(t *testing.T) {
+	filter := createIndexSettings(t, search.Config{SynonymsPath: "analysis/salon_synonyms.txt"})
+
+	if filter["synonyms_path"] != "analysis/salon_synonyms.txt" {
+		t.Errorf("synonyms_path = %v", filter["synonyms_path"])
+	}
+	if filter["updateable"] != true {
+		t.Error("file based synonyms should be updateable")
+	}
+}
+
+func TestCreateIndex_InlineSynonyms(t *testing.T) {
+	filter := createIndexSettings(t, search.Config{Synonyms: synonyms.Default()})
+
+	rules, ok := filter["synonyms"].([]interface{})
+	if !ok || len(rules) != synonyms.Default().Len() {
+		t.Errorf("inline synonyms = %v, want the built-in rules", filter["synonyms"])
+	}
+	if _, ok := filter["synonyms_path"]; ok {
+		t.Error("inline synonyms should not reference a file")
+	}
+}
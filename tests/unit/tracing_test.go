@@ -0,0 +1,89 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"beauty-salons/internal/api/middleware"
+	"beauty-salons/internal/tracing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracingMiddleware_ContinuesIncomingTrace(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	prevTP, prevProp := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer func() {
+		otel.SetTracerProvider(prevTP)
+		otel.SetTextMapPropagator(prevProp)
+	}()
+
+	r := gin.New()
+	r.Use(middleware.Tracing())
+	r.GET("/api/v1/salons/:id", func(c *gin.Context) {
+		// Child spans started from the request context join the same trace
+		_, span := tracing.Tracer().Start(c.Request.Context(), "child")
+		span.End()
+		c.Status(http.StatusOK)
+	})
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/salons/42", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("recorded %d spans, want 2", len(spans))
+	}
+
+	server := spans[1]
+	if server.Name() != "GET /api/v1/salons/:id" {
+		t.Errorf("span name = %q, want route template", server.Name())
+	}
+	for _, s := range spans {
+		if s.SpanContext().TraceID().String() != traceID {
+			t.Errorf("span %q trace ID = %s, want %s", s.Name(), s.SpanContext().TraceID(), traceID)
+		}
+	}
+	if spans[0].Parent().SpanID() != server.SpanContext().SpanID() {
+		t.Error("child span should be parented to the server span")
+	}
+}
+
+func TestTracingSetup(t *testing.T) {
+	prevTP, prevProp := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	defer func() {
+		otel.SetTracerProvider(prevTP)
+		otel.SetTextMapPropagator(prevProp)
+	}()
+
+	for _, exporter := range []string{tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterOTLP} {
+		shutdown, err := tracing.Setup(context.Background(), tracing.Config{
+			Exporter:    exporter,
+			Endpoint:    "http://localhost:4318",
+			ServiceName: "test",
+			SampleRatio: 1,
+		})
+		if err != nil {
+			t.Errorf("Setup(%s) error = %v", exporter, err)
+			continue
+		}
+		_ = shutdown(context.Background())
+	}
+
+	if _, err := tracing.Setup(context.Background(), tracing.Config{Exporter: "zipkin"}); err == nil {
+		t.Error("Setup() should reject unknown exporters")
+	}
+}
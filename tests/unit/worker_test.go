@@ -0,0 +1,64 @@
+package unit
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"beauty-salons/internal/worker"
+)
+
+func TestGroup_ShutdownDrainsWorkers(t *testing.T) {
+	g := worker.NewGroup()
+
+	var flushed atomic.Bool
+	g.Go(func(ctx context.Context) {
+		<-g.Stopping()
+		// Simulate flushing buffered work after the stop signal
+		time.Sleep(10 * time.Millisecond)
+		flushed.Store(ctx.Err() == nil)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := g.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if !flushed.Load() {
+		t.Error("worker context was cancelled before it finished draining")
+	}
+}
+
+func TestGroup_ShutdownDeadline(t *testing.T) {
+	g := worker.NewGroup()
+
+	cancelled := make(chan struct{})
+	g.Go(func(ctx context.Context) {
+		<-ctx.Done()
+		close(cancelled)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := g.Shutdown(ctx); err == nil {
+		t.Error("Shutdown() should fail when workers exceed the deadline")
+	}
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Error("worker context was not cancelled after the deadline")
+	}
+}
+
+func TestGroup_GoAfterShutdown(t *testing.T) {
+	g := worker.NewGroup()
+	_ = g.Shutdown(context.Background())
+
+	if g.Go(func(ctx context.Context) {}) {
+		t.Error("Go() = true after Shutdown, want false")
+	}
+}